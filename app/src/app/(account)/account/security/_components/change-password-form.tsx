@@ -7,7 +7,7 @@ import { z } from 'zod';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
-import { changePassword, setPassword } from '@/lib/auth/api';
+import { changePassword, setPassword, reauthenticate } from '@/lib/auth/api';
 import { useAuth } from '@/hooks/use-auth';
 import { Loader2, Check, AlertCircle } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
@@ -60,6 +60,12 @@ export const ChangePasswordForm = ({ hasPassword }: ChangePasswordFormProps) =>
     setSuccess(false);
 
     try {
+      const reauth = await reauthenticate({ password: data.current_password });
+      if (!reauth.success) {
+        setError(reauth.error_code === 'INVALID_PASSWORD' ? 'La contraseña actual es incorrecta' : reauth.error || 'Error al verificar tu identidad');
+        return;
+      }
+
       const response = await changePassword({ current_password: data.current_password, new_password: data.new_password });
 
       if (response.success) {
@@ -87,7 +93,8 @@ export const ChangePasswordForm = ({ hasPassword }: ChangePasswordFormProps) =>
         setForm.reset();
         await refreshUser();
         setTimeout(() => setSuccess(false), 3000);
-      } else setError(response.error || 'Error al establecer contraseña');
+      } else if (response.error_code === 'REAUTH_REQUIRED') setError('Por seguridad, vuelve a iniciar sesión y establece la contraseña en los 5 minutos siguientes');
+      else setError(response.error || 'Error al establecer contraseña');
     } catch {
       setError('Error de conexión. Intenta de nuevo.');
     } finally {
@@ -138,7 +145,7 @@ export const ChangePasswordForm = ({ hasPassword }: ChangePasswordFormProps) =>
         </form>
       ) : (
         <form onSubmit={setForm.handleSubmit(onSetPassword)} className="space-y-4">
-          <p className="text-sm text-muted-foreground">Tu cuenta fue creada con Google. Puedes establecer una contraseña para iniciar sesión también con email y contraseña.</p>
+          <p className="text-sm text-muted-foreground">Tu cuenta fue creada con un proveedor externo (Google, GitHub, Microsoft u otro). Puedes establecer una contraseña para iniciar sesión también con email y contraseña.</p>
 
           <div className="space-y-2">
             <Label htmlFor="new_password_set">Nueva Contraseña</Label>
@@ -5,7 +5,7 @@ import { Button } from '@/components/ui/button';
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle } from '@/components/ui/dialog';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
-import { unlinkGoogleAccount, getGoogleOAuthUrl } from '@/lib/auth/api';
+import { unlinkOAuthAccount, linkOAuthAccount, reauthenticate } from '@/lib/auth/api';
 import { useAuth } from '@/hooks/use-auth';
 import { Loader2, Link2, Unlink, AlertCircle } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
@@ -24,10 +24,21 @@ export const LinkedAccounts = ({ providers, hasPassword }: LinkedAccountsProps)
 
   const hasGoogle = providers.includes('google');
 
-  const handleLinkGoogle = () => {
-    const callbackUrl = `${window.location.origin}/auth/callback`;
-    const googleUrl = getGoogleOAuthUrl(callbackUrl);
-    window.location.href = googleUrl;
+  const handleLinkGoogle = async () => {
+    setIsLoading(true);
+    setError(null);
+
+    try {
+      const response = await linkOAuthAccount('google', `${window.location.origin}/account/security`);
+
+      if (response.success && response.data) window.location.href = response.data.authorization_url;
+      else if (response.error_code === 'REAUTH_REQUIRED') setError('Por seguridad, vuelve a iniciar sesión y vincula la cuenta en los 5 minutos siguientes');
+      else setError(response.error || 'Error al vincular cuenta');
+    } catch {
+      setError('Error de conexión');
+    } finally {
+      setIsLoading(false);
+    }
   };
 
   const handleUnlinkGoogle = async () => {
@@ -35,13 +46,22 @@ export const LinkedAccounts = ({ providers, hasPassword }: LinkedAccountsProps)
     setError(null);
 
     try {
-      const response = await unlinkGoogleAccount(hasPassword ? password : undefined);
+      if (hasPassword) {
+        const reauth = await reauthenticate({ password });
+        if (!reauth.success) {
+          setError(reauth.error_code === 'INVALID_PASSWORD' ? 'Contraseña incorrecta' : reauth.error || 'Error al verificar tu identidad');
+          return;
+        }
+      }
+
+      const response = await unlinkOAuthAccount('google');
 
       if (response.success) {
         setShowUnlinkDialog(false);
         setPassword('');
         await refreshUser();
-      } else setError(response.error || 'Error al desvincular cuenta');
+      } else if (response.error_code === 'REAUTH_REQUIRED') setError('Por seguridad, vuelve a iniciar sesión y desvincula la cuenta en los 5 minutos siguientes');
+      else setError(response.error || 'Error al desvincular cuenta');
     } catch {
       setError('Error de conexión');
     } finally {
@@ -71,7 +91,7 @@ export const LinkedAccounts = ({ providers, hasPassword }: LinkedAccountsProps)
             Desvincular
           </Button>
         ) : (
-          <Button variant="outline" size="sm" onClick={handleLinkGoogle}>
+          <Button variant="outline" size="sm" onClick={handleLinkGoogle} disabled={isLoading}>
             <Link2 className="mr-2 h-4 w-4" />
             Vincular
           </Button>
@@ -9,7 +9,7 @@ import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle, DialogTrigger } from '@/components/ui/dialog';
-import { enable2FA, verify2FAEnable, disable2FA, regenerateBackupCodes } from '@/lib/auth/api';
+import { enable2FA, verify2FAEnable, disable2FA, regenerateBackupCodes, reauthenticate } from '@/lib/auth/api';
 import { useAuth } from '@/hooks/use-auth';
 import { Loader2, Shield, ShieldOff, Copy, Check, AlertCircle } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
@@ -58,7 +58,8 @@ export const TwoFactorSettings = ({ enabled }: TwoFactorSettingsProps) => {
       if (response.success && response.data) {
         setSetupData(response.data);
         setShowEnableDialog(true);
-      } else setError(response.error || 'Error al iniciar configuración 2FA');
+      } else if (response.error_code === 'REAUTH_REQUIRED') setError('Por seguridad, vuelve a iniciar sesión y activa 2FA en los 5 minutos siguientes');
+      else setError(response.error || 'Error al iniciar configuración 2FA');
     } catch {
       setError('Error de conexión');
     } finally {
@@ -83,7 +84,8 @@ export const TwoFactorSettings = ({ enabled }: TwoFactorSettingsProps) => {
         setShowEnableDialog(false);
         setShowBackupCodes(true);
         await refreshUser();
-      } else setError(response.error || 'Código inválido');
+      } else if (response.error_code === 'REAUTH_REQUIRED') setError('Por seguridad, vuelve a iniciar sesión y activa 2FA en los 5 minutos siguientes');
+      else setError(response.error || 'Código inválido');
     } catch {
       setError('Error de conexión');
     } finally {
@@ -96,7 +98,13 @@ export const TwoFactorSettings = ({ enabled }: TwoFactorSettingsProps) => {
     setError(null);
 
     try {
-      const response = await disable2FA(data);
+      const reauth = await reauthenticate({ password: data.password });
+      if (!reauth.success) {
+        setError(reauth.error_code === 'INVALID_PASSWORD' ? 'Contraseña incorrecta' : reauth.error || 'Error al verificar tu identidad');
+        return;
+      }
+
+      const response = await disable2FA({ code: data.code });
 
       if (response.success) {
         setShowDisableDialog(false);
@@ -116,7 +124,13 @@ export const TwoFactorSettings = ({ enabled }: TwoFactorSettingsProps) => {
     setIsLoading(true);
 
     try {
-      const response = await regenerateBackupCodes({ code });
+      const reauth = await reauthenticate({ code });
+      if (!reauth.success) {
+        alert(reauth.error_code === 'INVALID_CODE' ? 'Código inválido' : reauth.error || 'Error al verificar tu identidad');
+        return;
+      }
+
+      const response = await regenerateBackupCodes();
 
       if (response.success && response.data) {
         setBackupCodes(response.data.backup_codes);
@@ -5,7 +5,7 @@ import { Button } from '@/components/ui/button';
 import { Card, CardContent } from '@/components/ui/card';
 import { AlertDialog, AlertDialogAction, AlertDialogCancel, AlertDialogContent } from '@/components/ui/alert-dialog';
 import { AlertDialogDescription, AlertDialogFooter, AlertDialogHeader, AlertDialogTitle, AlertDialogTrigger } from '@/components/ui/alert-dialog';
-import { getSessions, revokeSession, revokeAllSessions } from '@/lib/auth/api';
+import { getSessions, revokeSession, revokeAllSessions, reauthenticate } from '@/lib/auth/api';
 import type { SessionInfo } from '@/lib/auth/types';
 import { Loader2, Monitor, Smartphone, Globe, Trash2, LogOut, AlertCircle } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
@@ -56,7 +56,26 @@ export const SessionsList = () => {
     setIsLoading(true);
 
     try {
-      const response = await revokeAllSessions(false);
+      let response = await revokeAllSessions(false);
+
+      if (response.error_code === 'REAUTH_REQUIRED') {
+        const methods = (response.details?.methods as string[] | undefined) ?? [];
+        if (!methods.includes('password')) {
+          setError('Por seguridad, vuelve a iniciar sesión para cerrar las demás sesiones');
+          return;
+        }
+
+        const password = prompt('Ingresa tu contraseña para cerrar las demás sesiones:');
+        if (!password) return;
+
+        const reauth = await reauthenticate({ password });
+        if (!reauth.success) {
+          setError(reauth.error_code === 'INVALID_PASSWORD' ? 'Contraseña incorrecta' : reauth.error || 'Error al verificar tu identidad');
+          return;
+        }
+
+        response = await revokeAllSessions(false);
+      }
 
       if (response.success) await loadSessions();
       else setError(response.error || 'Error al revocar sesiones');
@@ -67,13 +86,24 @@ export const SessionsList = () => {
     }
   };
 
-  const getDeviceIcon = (userAgent?: string) => {
-    if (!userAgent) return <Globe className="h-5 w-5" />;
-    if (userAgent.toLowerCase().includes('mobile')) return <Smartphone className="h-5 w-5" />;
+  const getDeviceIcon = ({ device_type, user_agent }: SessionInfo['device_info']) => {
+    if (device_type === 'mobile' || device_type === 'tablet') return <Smartphone className="h-5 w-5" />;
+    if (device_type) return <Monitor className="h-5 w-5" />;
+    if (!user_agent) return <Globe className="h-5 w-5" />;
+    if (user_agent.toLowerCase().includes('mobile')) return <Smartphone className="h-5 w-5" />;
 
     return <Monitor className="h-5 w-5" />;
   };
 
+  const getDeviceName = ({ browser, os }: SessionInfo['device_info']) => {
+    if (browser && os) return `${browser} en ${os}`;
+    return browser || os || null;
+  };
+
+  const getLocation = ({ location }: SessionInfo['device_info']) => {
+    return [location?.city, location?.country].filter(Boolean).join(', ');
+  };
+
   const formatDate = (dateString: string) => {
     return new Date(dateString).toLocaleString('es', { dateStyle: 'medium', timeStyle: 'short' });
   };
@@ -123,16 +153,21 @@ export const SessionsList = () => {
           <Card key={session.id}>
             <CardContent className="flex items-center justify-between py-4">
               <div className="flex items-center gap-4">
-                <div className="p-2 bg-muted rounded-lg">{getDeviceIcon(session.device_info?.user_agent)}</div>
+                <div className="p-2 bg-muted rounded-lg">{getDeviceIcon(session.device_info ?? {})}</div>
                 <div>
                   <div className="flex items-center gap-2">
-                    <p className="font-medium text-sm">{session.device_info?.ip_address || 'Dispositivo desconocido'}</p>
+                    <p className="font-medium text-sm">{getDeviceName(session.device_info ?? {}) || session.device_info?.ip_address || 'Dispositivo desconocido'}</p>
                     {session.current && (
                       <Badge variant="secondary" className="text-xs">
                         Sesión actual
                       </Badge>
                     )}
                   </div>
+                  {getLocation(session.device_info ?? {}) && (
+                    <p className="text-xs text-muted-foreground">
+                      {getLocation(session.device_info ?? {})} · {session.device_info?.ip_address}
+                    </p>
+                  )}
                   <p className="text-xs text-muted-foreground">Última actividad: {formatDate(session.last_activity_at)}</p>
                   <p className="text-xs text-muted-foreground">Iniciada: {formatDate(session.created_at)}</p>
                 </div>
@@ -10,7 +10,7 @@ import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
 import { Card, CardContent, CardDescription, CardFooter, CardHeader, CardTitle } from '@/components/ui/card';
-import { login, getCurrentUser, getGoogleOAuthUrl } from '@/lib/auth/api';
+import { login, getCurrentUser, getOAuthUrl } from '@/lib/auth/api';
 import { useAuthActions, useAuth } from '@/hooks/use-auth';
 import { Loader2, Mail, Lock, AlertCircle } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
@@ -53,6 +53,11 @@ export const SignInForm = () => {
         return;
       }
 
+      if (response.requires_step_up && response.data && 'temp_token' in response.data) {
+        router.push(`/auth/verify-2fa?temp_token=${response.data.temp_token}&step_up=1`);
+        return;
+      }
+
       // obtener datos del usuario y actualizar el contexto
       const userResponse = await getCurrentUser();
       if (userResponse.success && userResponse.data) setUser(userResponse.data);
@@ -69,7 +74,7 @@ export const SignInForm = () => {
 
   const handleGoogleLogin = () => {
     const callbackUrl = `${window.location.origin}/auth/callback`;
-    const googleUrl = getGoogleOAuthUrl(callbackUrl);
+    const googleUrl = getOAuthUrl('google', callbackUrl);
     window.location.href = googleUrl;
   };
 
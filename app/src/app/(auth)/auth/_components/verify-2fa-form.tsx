@@ -9,9 +9,9 @@ import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Label } from '@/components/ui/label';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
-import { verify2FA, verifyBackupCode, getCurrentUser } from '@/lib/auth/api';
+import { verify2FA, verifyBackupCode, verifyLoginStepUp, getCurrentUser } from '@/lib/auth/api';
 import { useAuthActions, useAuth } from '@/hooks/use-auth';
-import { Loader2, Shield, AlertCircle, Key } from 'lucide-react';
+import { Loader2, Shield, AlertCircle, Key, Mail } from 'lucide-react';
 import { Alert, AlertDescription } from '@/components/ui/alert';
 
 const verify2FASchema = z.object({
@@ -31,6 +31,8 @@ export const Verify2FAForm = () => {
   const { getRedirectUrl } = useAuthActions();
   const { login: setUser } = useAuth();
   const tempToken = searchParams.get('temp_token');
+  // login sospechoso: el código llega por correo y no hay códigos de respaldo
+  const stepUp = searchParams.get('step_up') === '1';
   const [isLoading, setIsLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
   const [useBackupCode, setUseBackupCode] = useState(false);
@@ -63,7 +65,7 @@ export const Verify2FAForm = () => {
     setError(null);
 
     try {
-      const response = await verify2FA({ temp_token: tempToken, code: data.code });
+      const response = stepUp ? await verifyLoginStepUp({ temp_token: tempToken, code: data.code }) : await verify2FA({ temp_token: tempToken, code: data.code });
 
       if (response.success) await handleSuccessfulAuth();
       else setError(response.error || 'Código inválido');
@@ -115,9 +117,11 @@ export const Verify2FAForm = () => {
   return (
     <Card>
       <CardHeader className="space-y-1">
-        <div className="flex justify-center mb-4">{useBackupCode ? <Key className="h-12 w-12 text-primary" /> : <Shield className="h-12 w-12 text-primary" />}</div>
-        <CardTitle className="text-2xl font-bold text-center">Verificación en Dos Pasos</CardTitle>
-        <CardDescription className="text-center">{useBackupCode ? 'Ingresa uno de tus códigos de respaldo' : 'Ingresa el código de tu aplicación de autenticación'}</CardDescription>
+        <div className="flex justify-center mb-4">{stepUp ? <Mail className="h-12 w-12 text-primary" /> : useBackupCode ? <Key className="h-12 w-12 text-primary" /> : <Shield className="h-12 w-12 text-primary" />}</div>
+        <CardTitle className="text-2xl font-bold text-center">{stepUp ? 'Confirma tu Inicio de Sesión' : 'Verificación en Dos Pasos'}</CardTitle>
+        <CardDescription className="text-center">
+          {stepUp ? 'Detectamos un acceso inusual. Ingresa el código que enviamos a tu correo' : useBackupCode ? 'Ingresa uno de tus códigos de respaldo' : 'Ingresa el código de tu aplicación de autenticación'}
+        </CardDescription>
       </CardHeader>
       <CardContent className="space-y-4">
         {error && (
@@ -155,18 +159,20 @@ export const Verify2FAForm = () => {
           </form>
         )}
 
-        <div className="text-center">
-          <Button
-            variant="link"
-            onClick={() => {
-              setUseBackupCode(!useBackupCode);
-              setError(null);
-            }}
-            disabled={isLoading}
-          >
-            {useBackupCode ? 'Usar código de autenticación' : '¿No tienes acceso? Usa código de respaldo'}
-          </Button>
-        </div>
+        {!stepUp && (
+          <div className="text-center">
+            <Button
+              variant="link"
+              onClick={() => {
+                setUseBackupCode(!useBackupCode);
+                setError(null);
+              }}
+              disabled={isLoading}
+            >
+              {useBackupCode ? 'Usar código de autenticación' : '¿No tienes acceso? Usa código de respaldo'}
+            </Button>
+          </div>
+        )}
       </CardContent>
     </Card>
   );
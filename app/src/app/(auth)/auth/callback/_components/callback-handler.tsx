@@ -1,8 +1,8 @@
 'use client';
 
-import { useEffect } from 'react';
+import { useEffect, useRef } from 'react';
 import { useRouter, useSearchParams } from 'next/navigation';
-import { setTokens } from '@/lib/auth/tokens';
+import { exchangeOAuthCode } from '@/lib/auth/api';
 import { useAuthActions } from '@/hooks/use-auth';
 import { Loader2 } from 'lucide-react';
 
@@ -10,12 +10,11 @@ export const CallbackHandler = () => {
   const router = useRouter();
   const searchParams = useSearchParams();
   const { getRedirectUrl } = useAuthActions();
+  // the code is single use, don't exchange it twice when the effect re-runs
+  const exchanged = useRef(false);
 
   useEffect(() => {
-    const accessToken = searchParams.get('access_token');
-    const refreshToken = searchParams.get('refresh_token');
-    const tempToken = searchParams.get('temp_token');
-    const requires2FA = searchParams.get('requires_2fa');
+    const code = searchParams.get('code');
     const error = searchParams.get('error');
 
     if (error) {
@@ -23,20 +22,33 @@ export const CallbackHandler = () => {
       return;
     }
 
-    if (requires2FA && tempToken) {
-      router.push(`/auth/verify-2fa?temp_token=${tempToken}`);
+    if (!code) {
+      router.push('/auth/sign-in?error=invalid_callback');
       return;
     }
 
-    if (accessToken && refreshToken) {
-      setTokens(accessToken, refreshToken);
-      const redirectUrl = getRedirectUrl();
-      router.push(redirectUrl);
-      router.refresh();
-      return;
-    }
+    if (exchanged.current) return;
+    exchanged.current = true;
+
+    exchangeOAuthCode(code).then((response) => {
+      if (!response.success || !response.data) {
+        router.push(`/auth/sign-in?error=${response.error_code?.toLowerCase() || 'invalid_callback'}`);
+        return;
+      }
 
-    router.push('/auth/sign-in?error=invalid_callback');
+      if (response.requires_2fa && 'temp_token' in response.data) {
+        router.push(`/auth/verify-2fa?temp_token=${response.data.temp_token}`);
+        return;
+      }
+
+      if (response.requires_step_up && 'temp_token' in response.data) {
+        router.push(`/auth/verify-2fa?temp_token=${response.data.temp_token}&step_up=1`);
+        return;
+      }
+
+      router.push(getRedirectUrl());
+      router.refresh();
+    });
   }, [searchParams, router, getRedirectUrl]);
 
   return (
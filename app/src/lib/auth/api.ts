@@ -1,4 +1,4 @@
-import { getAccessToken, getRefreshToken, setTokens, setAccessToken, clearTokens } from './tokens';
+import { getAccessToken, getRefreshToken, setAccessToken, setTokens, clearTokens, setMagicLinkDeviceSecret, getMagicLinkDeviceSecret, removeMagicLinkDeviceSecret } from './tokens';
 import type {
   ApiResponse,
   LoginRequest,
@@ -12,7 +12,9 @@ import type {
   BackupCodesStatusResponse,
 } from './types';
 import type { RequestPasswordResetRequest, ResetPasswordRequest, RefreshResponse, User, UpdateProfileRequest, ChangePasswordRequest, SetPasswordRequest, Enable2FAResponse } from './types';
-import type { Enable2FAVerifyRequest, Disable2FARequest, Regenerate2FABackupCodesRequest, RegenerateBackupCodesResponse, SessionInfo, LoginAttemptInfo, AccountStatus } from './types';
+import type { Enable2FAVerifyRequest, Disable2FARequest, RegenerateBackupCodesResponse, ReauthRequest, ReauthResponse, SessionInfo, LoginAttemptInfo, AccountStatus } from './types';
+import type { Passkey, WebAuthnChallengeResponse, MagicLinkRequestResponse, EmailChangeRequest, EmailChangeResponse, AuditEventInfo, SecurityEventsFilter } from './types';
+import type { PersonalAccessToken, CreateTokenRequest, CreatedToken } from './types';
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8000';
 const API_V1 = `${API_BASE_URL}/api/v1`;
@@ -62,6 +64,14 @@ export const login = async (credentials: LoginRequest): Promise<LoginApiResponse
   return response as LoginApiResponse;
 };
 
+export const verifyLoginStepUp = async (data: Verify2FARequest): Promise<ApiResponse<LoginResponse>> => {
+  const response = await request<LoginResponse>('/auth/login/step-up', { method: 'POST', body: JSON.stringify(data) });
+
+  if (response.success && response.data) setTokens(response.data.access_token, response.data.refresh_token);
+
+  return response;
+};
+
 export const register = async (data: RegisterRequest): Promise<ApiResponse<RegisterResponse>> => {
   return request<RegisterResponse>('/auth/register', { method: 'POST', body: JSON.stringify(data) });
 };
@@ -90,7 +100,7 @@ export const refreshToken = async (): Promise<boolean> => {
     const data: ApiResponse<RefreshResponse> = await response.json();
 
     if (data.success && data.data) {
-      setAccessToken(data.data.access_token);
+      setTokens(data.data.access_token, data.data.refresh_token);
       return true;
     }
 
@@ -102,6 +112,28 @@ export const refreshToken = async (): Promise<boolean> => {
   }
 };
 
+// -- re-authentication
+
+export const reauthenticate = async (data: ReauthRequest): Promise<ApiResponse<ReauthResponse>> => {
+  const response = await request<ReauthResponse>('/auth/reauth', { method: 'POST', body: JSON.stringify(data) });
+
+  if (response.success && response.data) setAccessToken(response.data.access_token);
+
+  return response;
+};
+
+export const beginPasskeyReauth = async (): Promise<ApiResponse<WebAuthnChallengeResponse>> => {
+  return request<WebAuthnChallengeResponse>('/auth/reauth/webauthn/begin', { method: 'POST' });
+};
+
+export const finishPasskeyReauth = async (challengeId: string, credential: unknown): Promise<ApiResponse<ReauthResponse>> => {
+  const response = await request<ReauthResponse>('/auth/reauth/webauthn/finish', { method: 'POST', body: JSON.stringify({ challenge_id: challengeId, credential }) });
+
+  if (response.success && response.data) setAccessToken(response.data.access_token);
+
+  return response;
+};
+
 // -- 2fa
 
 export const verify2FA = async (data: Verify2FARequest): Promise<ApiResponse<LoginResponse>> => {
@@ -132,14 +164,33 @@ export const disable2FA = async (data: Disable2FARequest): Promise<ApiResponse<v
   return request<void>('/auth/2fa/disable', { method: 'POST', body: JSON.stringify(data) });
 };
 
-export const regenerateBackupCodes = async (data: Regenerate2FABackupCodesRequest): Promise<ApiResponse<RegenerateBackupCodesResponse>> => {
-  return request<RegenerateBackupCodesResponse>('/auth/2fa/regenerate-backup-codes', { method: 'POST', body: JSON.stringify(data) });
+export const regenerateBackupCodes = async (): Promise<ApiResponse<RegenerateBackupCodesResponse>> => {
+  return request<RegenerateBackupCodesResponse>('/auth/2fa/regenerate-backup-codes', { method: 'POST' });
 };
 
 export const getBackupCodesStatus = async (): Promise<ApiResponse<BackupCodesStatusResponse>> => {
   return request<BackupCodesStatusResponse>('/auth/2fa/backup-codes/status', { method: 'GET' });
 };
 
+// -- magic link
+
+export const requestMagicLink = async (email: string): Promise<ApiResponse<MagicLinkRequestResponse>> => {
+  const response = await request<MagicLinkRequestResponse>('/auth/magic-link/request', { method: 'POST', body: JSON.stringify({ email }) });
+
+  if (response.success && response.data) setMagicLinkDeviceSecret(response.data.device_secret, response.data.expires_in);
+
+  return response;
+};
+
+export const consumeMagicLink = async (token: string): Promise<LoginApiResponse> => {
+  const response = await request<LoginResponse>('/auth/magic-link/consume', { method: 'POST', body: JSON.stringify({ token, device_secret: getMagicLinkDeviceSecret() ?? '' }) });
+
+  if (response.success) removeMagicLinkDeviceSecret();
+  if (response.success && response.data && 'access_token' in response.data) setTokens(response.data.access_token, response.data.refresh_token);
+
+  return response as LoginApiResponse;
+};
+
 // -- password
 
 export const requestPasswordReset = async (data: RequestPasswordResetRequest): Promise<ApiResponse<void>> => {
@@ -172,6 +223,34 @@ export const deleteProfileImage = async (): Promise<ApiResponse<void>> => {
   return request<void>('/auth/me/profile', { method: 'DELETE' });
 };
 
+export const requestEmailChange = async (data: EmailChangeRequest): Promise<ApiResponse<EmailChangeResponse>> => {
+  return request<EmailChangeResponse>('/auth/me/email', { method: 'POST', body: JSON.stringify(data) });
+};
+
+export const confirmEmailChange = async (token: string): Promise<ApiResponse<{ email: string }>> => {
+  return request<{ email: string }>('/auth/me/email/confirm', { method: 'POST', body: JSON.stringify({ token }) });
+};
+
+export const cancelEmailChange = async (token: string): Promise<ApiResponse<void>> => {
+  return request<void>('/auth/email-change/cancel', { method: 'POST', body: JSON.stringify({ token }) });
+};
+
+// -- personal data
+
+export const exportPersonalData = async (format: 'json' | 'zip' = 'json'): Promise<Blob | null> => {
+  const accessToken = getAccessToken();
+  const response = await fetch(`${API_V1}/auth/me/export?format=${format}`, { headers: accessToken ? { Authorization: `Bearer ${accessToken}` } : {} });
+  return response.ok ? await response.blob() : null;
+};
+
+export const deleteAccount = async (): Promise<ApiResponse<{ deletion_scheduled_at: string }>> => {
+  return request<{ deletion_scheduled_at: string }>('/auth/me/delete', { method: 'POST' });
+};
+
+export const cancelAccountDeletion = async (): Promise<ApiResponse<void>> => {
+  return request<void>('/auth/me/delete/cancel', { method: 'POST' });
+};
+
 // -- sessions
 
 export const getSessions = async (): Promise<ApiResponse<{ sessions: SessionInfo[] }>> => {
@@ -186,26 +265,106 @@ export const revokeAllSessions = async (includeCurrent = false): Promise<ApiResp
   return request<{ revoked_count: number }>('/auth/sessions/all', { method: 'DELETE', body: JSON.stringify({ include_current: includeCurrent }) });
 };
 
+// -- personal access tokens
+
+export const getTokens = async (): Promise<ApiResponse<PersonalAccessToken[]>> => {
+  return request<PersonalAccessToken[]>('/auth/tokens', { method: 'GET' });
+};
+
+export const createToken = async (data: CreateTokenRequest): Promise<ApiResponse<CreatedToken>> => {
+  return request<CreatedToken>('/auth/tokens', { method: 'POST', body: JSON.stringify(data) });
+};
+
+export const revokeToken = async (id: string): Promise<ApiResponse<void>> => {
+  return request<void>(`/auth/tokens/${id}`, { method: 'DELETE' });
+};
+
 // -- security
 
 export const getLoginHistory = async (limit = 20, offset = 0): Promise<ApiResponse<{ total: number; limit: number; offset: number; attempts: LoginAttemptInfo[] }>> => {
   return request(`/auth/security/login-history?limit=${limit}&offset=${offset}`, { method: 'GET' });
 };
 
+export const getSecurityEvents = async (filter: SecurityEventsFilter = {}): Promise<ApiResponse<{ total: number; limit: number; offset: number; events: AuditEventInfo[] }>> => {
+  const params = new URLSearchParams();
+  Object.entries(filter).forEach(([key, value]) => {
+    if (value !== undefined && value !== '') params.set(key, String(value));
+  });
+  return request(`/auth/security/events?${params.toString()}`, { method: 'GET' });
+};
+
 export const checkAccountStatus = async (email: string): Promise<ApiResponse<AccountStatus>> => {
   return request<AccountStatus>('/auth/security/status', { method: 'POST', body: JSON.stringify({ email }) });
 };
 
 // -- oauth
 
-export const getGoogleOAuthUrl = (redirectUri: string): string => {
-  return `${API_V1}/auth/oauth/google?redirect_uri=${encodeURIComponent(redirectUri)}`;
+export const getOAuthProviders = async (): Promise<ApiResponse<{ providers: string[] }>> => {
+  return request<{ providers: string[] }>('/auth/oauth/providers', { method: 'GET' });
+};
+
+export const getOAuthUrl = (provider: string, redirectUri: string): string => {
+  return `${API_V1}/auth/oauth/${provider}?redirect_uri=${encodeURIComponent(redirectUri)}`;
+};
+
+export const exchangeOAuthCode = async (code: string): Promise<LoginApiResponse> => {
+  const response = await request<LoginResponse>('/auth/oauth/exchange', { method: 'POST', body: JSON.stringify({ code }) });
+
+  if (response.success && response.data && 'access_token' in response.data) setTokens(response.data.access_token, response.data.refresh_token);
+
+  return response as LoginApiResponse;
+};
+
+export const linkOAuthAccount = async (provider: string, redirectUri: string): Promise<ApiResponse<{ provider: string; authorization_url: string }>> => {
+  return request(`/auth/oauth/${provider}/link`, { method: 'POST', body: JSON.stringify({ redirect_uri: redirectUri }) });
+};
+
+export const unlinkOAuthAccount = async (provider: string): Promise<ApiResponse<void>> => {
+  return request(`/auth/oauth/${provider}/unlink`, { method: 'DELETE' });
+};
+
+// -- passkeys
+
+export const beginPasskeyRegistration = async (): Promise<ApiResponse<WebAuthnChallengeResponse>> => {
+  return request<WebAuthnChallengeResponse>('/auth/webauthn/register/begin', { method: 'POST' });
+};
+
+export const finishPasskeyRegistration = async (challengeId: string, credential: unknown, name?: string): Promise<ApiResponse<Passkey>> => {
+  return request<Passkey>('/auth/webauthn/register/finish', { method: 'POST', body: JSON.stringify({ challenge_id: challengeId, name, credential }) });
+};
+
+export const getPasskeys = async (): Promise<ApiResponse<Passkey[]>> => {
+  return request<Passkey[]>('/auth/webauthn/credentials', { method: 'GET' });
+};
+
+export const renamePasskey = async (id: string, name: string): Promise<ApiResponse<Passkey>> => {
+  return request<Passkey>(`/auth/webauthn/credentials/${id}`, { method: 'PATCH', body: JSON.stringify({ name }) });
+};
+
+export const deletePasskey = async (id: string): Promise<ApiResponse<void>> => {
+  return request<void>(`/auth/webauthn/credentials/${id}`, { method: 'DELETE' });
+};
+
+export const beginPasskeyLogin = async (): Promise<ApiResponse<WebAuthnChallengeResponse>> => {
+  return request<WebAuthnChallengeResponse>('/auth/webauthn/login/begin', { method: 'POST' });
 };
 
-export const linkGoogleAccount = async (googleAuthCode: string): Promise<ApiResponse<{ provider: string; provider_email: string }>> => {
-  return request('/auth/oauth/google/link', { method: 'POST', body: JSON.stringify({ google_auth_code: googleAuthCode }) });
+export const finishPasskeyLogin = async (challengeId: string, credential: unknown): Promise<ApiResponse<LoginResponse>> => {
+  const response = await request<LoginResponse>('/auth/webauthn/login/finish', { method: 'POST', body: JSON.stringify({ challenge_id: challengeId, credential }) });
+
+  if (response.success && response.data) setTokens(response.data.access_token, response.data.refresh_token);
+
+  return response;
 };
 
-export const unlinkGoogleAccount = async (password?: string): Promise<ApiResponse<void>> => {
-  return request('/auth/oauth/google/unlink', { method: 'DELETE', body: JSON.stringify({ password }) });
+export const begin2FAPasskey = async (tempToken: string): Promise<ApiResponse<WebAuthnChallengeResponse>> => {
+  return request<WebAuthnChallengeResponse>('/auth/2fa/webauthn/begin', { method: 'POST', body: JSON.stringify({ temp_token: tempToken }) });
+};
+
+export const verify2FAPasskey = async (tempToken: string, challengeId: string, credential: unknown): Promise<ApiResponse<LoginResponse>> => {
+  const response = await request<LoginResponse>('/auth/2fa/webauthn/verify', { method: 'POST', body: JSON.stringify({ temp_token: tempToken, challenge_id: challengeId, credential }) });
+
+  if (response.success && response.data) setTokens(response.data.access_token, response.data.refresh_token);
+
+  return response;
 };
@@ -5,6 +5,7 @@ import type { JWTPayload } from './types';
 const ACCESS_TOKEN_KEY = 'access_token';
 const REFRESH_TOKEN_KEY = 'refresh_token';
 const AUTH_ORIGIN_KEY = 'auth_origin';
+const MAGIC_LINK_DEVICE_KEY = 'magic_link_device';
 
 const cookieOptions: Cookies.CookieAttributes = {
   secure: process.env.NODE_ENV === 'production',
@@ -65,6 +66,21 @@ export const removeAuthOrigin = (): void => {
   Cookies.remove(AUTH_ORIGIN_KEY, { path: '/' });
 };
 
+// -- magic link device secret
+
+export const setMagicLinkDeviceSecret = (secret: string, expiresIn: number): void => {
+  Cookies.set(MAGIC_LINK_DEVICE_KEY, secret, { ...cookieOptions, expires: expiresIn / (60 * 60 * 24) });
+};
+
+export const getMagicLinkDeviceSecret = (): string | undefined => {
+  if (typeof window === 'undefined') return undefined;
+  return Cookies.get(MAGIC_LINK_DEVICE_KEY);
+};
+
+export const removeMagicLinkDeviceSecret = (): void => {
+  Cookies.remove(MAGIC_LINK_DEVICE_KEY, { path: '/' });
+};
+
 // -- helpers
 
 export const setTokens = (accessToken: string, refreshToken: string): void => {
@@ -8,10 +8,12 @@ export interface User {
   role: 'support' | 'admin' | 'dev';
   active: boolean;
   two_factor_enabled: boolean;
+  permissions: string[];
   oauth_providers: string[];
   has_password: boolean;
   created_at: string;
   last_login_at?: string;
+  deletion_scheduled_at?: string;
 }
 
 export interface LoginUser {
@@ -70,6 +72,11 @@ export interface RequestPasswordResetRequest {
   email: string;
 }
 
+export interface MagicLinkRequestResponse {
+  device_secret: string;
+  expires_in: number;
+}
+
 export interface ResetPasswordRequest {
   token: string;
   new_password: string;
@@ -90,18 +97,28 @@ export interface UpdateProfileRequest {
   profile?: string;
 }
 
+export interface EmailChangeRequest {
+  new_email: string;
+}
+
+export interface EmailChangeResponse {
+  pending_email: string;
+  expires_at: string;
+}
+
 export interface Enable2FAVerifyRequest {
   setup_token: string;
   code: string;
 }
 
 export interface Disable2FARequest {
-  password: string;
   code: string;
 }
 
-export interface Regenerate2FABackupCodesRequest {
-  code: string;
+// exactly one of password or code (TOTP)
+export interface ReauthRequest {
+  password?: string;
+  code?: string;
 }
 
 // -- response types
@@ -112,7 +129,16 @@ export interface ApiResponse<T = unknown> {
   data?: T;
   error?: string;
   error_code?: string;
-  details?: Record<string, string>;
+  details?: Record<string, string | number | string[] | PasswordViolation[]>;
+}
+
+// returned under the password field with error_code PASSWORD_POLICY
+export type PasswordViolationCode = 'too_short' | 'too_long' | 'too_weak' | 'contains_personal_info' | 'breached' | 'reused';
+
+export interface PasswordViolation {
+  code: PasswordViolationCode;
+  message: string;
+  params?: Record<string, number>;
 }
 
 export interface LoginResponse {
@@ -123,15 +149,29 @@ export interface LoginResponse {
   user: LoginUser;
 }
 
+export type SecondFactorMethod = 'totp' | 'webauthn';
+
 export interface Login2FAResponse {
   temp_token: string;
   message: string;
+  methods: SecondFactorMethod[];
+}
+
+export type LoginRiskReason = 'new_device' | 'new_network' | 'unusual_time' | 'failed_attempts' | 'impossible_travel';
+
+export interface LoginStepUpResponse {
+  temp_token: string;
+  message: string;
+  methods: 'email'[];
+  reasons: LoginRiskReason[];
+  expires_in: number;
 }
 
 export interface LoginApiResponse {
   success: boolean;
   requires_2fa?: boolean;
-  data?: LoginResponse | Login2FAResponse;
+  requires_step_up?: boolean;
+  data?: LoginResponse | Login2FAResponse | LoginStepUpResponse;
   error?: string;
   error_code?: string;
 }
@@ -144,10 +184,20 @@ export interface RegisterResponse {
 
 export interface RefreshResponse {
   access_token: string;
+  refresh_token: string;
   token_type: string;
   expires_in: number;
 }
 
+// amr values: pwd, otp, hwk, email, fed
+export interface ReauthResponse {
+  access_token: string;
+  token_type: string;
+  expires_in: number;
+  auth_time: string;
+  amr: string[];
+}
+
 export interface Enable2FAResponse {
   secret: string;
   qr_code: string;
@@ -165,26 +215,113 @@ export interface BackupCodesStatusResponse {
   remaining_codes: number;
 }
 
+export type DeviceType = 'desktop' | 'mobile' | 'tablet' | 'bot';
+
+export interface DeviceDetails {
+  browser?: string;
+  browser_version?: string;
+  os?: string;
+  os_version?: string;
+  device_type?: DeviceType;
+}
+
+export interface GeoLocation {
+  country_code?: string;
+  country?: string;
+  city?: string;
+  latitude?: number;
+  longitude?: number;
+  asn?: number;
+  as_org?: string;
+}
+
 export interface SessionInfo {
   id: string;
-  device_info: {
+  device_info: DeviceDetails & {
     user_agent?: string;
     ip_address?: string;
+    location?: GeoLocation;
   };
   created_at: string;
   last_activity_at: string;
   current: boolean;
 }
 
-export interface LoginAttemptInfo {
+export interface LoginAttemptInfo extends DeviceDetails {
   id: string;
   success: boolean;
   failure_reason?: string;
   ip_address?: string;
   user_agent?: string;
+  location?: GeoLocation;
+  created_at: string;
+}
+
+export interface AuditEventInfo {
+  id: string;
+  event_type: string;
+  actor_id?: string;
+  target_id?: string;
+  ip_address?: string;
+  user_agent?: string;
+  metadata: Record<string, unknown>;
   created_at: string;
 }
 
+export interface SecurityEventsFilter {
+  type?: string;
+  from?: string;
+  to?: string;
+  ip?: string;
+  limit?: number;
+  offset?: number;
+}
+
+export interface Passkey {
+  id: string;
+  name: string;
+  clone_warning: boolean;
+  user_verified: boolean;
+  backup_eligible: boolean;
+  backup_state: boolean;
+  created_at: string;
+  updated_at: string;
+  last_used_at?: string | null;
+}
+
+export type TokenScope = 'profile:read' | 'profile:write' | 'sessions:read' | 'sessions:write' | 'security:read' | 'admin:read' | 'admin:write';
+
+export interface PersonalAccessToken {
+  id: string;
+  user_id: string;
+  name: string;
+  token_prefix: string;
+  scopes: TokenScope[];
+  expires_at: string;
+  created_at: string;
+  last_used_at?: string | null;
+  last_used_ip?: string | null;
+  last_used_user_agent?: string | null;
+  revoked: boolean;
+}
+
+export interface CreateTokenRequest {
+  name: string;
+  scopes: TokenScope[];
+  expires_in_days?: number;
+}
+
+// token is only returned once, when it's created
+export interface CreatedToken extends PersonalAccessToken {
+  token: string;
+}
+
+// options go to navigator.credentials.create()/get() as-is (e.g. via @simplewebauthn/browser)
+export interface WebAuthnChallengeResponse<T = Record<string, unknown>> {
+  challenge_id: string;
+  options: { publicKey: T };
+}
+
 export interface AccountStatus {
   exists: boolean;
   has_password: boolean;
@@ -2,6 +2,7 @@ package actions
 
 import (
 	"os"
+	"server/ratelimit"
 	"testing"
 
 	"github.com/gobuffalo/suite/v4"
@@ -22,3 +23,9 @@ func Test_ActionSuite(t *testing.T) {
 	}
 	suite.Run(t, as)
 }
+
+// SetupTest also gives each test empty rate limit buckets.
+func (as *ActionSuite) SetupTest() {
+	as.Action.SetupTest()
+	rateLimitStore = ratelimit.NewMemoryStore()
+}
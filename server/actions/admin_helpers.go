@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+type AdminUserInfo struct {
+	ID               string     `json:"id"`
+	Email            string     `json:"email"`
+	EmailVerified    bool       `json:"email_verified"`
+	Name             string     `json:"name"`
+	LastName         string     `json:"last_name"`
+	Role             string     `json:"role"`
+	Active           bool       `json:"active"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	LastLoginAt      *time.Time `json:"last_login_at,omitempty"`
+}
+
+func newAdminUserInfo(user models.User) AdminUserInfo {
+	return AdminUserInfo{
+		ID:               user.ID.String(),
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified,
+		Name:             user.Name,
+		LastName:         user.LastName,
+		Role:             user.Role,
+		Active:           user.Active,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
+		LastLoginAt:      user.LastLoginAt,
+	}
+}
+
+// findTargetUser loads the user referenced by the {user_id} route param.
+func findTargetUser(c buffalo.Context, tx *pop.Connection) (models.User, error) {
+	var user models.User
+	userID, err := uuid.FromString(c.Param("user_id"))
+	if err != nil {
+		return user, err
+	}
+	err = tx.Find(&user, userID)
+	return user, err
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term, for patterns
+// used with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
@@ -0,0 +1,108 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"strconv"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+func AdminUsersList(c buffalo.Context) error {
+	limit, offset := parseLimitOffset(c)
+
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	details := map[string]any{}
+
+	if role := strings.TrimSpace(c.Param("role")); role != "" {
+		if !ValidRoles[role] {
+			details["role"] = "Role must be: support, admin, or dev"
+		}
+		conditions = append(conditions, "role = ?")
+		args = append(args, role)
+	}
+
+	boolFilters := []struct {
+		param  string
+		column string
+	}{
+		{"active", "active"},
+		{"email_verified", "email_verified"},
+		{"two_factor_enabled", "two_factor_enabled"},
+	}
+	for _, f := range boolFilters {
+		value := c.Param(f.param)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			details[f.param] = "Must be true or false"
+			continue
+		}
+		conditions = append(conditions, f.column+" = ?")
+		args = append(args, parsed)
+	}
+
+	if search := strings.TrimSpace(c.Param("q")); search != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(search)) + "%"
+		conditions = append(conditions, `(email LIKE ? ESCAPE '\' OR LOWER(name) LIKE ? ESCAPE '\' OR LOWER(last_name) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	if len(details) > 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   details,
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	tx.RawQuery("SELECT COUNT(*) FROM auth.users WHERE "+where, args...).First(&total)
+
+	var users []models.User
+	err := tx.RawQuery(`
+		SELECT * FROM auth.users
+		WHERE `+where+`
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...).All(&users)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to list users",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	userInfos := make([]AdminUserInfo, len(users))
+	for i, user := range users {
+		userInfos[i] = newAdminUserInfo(user)
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"users":  userInfos,
+		},
+	}))
+}
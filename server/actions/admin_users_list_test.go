@@ -0,0 +1,30 @@
+package actions
+
+import "net/http"
+
+func (as *ActionSuite) Test_AdminUsersList_SearchMatchesWildcardsLiterally() {
+	as.grant(RoleAdmin, PermissionUsersRead)
+
+	admin := as.createUser("admin@example.com", RoleAdmin)
+	as.createUser("first_last@example.com", RoleSupport)
+	as.createUser("firstxlast@example.com", RoleSupport)
+	accessToken, _ := as.signIn(admin)
+
+	res := as.authJSON(accessToken, "/api/v1/admin/users?q=first_last").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	var body struct {
+		Data struct {
+			Total int             `json:"total"`
+			Users []AdminUserInfo `json:"users"`
+		} `json:"data"`
+	}
+	res.Bind(&body)
+	as.Equal(1, body.Data.Total)
+	as.Len(body.Data.Users, 1)
+	as.Equal("first_last@example.com", body.Data.Users[0].Email)
+
+	res = as.authJSON(accessToken, "/api/v1/admin/users?q=%%25").Get()
+	res.Bind(&body)
+	as.Equal(0, body.Data.Total)
+}
@@ -0,0 +1,64 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+func AdminUsersLoginHistory(c buffalo.Context) error {
+	limit, offset := parseLimitOffset(c)
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var total int
+	tx.RawQuery("SELECT COUNT(*) FROM auth.login_attempts WHERE user_id = ?", user.ID).First(&total)
+
+	var attempts []models.LoginAttempt
+	err = tx.RawQuery(`
+		SELECT * FROM auth.login_attempts
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, user.ID, limit, offset).All(&attempts)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to get login history",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	attemptInfos := make([]LoginAttemptInfo, len(attempts))
+	for i, attempt := range attempts {
+		attemptInfos[i] = newLoginAttemptInfo(attempt)
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"total":    total,
+			"limit":    limit,
+			"offset":   offset,
+			"attempts": attemptInfos,
+		},
+	}))
+}
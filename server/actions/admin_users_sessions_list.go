@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+func AdminUsersSessionsList(c buffalo.Context) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var sessions []models.Session
+	err = tx.Where("user_id = ? AND revoked = ? AND expires_at > ?",
+		user.ID, false, time.Now().UTC()).Order("last_activity_at DESC").All(&sessions)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to get sessions",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	sessionInfos := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		sessionInfos[i] = SessionInfo{
+			ID:             session.ID.String(),
+			DeviceInfo:     session.DeviceInfo,
+			CreatedAt:      session.CreatedAt,
+			LastActivityAt: session.LastActivityAt,
+		}
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"sessions": sessionInfos,
+		},
+	}))
+}
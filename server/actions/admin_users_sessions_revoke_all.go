@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// AdminUsersSessionsRevokeAll force-logs a user out of every device.
+func AdminUsersSessionsRevokeAll(c buffalo.Context) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	revokedCount, err := revokeUserSessions(c, tx, user.ID, uuid.Nil)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke sessions",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordAdminEvent(tx, c, user, AuditSessionsRevokedByAdmin, map[string]any{"revoked_count": revokedCount})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "All sessions revoked successfully",
+		"data": map[string]interface{}{
+			"revoked_count": revokedCount,
+		},
+	}))
+}
@@ -0,0 +1,77 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+type AdminUserDetail struct {
+	AdminUserInfo
+	Profile        *string    `json:"profile,omitempty"`
+	HasPassword    bool       `json:"has_password"`
+	OAuthProviders []string   `json:"oauth_providers"`
+	ActiveSessions int        `json:"active_sessions"`
+	IsLocked       bool       `json:"is_locked"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+func AdminUsersShow(c buffalo.Context) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data":    adminUserDetail(tx, user),
+	}))
+}
+
+func adminUserDetail(tx *pop.Connection, user models.User) AdminUserDetail {
+	var oauthProviders []models.OAuthProvider
+	tx.Where("user_id = ?", user.ID).All(&oauthProviders)
+
+	providers := make([]string, len(oauthProviders))
+	for i, p := range oauthProviders {
+		providers[i] = p.Provider
+	}
+
+	var activeSessions int
+	tx.RawQuery(`
+		SELECT COUNT(*) FROM auth.sessions
+		WHERE user_id = ? AND revoked = false AND expires_at > ?
+	`, user.ID, time.Now().UTC()).First(&activeSessions)
+
+	detail := AdminUserDetail{
+		AdminUserInfo:  newAdminUserInfo(user),
+		Profile:        user.Profile,
+		HasPassword:    user.PasswordHash != nil && *user.PasswordHash != "",
+		OAuthProviders: providers,
+		ActiveSessions: activeSessions,
+	}
+
+	var lock models.AccountLock
+	if err := tx.Where("user_id = ?", user.ID).First(&lock); err == nil && time.Now().UTC().Before(lock.LockedUntil) {
+		detail.IsLocked = true
+		detail.LockedUntil = &lock.LockedUntil
+	}
+
+	return detail
+}
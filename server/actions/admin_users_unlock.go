@@ -0,0 +1,36 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+func AdminUsersUnlock(c buffalo.Context) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	clearAccountLock(tx, user.ID)
+	recordAdminEvent(tx, c, user, AuditUserUnlockedByAdmin, nil)
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Account unlocked successfully",
+	}))
+}
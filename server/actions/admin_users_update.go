@@ -0,0 +1,133 @@
+package actions
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+type AdminUpdateUserRequest struct {
+	Role   *string `json:"role"`
+	Active *bool   `json:"active"`
+}
+
+func AdminUsersUpdate(c buffalo.Context) error {
+	admin, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	var req AdminUpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	if req.Role == nil && req.Active == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Role or active is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	if req.Role != nil {
+		role := strings.TrimSpace(*req.Role)
+		if !ValidRoles[role] {
+			return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Invalid role. Must be: support, admin, or dev",
+				ErrorCode: "INVALID_ROLE",
+			}))
+		}
+		req.Role = &role
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, err := findTargetUser(c, tx)
+	if err != nil {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	// Evitar que un admin se quite acceso a sí mismo
+	if user.ID == admin.ID && ((req.Role != nil && *req.Role != user.Role) || (req.Active != nil && !*req.Active)) {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "You cannot change your own role or deactivate yourself",
+			ErrorCode: "CANNOT_MODIFY_SELF",
+		}))
+	}
+
+	deactivated := req.Active != nil && !*req.Active && user.Active
+	changes := map[string]any{}
+	if req.Role != nil && *req.Role != user.Role {
+		changes["role"] = map[string]string{"from": user.Role, "to": *req.Role}
+	}
+	if req.Active != nil && *req.Active != user.Active {
+		changes["active"] = map[string]bool{"from": user.Active, "to": *req.Active}
+	}
+
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+	if req.Active != nil {
+		user.Active = *req.Active
+	}
+	user.UpdatedAt = time.Now().UTC()
+
+	if err := tx.Update(&user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to update user",
+			ErrorCode: "UPDATE_FAILED",
+		}))
+	}
+
+	if deactivated {
+		if _, err := revokeUserSessions(c, tx, user.ID, uuid.Nil); err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to revoke sessions",
+				ErrorCode: "INTERNAL_ERROR",
+			}))
+		}
+		if _, err := revokeUserTokens(tx, user.ID); err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to revoke tokens",
+				ErrorCode: "INTERNAL_ERROR",
+			}))
+		}
+	}
+
+	recordAdminEvent(tx, c, user, AuditUserUpdatedByAdmin, map[string]any{"changes": changes})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "User updated successfully",
+		"data":    newAdminUserInfo(user),
+	}))
+}
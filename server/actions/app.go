@@ -71,6 +71,10 @@ func App() *buffalo.App {
 		// Set the request content type to JSON
 		app.Use(contenttype.Set("application/json"))
 
+		// Clears revoked sessions from the session cache once the
+		// transaction below is over.
+		app.Use(ForgetRevokedSessions)
+
 		// Wraps each request in a transaction.
 		//   c.Value("tx").(*pop.Connection)
 		// Remove to disable this.
@@ -79,57 +83,118 @@ func App() *buffalo.App {
 		// -- home
 		app.GET("/", HomeHandler)
 
+		// -- public signing keys
+		app.GET("/.well-known/jwks.json", AuthJWKS)
+
 		// -- api v1
 		api := app.Group("/api")
 		v1 := api.Group("/v1")
 
 		// -- public routes (no auth required)
-		v1.POST("/auth/register", AuthRegister)
-		v1.POST("/auth/verify-email", AuthVerifyEmail)
-		v1.POST("/auth/login", AuthLogin)
-		v1.POST("/auth/refresh", AuthRefresh)
-		v1.POST("/auth/password/request-reset", AuthRequestPasswordReset)
-		v1.POST("/auth/password/reset", AuthResetPassword)
-		v1.POST("/auth/2fa/verify", Auth2FAVerify)
-		v1.POST("/auth/2fa/verify-backup", Auth2FAVerifyBackup)
-		v1.GET("/auth/oauth/google", AuthOAuthGoogleInitiate)
-		v1.GET("/auth/oauth/google/callback", AuthOAuthGoogleCallback)
-		v1.POST("/auth/security/status", AuthSecurityStatus)
+		v1.POST("/auth/register", RateLimit(RateLimitRegister)(AuthRegister))
+		v1.POST("/auth/verify-email", RateLimit(RateLimitVerifyEmail)(AuthVerifyEmail))
+		v1.POST("/auth/email-change/cancel", RateLimit(RateLimitVerifyEmail)(AuthEmailChangeCancel))
+		v1.POST("/auth/login", RateLimit(RateLimitLogin)(AuthLogin))
+		v1.POST("/auth/refresh", RateLimit(RateLimitRefresh)(AuthRefresh))
+		v1.POST("/auth/password/request-reset", RateLimit(RateLimitRequestReset)(AuthRequestPasswordReset))
+		v1.POST("/auth/password/reset", RateLimit(RateLimitResetPass)(AuthResetPassword))
+		v1.POST("/auth/magic-link/request", RateLimit(RateLimitMagicLink)(AuthMagicLinkRequest))
+		v1.POST("/auth/magic-link/consume", RateLimit(RateLimitLogin)(AuthMagicLinkConsume))
+		v1.POST("/auth/login/step-up", RateLimit(RateLimit2FAVerify)(AuthLoginStepUpVerify))
+		v1.POST("/auth/2fa/verify", RateLimit(RateLimit2FAVerify)(Auth2FAVerify))
+		v1.POST("/auth/2fa/verify-backup", RateLimit(RateLimit2FAVerify)(Auth2FAVerifyBackup))
+		v1.POST("/auth/2fa/webauthn/begin", RateLimit(RateLimit2FAVerify)(Auth2FAWebAuthnBegin))
+		v1.POST("/auth/2fa/webauthn/verify", RateLimit(RateLimit2FAVerify)(Auth2FAWebAuthnVerify))
+		v1.POST("/auth/webauthn/login/begin", RateLimit(RateLimitLogin)(AuthWebAuthnLoginBegin))
+		v1.POST("/auth/webauthn/login/finish", RateLimit(RateLimitLogin)(AuthWebAuthnLoginFinish))
+		v1.GET("/auth/oauth/providers", AuthOAuthProviders)
+		v1.POST("/auth/oauth/exchange", RateLimit(RateLimitOAuth)(AuthOAuthExchange))
+		v1.GET("/auth/oauth/{provider}", RateLimit(RateLimitOAuth)(AuthOAuthInitiate))
+		v1.GET("/auth/oauth/{provider}/callback", AuthOAuthCallback)
+		v1.POST("/auth/security/status", RateLimit(RateLimitSecStatus)(AuthSecurityStatus))
 
 		// -- protected routes (auth required)
 		auth := v1.Group("")
 		auth.Use(AuthMiddleware)
 
+		// sensitive changes need a login or re-authentication of the last minutes
+		recentAuth := RequireRecentAuth(RecentAuthMaxAge)
+
+		// personal access tokens only work on the routes opened to them with
+		// allowTokens, and need the scope given there
+
 		// -- logout
 		auth.POST("/auth/logout", AuthLogout)
 
+		// -- re-authentication
+		auth.POST("/auth/reauth", RateLimit(RateLimitReauth)(AuthReauth))
+		auth.POST("/auth/reauth/webauthn/begin", RateLimit(RateLimitReauth)(AuthReauthWebAuthnBegin))
+		auth.POST("/auth/reauth/webauthn/finish", RateLimit(RateLimitReauth)(AuthReauthWebAuthnFinish))
+
 		// -- user profile
-		auth.GET("/auth/me", AuthMe)
-		auth.PATCH("/auth/me", AuthMeUpdate)
+		allowTokens(ScopeProfileRead, auth.GET("/auth/me", AuthMe))
+		allowTokens(ScopeProfileWrite, auth.PATCH("/auth/me", AuthMeUpdate))
 		auth.DELETE("/auth/me/profile", AuthProfileDelete)
+		auth.POST("/auth/me/email", recentAuth(AuthMeEmailChange))
+		auth.POST("/auth/me/email/confirm", AuthMeEmailConfirm)
+		auth.GET("/auth/me/export", AuthMeExport)
+		auth.POST("/auth/me/delete", recentAuth(AuthMeDelete))
+		auth.POST("/auth/me/delete/cancel", AuthMeDeleteCancel)
 
 		// -- 2fa management
-		auth.POST("/auth/2fa/enable", Auth2FAEnable)
-		auth.POST("/auth/2fa/verify-enable", Auth2FAVerifyEnable)
-		auth.POST("/auth/2fa/disable", Auth2FADisable)
-		auth.POST("/auth/2fa/regenerate-backup-codes", Auth2FARegenerateBackupCodes)
+		auth.POST("/auth/2fa/enable", recentAuth(Auth2FAEnable))
+		auth.POST("/auth/2fa/verify-enable", recentAuth(Auth2FAVerifyEnable))
+		auth.POST("/auth/2fa/disable", recentAuth(Auth2FADisable))
+		auth.POST("/auth/2fa/regenerate-backup-codes", recentAuth(Auth2FARegenerateBackupCodes))
 		auth.GET("/auth/2fa/backup-codes/status", Auth2FABackupStatus)
 
+		// -- passkeys
+		auth.POST("/auth/webauthn/register/begin", recentAuth(AuthWebAuthnRegisterBegin))
+		auth.POST("/auth/webauthn/register/finish", recentAuth(AuthWebAuthnRegisterFinish))
+		auth.GET("/auth/webauthn/credentials", AuthWebAuthnCredentialsList)
+		auth.PATCH("/auth/webauthn/credentials/{credential_id}", AuthWebAuthnCredentialsRename)
+		auth.DELETE("/auth/webauthn/credentials/{credential_id}", recentAuth(AuthWebAuthnCredentialsDelete))
+
 		// -- password management
-		auth.POST("/auth/password/change", AuthPasswordChange)
-		auth.POST("/auth/password/set", AuthPasswordSet)
+		auth.POST("/auth/password/change", recentAuth(AuthPasswordChange))
+		auth.POST("/auth/password/set", recentAuth(AuthPasswordSet))
 
 		// -- oauth management
-		auth.POST("/auth/oauth/google/link", AuthOAuthGoogleLink)
-		auth.DELETE("/auth/oauth/google/unlink", AuthOAuthGoogleUnlink)
+		auth.POST("/auth/oauth/{provider}/link", recentAuth(AuthOAuthLink))
+		auth.DELETE("/auth/oauth/{provider}/unlink", recentAuth(AuthOAuthUnlink))
 
 		// -- sessions management
-		auth.GET("/auth/sessions", AuthSessionsList)
-		auth.DELETE("/auth/sessions/{session_id}", AuthSessionsRevoke)
-		auth.DELETE("/auth/sessions/all", AuthSessionsRevokeAll)
+		allowTokens(ScopeSessionsRead, auth.GET("/auth/sessions", AuthSessionsList))
+		allowTokens(ScopeSessionsWrite, auth.DELETE("/auth/sessions/{session_id}", AuthSessionsRevoke))
+		auth.DELETE("/auth/sessions/all", recentAuth(AuthSessionsRevokeAll))
 
 		// -- security
-		auth.GET("/auth/security/login-history", AuthSecurityLoginHistory)
+		allowTokens(ScopeSecurityRead, auth.GET("/auth/security/login-history", AuthSecurityLoginHistory))
+		allowTokens(ScopeSecurityRead, auth.GET("/auth/security/events", AuthSecurityEvents))
+
+		// -- personal access tokens
+		auth.GET("/auth/tokens", AuthTokensList)
+		auth.POST("/auth/tokens", recentAuth(AuthTokensCreate))
+		auth.DELETE("/auth/tokens/{token_id}", AuthTokensRevoke)
+
+		// -- admin routes (auth + permission required)
+		admin := v1.Group("/admin")
+		admin.Use(AuthMiddleware)
+		admin.Use(RequirePermission(PermissionUsersRead))
+
+		// -- users
+		allowTokens(ScopeAdminRead, admin.GET("/users", AdminUsersList))
+		allowTokens(ScopeAdminRead, admin.GET("/users/{user_id}", AdminUsersShow))
+		allowTokens(ScopeAdminRead, admin.GET("/users/{user_id}/sessions", AdminUsersSessionsList))
+		allowTokens(ScopeAdminRead, admin.GET("/users/{user_id}/login-history", AdminUsersLoginHistory))
+		allowTokens(ScopeAdminRead, admin.GET("/audit-events", RequirePermission(PermissionAuditRead)(AdminAuditEvents)))
+
+		// -- users management
+		adminManage := admin.Group("")
+		adminManage.Use(RequirePermission(PermissionUsersManage))
+		allowTokens(ScopeAdminWrite, adminManage.PATCH("/users/{user_id}", AdminUsersUpdate))
+		allowTokens(ScopeAdminWrite, adminManage.POST("/users/{user_id}/unlock", AdminUsersUnlock))
+		allowTokens(ScopeAdminWrite, adminManage.DELETE("/users/{user_id}/sessions", AdminUsersSessionsRevokeAll))
 	})
 
 	return app
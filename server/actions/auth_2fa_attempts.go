@@ -0,0 +1,108 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const TOTPPeriod = 30
+
+// -- failed attempts
+
+// record2FAFailure stores a failed second factor for the temp token it was
+// made with. Like a wrong password it counts towards locking the account.
+// It writes outside the request transaction, which is rolled back with the
+// error response.
+func record2FAFailure(user models.User, tokenID string, reason string, r *http.Request) {
+	attempt := newLoginAttempt(&user.ID, user.Email, false, reason, r)
+	if id, err := uuid.FromString(tokenID); err == nil {
+		attempt.TokenID = &id
+	}
+	models.DB.Create(&attempt)
+	recordLoginEvent(models.DB, attempt, r)
+	checkAndLockAccount(models.DB, user.ID)
+}
+
+// count2FAFailures counts the failed attempts made with a temp token.
+func count2FAFailures(tx *pop.Connection, tokenID string) int {
+	id, err := uuid.FromString(tokenID)
+	if err != nil {
+		return Max2FAAttempts
+	}
+	var count int
+	tx.RawQuery(`
+		SELECT COUNT(*) FROM auth.login_attempts
+		WHERE token_id = ? AND success = false
+	`, id).First(&count)
+	return count
+}
+
+// accountLock returns the active lock of the user, if any.
+func accountLock(tx *pop.Connection, userID uuid.UUID) (models.AccountLock, bool) {
+	var lock models.AccountLock
+	err := tx.Where("user_id = ?", userID).First(&lock)
+	return lock, err == nil && time.Now().UTC().Before(lock.LockedUntil)
+}
+
+func renderAccountLocked(c buffalo.Context, lock models.AccountLock) error {
+	return c.Render(http.StatusLocked, r.JSON(map[string]interface{}{
+		"success":      false,
+		"error":        "Account temporarily locked due to multiple failed attempts",
+		"error_code":   "ACCOUNT_LOCKED",
+		"locked_until": lock.LockedUntil,
+	}))
+}
+
+// -- totp
+
+// useTOTPCode checks code against the user's secret and burns its time
+// step, so a code that was accepted once can't be replayed in its window.
+// The step is claimed with a conditional update, two requests racing with
+// the same code can't both win.
+func useTOTPCode(tx *pop.Connection, user *models.User, code string) bool {
+	if user.TwoFactorSecret == nil {
+		return false
+	}
+
+	step, ok := matchTOTPStep(code, string(*user.TwoFactorSecret), time.Now().UTC())
+	if !ok || (user.TOTPLastStep != nil && step <= *user.TOTPLastStep) {
+		return false
+	}
+
+	n, err := tx.RawQuery(`
+		UPDATE auth.users SET totp_last_step = ?
+		WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)
+	`, step, user.ID, step).ExecWithCount()
+	if err != nil || n == 0 {
+		return false
+	}
+
+	user.TOTPLastStep = &step
+	return true
+}
+
+// matchTOTPStep returns the time step code belongs to, allowing one step of
+// clock drift either way like totp.Validate.
+func matchTOTPStep(code, secret string, now time.Time) (int64, bool) {
+	opts := totp.ValidateOpts{
+		Period:    TOTPPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	current := now.Unix() / TOTPPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		valid, err := totp.ValidateCustom(code, secret, time.Unix(step*TOTPPeriod, 0).UTC(), opts)
+		if err == nil && valid {
+			return step, true
+		}
+	}
+	return 0, false
+}
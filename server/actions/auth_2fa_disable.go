@@ -2,18 +2,19 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"strings"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
-	"github.com/pquerna/otp/totp"
 )
 
 type Disable2FARequest struct {
-	Password string `json:"password"`
-	Code     string `json:"code"`
+	Code string `json:"code"`
 }
 
+// Auth2FADisable turns TOTP off. Besides the recent authentication the
+// route asks for, the code proves the authenticator is at hand.
 func Auth2FADisable(c buffalo.Context) error {
 	user, err := GetCurrentUser(c)
 	if err != nil {
@@ -33,13 +34,12 @@ func Auth2FADisable(c buffalo.Context) error {
 		}))
 	}
 
-	req.Password = strings.TrimSpace(req.Password)
 	req.Code = strings.TrimSpace(req.Code)
 
-	if req.Password == "" || req.Code == "" {
+	if req.Code == "" {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Password and 2FA code are required",
+			Error:     "2FA code is required",
 			ErrorCode: "VALIDATION_ERROR",
 		}))
 	}
@@ -52,51 +52,55 @@ func Auth2FADisable(c buffalo.Context) error {
 		}))
 	}
 
-	if user.PasswordHash == nil || *user.PasswordHash == "" {
+	if user.TwoFactorSecret == nil {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "No password set for this account",
-			ErrorCode: "NO_PASSWORD",
+			Error:     "2FA secret not found",
+			ErrorCode: "2FA_SECRET_NOT_FOUND",
 		}))
 	}
 
-	if !verifyPassword(req.Password, *user.PasswordHash) {
-		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Invalid password",
-			ErrorCode: "INVALID_PASSWORD",
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
 		}))
 	}
 
-	if user.TwoFactorSecret == nil {
+	if !useTOTPCode(tx, &user, req.Code) {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "2FA secret not found",
-			ErrorCode: "2FA_SECRET_NOT_FOUND",
+			Error:     "Invalid 2FA code",
+			ErrorCode: "INVALID_CODE",
 		}))
 	}
 
-	valid := totp.Validate(req.Code, *user.TwoFactorSecret)
-	if !valid {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+	user.TwoFactorEnabled = false
+	user.TwoFactorSecret = nil
+	user.TOTPLastStep = nil
+	if err := tx.Update(&user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Invalid 2FA code",
-			ErrorCode: "INVALID_CODE",
+			Error:     "Failed to disable 2FA",
+			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
 
-	tx, ok := c.Value("tx").(*pop.Connection)
-	if !ok || tx == nil {
+	tx.RawQuery("DELETE FROM auth.two_factor_backup_codes WHERE user_id = ?", user.ID).Exec()
+
+	if _, err := revokeUserTokens(tx, user.ID); err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Database connection not available",
-			ErrorCode: "DB_NOT_AVAILABLE",
+			Error:     "Failed to disable 2FA",
+			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
 
-	user.TwoFactorEnabled = false
-	user.TwoFactorSecret = nil
-	if err := tx.Update(&user); err != nil {
+	recordUserEvent(tx, c, user, Audit2FADisabled, nil)
+
+	if err := mailers.SendTwoFactorChangedEmail(tx, user, false); err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Failed to disable 2FA",
@@ -104,8 +108,6 @@ func Auth2FADisable(c buffalo.Context) error {
 		}))
 	}
 
-	tx.RawQuery("DELETE FROM auth.two_factor_backup_codes WHERE user_id = ?", user.ID).Exec()
-
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"message": "Two-factor authentication disabled",
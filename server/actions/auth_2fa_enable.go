@@ -98,19 +98,30 @@ func Auth2FAEnable(c buffalo.Context) error {
 	setupToken := randomToken(32)
 	setupTokenHash := sha256Hex(setupToken)
 
-	tempData := key.Secret() + "|" + strings.Join(backupCodes, ",")
-
-	vt := models.VerificationToken{
-		UserID:    &user.ID,
-		Email:     &tempData,
-		TokenHash: setupTokenHash,
-		TokenType: "2fa_setup",
-		ExpiresAt: time.Now().UTC().Add(10 * time.Minute),
-		Used:      false,
-		CreatedAt: time.Now().UTC(),
+	backupCodeHashes := make([]string, len(backupCodes))
+	for i, code := range backupCodes {
+		backupCodeHashes[i] = sha256Hex(strings.ReplaceAll(code, "-", ""))
 	}
 
-	if err := tx.Create(&vt); err != nil {
+	// starting over replaces a setup that was never confirmed
+	if err := tx.RawQuery("DELETE FROM auth.two_factor_setups WHERE user_id = ?", user.ID).Exec(); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to create setup token",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	setup := models.TwoFactorSetup{
+		UserID:           user.ID,
+		TokenHash:        setupTokenHash,
+		Secret:           models.EncryptedString(key.Secret()),
+		BackupCodeHashes: strings.Join(backupCodeHashes, ","),
+		ExpiresAt:        time.Now().UTC().Add(10 * time.Minute),
+		CreatedAt:        time.Now().UTC(),
+	}
+
+	if err := tx.Create(&setup); err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Failed to create setup token",
@@ -8,13 +8,10 @@ import (
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
-	"github.com/pquerna/otp/totp"
 )
 
-type RegenerateBackupCodesRequest struct {
-	Code string `json:"code"`
-}
-
+// Auth2FARegenerateBackupCodes replaces the backup codes. Routes put it
+// behind RequireRecentAuth, which a TOTP code can satisfy.
 func Auth2FARegenerateBackupCodes(c buffalo.Context) error {
 	user, err := GetCurrentUser(c)
 	if err != nil {
@@ -25,25 +22,6 @@ func Auth2FARegenerateBackupCodes(c buffalo.Context) error {
 		}))
 	}
 
-	var req RegenerateBackupCodesRequest
-	if err := c.Bind(&req); err != nil {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Invalid request body",
-			ErrorCode: "INVALID_BODY",
-		}))
-	}
-
-	req.Code = strings.TrimSpace(req.Code)
-
-	if req.Code == "" {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "2FA code is required",
-			ErrorCode: "VALIDATION_ERROR",
-		}))
-	}
-
 	if !user.TwoFactorEnabled {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
@@ -60,15 +38,6 @@ func Auth2FARegenerateBackupCodes(c buffalo.Context) error {
 		}))
 	}
 
-	valid := totp.Validate(req.Code, *user.TwoFactorSecret)
-	if !valid {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Invalid 2FA code",
-			ErrorCode: "INVALID_CODE",
-		}))
-	}
-
 	tx, ok := c.Value("tx").(*pop.Connection)
 	if !ok || tx == nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
@@ -96,6 +65,8 @@ func Auth2FARegenerateBackupCodes(c buffalo.Context) error {
 		tx.Create(&backupCode)
 	}
 
+	recordUserEvent(tx, c, user, Audit2FABackupCodesRegenerated, map[string]any{"count": len(backupCodes)})
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
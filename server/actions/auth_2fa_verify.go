@@ -4,16 +4,17 @@ import (
 	"net/http"
 	"server/models"
 	"strings"
-	"time"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/pquerna/otp/totp"
 )
 
 const (
+	// Max2FAAttempts is how many codes can be tried with one temp token;
+	// after that the user has to log in again. Failures also count towards
+	// MaxLoginAttempts and lock the account.
 	Max2FAAttempts = 3
 )
 
@@ -50,9 +51,7 @@ func Auth2FAVerify(c buffalo.Context) error {
 		}))
 	}
 
-	token, err := jwt.Parse(req.TempToken, func(token *jwt.Token) (interface{}, error) {
-		return JWTSecret, nil
-	})
+	token, err := parseToken(req.TempToken)
 
 	if err != nil || !token.Valid {
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -116,24 +115,22 @@ func Auth2FAVerify(c buffalo.Context) error {
 		}))
 	}
 
-	var failedAttempts int
-	since := time.Now().UTC().Add(-5 * time.Minute)
-	tx.RawQuery(`
-		SELECT COUNT(*) FROM auth.login_attempts 
-		WHERE user_id = ? AND success = false AND failure_reason = '2fa_failed' AND created_at > ?
-	`, user.ID, since).First(&failedAttempts)
+	if lock, locked := accountLock(tx, user.ID); locked {
+		return renderAccountLocked(c, lock)
+	}
 
+	tokenID, _ := claims["jti"].(string)
+	failedAttempts := count2FAFailures(tx, tokenID)
 	if failedAttempts >= Max2FAAttempts {
 		return c.Render(http.StatusTooManyRequests, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Too many failed 2FA attempts. Please try again later.",
+			Error:     "Too many failed 2FA attempts. Please log in again.",
 			ErrorCode: "TOO_MANY_ATTEMPTS",
 		}))
 	}
 
-	valid := totp.Validate(req.Code, *user.TwoFactorSecret)
-	if !valid {
-		recordLoginAttempt(tx, &user.ID, user.Email, false, "2fa_failed", c.Request())
+	if !useTOTPCode(tx, &user, req.Code) {
+		record2FAFailure(user, tokenID, "2fa_failed", c.Request())
 
 		attemptsRemaining := Max2FAAttempts - failedAttempts - 1
 		if attemptsRemaining < 0 {
@@ -148,37 +145,7 @@ func Auth2FAVerify(c buffalo.Context) error {
 		}))
 	}
 
-	accessToken, refreshToken, err := createSession(tx, user, c.Request())
-	if err != nil {
-		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Failed to create session",
-			ErrorCode: "INTERNAL_ERROR",
-		}))
-	}
-
-	now := time.Now().UTC()
-	user.LastLoginAt = &now
-	tx.Update(&user)
-
 	recordLoginAttempt(tx, &user.ID, user.Email, true, "", c.Request())
 
-	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-			"token_type":    "Bearer",
-			"expires_in":    int(AccessTokenDuration.Seconds()),
-			"user": LoginUser{
-				ID:               user.ID.String(),
-				Email:            user.Email,
-				Name:             user.Name,
-				LastName:         user.LastName,
-				Profile:          user.Profile,
-				Role:             user.Role,
-				TwoFactorEnabled: user.TwoFactorEnabled,
-			},
-		},
-	}))
+	return generateAndReturnTokens(c, tx, user, withMethod(claimedAMR(claims), AMROTP))
 }
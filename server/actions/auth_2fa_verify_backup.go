@@ -1,7 +1,6 @@
 package actions
 
 import (
-	"fmt"
 	"net/http"
 	"server/models"
 	"strings"
@@ -39,9 +38,7 @@ func Auth2FAVerifyBackup(c buffalo.Context) error {
 		}))
 	}
 
-	token, err := jwt.Parse(req.TempToken, func(token *jwt.Token) (interface{}, error) {
-		return JWTSecret, nil
-	})
+	token, err := parseToken(req.TempToken)
 
 	if err != nil || !token.Valid {
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -105,13 +102,34 @@ func Auth2FAVerifyBackup(c buffalo.Context) error {
 		}))
 	}
 
+	if lock, locked := accountLock(tx, user.ID); locked {
+		return renderAccountLocked(c, lock)
+	}
+
+	tokenID, _ := claims["jti"].(string)
+	if count2FAFailures(tx, tokenID) >= Max2FAAttempts {
+		return c.Render(http.StatusTooManyRequests, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Too many failed 2FA attempts. Please log in again.",
+			ErrorCode: "TOO_MANY_ATTEMPTS",
+		}))
+	}
+
 	codeWithoutDashes := strings.ReplaceAll(req.BackupCode, "-", "")
 	codeHash := sha256Hex(codeWithoutDashes)
 
+	// claim the code in one statement so two requests with it can't both
+	// get in
+	now := time.Now().UTC()
 	var backupCode models.TwoFactorBackupCode
-	err = tx.Where("user_id = ? AND code_hash = ? AND used = ?", user.ID, codeHash, false).First(&backupCode)
+	err = tx.RawQuery(`
+		UPDATE auth.two_factor_backup_codes
+		SET used = true, used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used = false
+		RETURNING *
+	`, now, user.ID, codeHash).First(&backupCode)
 	if err != nil {
-		recordLoginAttempt(tx, &user.ID, user.Email, false, "backup_code_invalid", c.Request())
+		record2FAFailure(user, tokenID, "backup_code_invalid", c.Request())
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Invalid or already used backup code",
@@ -119,57 +137,11 @@ func Auth2FAVerifyBackup(c buffalo.Context) error {
 		}))
 	}
 
-	now := time.Now().UTC()
-	backupCode.Used = true
-	backupCode.UsedAt = &now
-	if err := tx.Update(&backupCode); err != nil {
-		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Failed to process backup code",
-			ErrorCode: "INTERNAL_ERROR",
-		}))
-	}
-
-	accessToken, refreshToken, err := createSession(tx, user, c.Request())
-	if err != nil {
-		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Failed to create session",
-			ErrorCode: "INTERNAL_ERROR",
-		}))
-	}
-
-	user.LastLoginAt = &now
-	tx.Update(&user)
-
 	recordLoginAttempt(tx, &user.ID, user.Email, true, "", c.Request())
 
 	var remainingCodes int
 	tx.RawQuery("SELECT COUNT(*) FROM auth.two_factor_backup_codes WHERE user_id = ? AND used = false", user.ID).First(&remainingCodes)
+	recordUserEvent(tx, c, user, Audit2FABackupCodeUsed, map[string]any{"remaining": remainingCodes})
 
-	warning := "This backup code has been used and cannot be reused"
-	if remainingCodes <= 3 {
-		warning = fmt.Sprintf("%s. Warning: You only have %d backup codes remaining. Please regenerate them soon.", warning, remainingCodes)
-	}
-
-	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
-		"success": true,
-		"message": "Backup code accepted",
-		"data": map[string]interface{}{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-			"token_type":    "Bearer",
-			"expires_in":    int(AccessTokenDuration.Seconds()),
-			"user": LoginUser{
-				ID:               user.ID.String(),
-				Email:            user.Email,
-				Name:             user.Name,
-				LastName:         user.LastName,
-				Profile:          user.Profile,
-				Role:             user.Role,
-				TwoFactorEnabled: user.TwoFactorEnabled,
-			},
-			"warning": warning,
-		},
-	}))
+	return generateAndReturnTokens(c, tx, user, withMethod(claimedAMR(claims), AMROTP))
 }
@@ -2,13 +2,13 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"server/models"
 	"strings"
 	"time"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
-	"github.com/pquerna/otp/totp"
 )
 
 type Verify2FAEnableRequest struct {
@@ -64,9 +64,8 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 	}
 
 	setupTokenHash := sha256Hex(req.SetupToken)
-	var vt models.VerificationToken
-	err = tx.Where("token_hash = ? AND token_type = ? AND used = ? AND user_id = ?",
-		setupTokenHash, "2fa_setup", false, user.ID).First(&vt)
+	var setup models.TwoFactorSetup
+	err = tx.Where("token_hash = ? AND user_id = ?", setupTokenHash, user.ID).First(&setup)
 	if err != nil {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
@@ -75,7 +74,7 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 		}))
 	}
 
-	if time.Now().UTC().After(vt.ExpiresAt) {
+	if time.Now().UTC().After(setup.ExpiresAt) {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Setup token has expired",
@@ -83,28 +82,7 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 		}))
 	}
 
-	if vt.Email == nil {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Invalid setup token",
-			ErrorCode: "INVALID_TOKEN",
-		}))
-	}
-
-	parts := strings.Split(*vt.Email, "|")
-	if len(parts) != 2 {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Invalid setup token data",
-			ErrorCode: "INVALID_TOKEN",
-		}))
-	}
-
-	secret := parts[0]
-	backupCodesStr := parts[1]
-	backupCodes := strings.Split(backupCodesStr, ",")
-
-	valid := totp.Validate(req.Code, secret)
+	step, valid := matchTOTPStep(req.Code, string(setup.Secret), time.Now().UTC())
 	if !valid {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
@@ -114,7 +92,8 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 	}
 
 	user.TwoFactorEnabled = true
-	user.TwoFactorSecret = &secret
+	user.TwoFactorSecret = &setup.Secret
+	user.TOTPLastStep = &step
 	if err := tx.Update(&user); err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
@@ -123,8 +102,7 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 		}))
 	}
 
-	for _, code := range backupCodes {
-		codeHash := sha256Hex(strings.ReplaceAll(code, "-", ""))
+	for _, codeHash := range strings.Split(setup.BackupCodeHashes, ",") {
 		backupCode := models.TwoFactorBackupCode{
 			UserID:    user.ID,
 			CodeHash:  codeHash,
@@ -134,10 +112,17 @@ func Auth2FAVerifyEnable(c buffalo.Context) error {
 		tx.Create(&backupCode)
 	}
 
-	vt.Used = true
-	now := time.Now().UTC()
-	vt.UsedAt = &now
-	tx.Update(&vt)
+	tx.Destroy(&setup)
+
+	recordUserEvent(tx, c, user, Audit2FAEnabled, nil)
+
+	if err := mailers.SendTwoFactorChangedEmail(tx, user, true); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to enable 2FA",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
 
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
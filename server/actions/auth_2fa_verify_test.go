@@ -0,0 +1,142 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXP"
+
+var testTOTPOpts = totp.ValidateOpts{Period: TOTPPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// enable2FA turns on TOTP for user with testTOTPSecret.
+func (as *ActionSuite) enable2FA(user *models.User) {
+	secret := models.EncryptedString(testTOTPSecret)
+	user.TwoFactorEnabled = true
+	user.TwoFactorSecret = &secret
+	as.NoError(as.DB.Update(user))
+}
+
+// totpCode is the code of testTOTPSecret at t.
+func (as *ActionSuite) totpCode(t time.Time) string {
+	code, err := totp.GenerateCodeCustom(testTOTPSecret, t, testTOTPOpts)
+	as.NoError(err)
+	return code
+}
+
+// tempToken is the temp_2fa token a password login hands out.
+func (as *ActionSuite) tempToken(user models.User) string {
+	token, err := generateTempToken(user, "temp_2fa", TempTokenDuration, []string{AMRPassword})
+	as.NoError(err)
+	return token
+}
+
+func (as *ActionSuite) Test_Auth2FAVerify_RejectsReusedStep() {
+	user := as.createUser("totp@example.com", RoleSupport)
+	as.enable2FA(&user)
+	code := as.totpCode(time.Now().UTC())
+
+	res := as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: as.tempToken(user), Code: code})
+	as.Equal(http.StatusOK, res.Code)
+
+	// same code, even with a fresh login
+	res = as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: as.tempToken(user), Code: code})
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_CODE", errorCode(res))
+}
+
+func (as *ActionSuite) Test_MatchTOTPStep_AllowsOneStepOfDrift() {
+	now := time.Now().UTC()
+	current := now.Unix() / TOTPPeriod
+
+	for offset, want := range map[int64]bool{-2: false, -1: true, 0: true, 1: true, 2: false} {
+		at := time.Unix((current+offset)*TOTPPeriod, 0).UTC()
+		step, ok := matchTOTPStep(as.totpCode(at), testTOTPSecret, now)
+		as.Equal(want, ok, "offset %d", offset)
+		if want {
+			as.Equal(current+offset, step, "offset %d", offset)
+		}
+	}
+}
+
+func (as *ActionSuite) Test_Auth2FAVerify_CapsAttemptsPerTempToken() {
+	user := as.createUser("totp@example.com", RoleSupport)
+	as.enable2FA(&user)
+	tempToken := as.tempToken(user)
+
+	for remaining := Max2FAAttempts - 1; remaining >= 0; remaining-- {
+		res := as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: tempToken, Code: "000000"})
+		as.Equal(http.StatusBadRequest, res.Code)
+		var body Verify2FAErrorResponse
+		res.Bind(&body)
+		as.Equal(remaining, body.AttemptsRemaining)
+	}
+	as.Equal(Max2FAAttempts, count2FAFailures(as.DB, as.tokenID(tempToken)))
+
+	// the right code doesn't help once the token is spent
+	code := as.totpCode(time.Now().UTC())
+	res := as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: tempToken, Code: code})
+	as.Equal(http.StatusTooManyRequests, res.Code)
+	as.Equal("TOO_MANY_ATTEMPTS", errorCode(res))
+
+	res = as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: as.tempToken(user), Code: code})
+	as.Equal(http.StatusOK, res.Code)
+}
+
+func (as *ActionSuite) Test_Auth2FAVerify_FailuresLockTheAccount() {
+	user := as.createUser("totp@example.com", RoleSupport)
+	as.enable2FA(&user)
+
+	for failures := 0; failures < MaxLoginAttempts; {
+		tempToken := as.tempToken(user)
+		for i := 0; i < Max2FAAttempts && failures < MaxLoginAttempts; i++ {
+			res := as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{TempToken: tempToken, Code: "000000"})
+			as.Equal(http.StatusBadRequest, res.Code)
+			failures++
+		}
+	}
+
+	_, locked := accountLock(as.DB, user.ID)
+	as.True(locked)
+
+	res := as.JSON("/api/v1/auth/2fa/verify").Post(Verify2FARequest{
+		TempToken: as.tempToken(user),
+		Code:      as.totpCode(time.Now().UTC()),
+	})
+	as.Equal(http.StatusLocked, res.Code)
+}
+
+func (as *ActionSuite) Test_Auth2FAVerifyBackup_CodeWorksOnce() {
+	user := as.createUser("backup@example.com", RoleSupport)
+	as.enable2FA(&user)
+	as.NoError(as.DB.Create(&models.TwoFactorBackupCode{
+		UserID:    user.ID,
+		CodeHash:  sha256Hex("ABCD1234"),
+		CreatedAt: time.Now().UTC(),
+	}))
+
+	res := as.JSON("/api/v1/auth/2fa/verify-backup").Post(VerifyBackupCodeRequest{TempToken: as.tempToken(user), BackupCode: "ABCD-1234"})
+	as.Equal(http.StatusOK, res.Code)
+
+	var code models.TwoFactorBackupCode
+	as.NoError(as.DB.Where("user_id = ?", user.ID).First(&code))
+	as.True(code.Used)
+	as.NotNil(code.UsedAt)
+
+	res = as.JSON("/api/v1/auth/2fa/verify-backup").Post(VerifyBackupCodeRequest{TempToken: as.tempToken(user), BackupCode: "ABCD-1234"})
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_BACKUP_CODE", errorCode(res))
+}
+
+// tokenID is the jti of a signed token.
+func (as *ActionSuite) tokenID(raw string) string {
+	token, err := parseToken(raw)
+	as.NoError(err)
+	jti, _ := token.Claims.(jwt.MapClaims)["jti"].(string)
+	return jti
+}
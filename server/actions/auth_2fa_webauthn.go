@@ -0,0 +1,248 @@
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/models"
+	"strings"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type WebAuthn2FABeginRequest struct {
+	TempToken string `json:"temp_token"`
+}
+
+type WebAuthn2FAVerifyRequest struct {
+	TempToken   string          `json:"temp_token"`
+	ChallengeID string          `json:"challenge_id"`
+	Credential  json.RawMessage `json:"credential"`
+}
+
+// Auth2FAWebAuthnBegin starts a passkey assertion for the temp_2fa step,
+// limited to the passkeys of the user the temp token was issued for.
+func Auth2FAWebAuthnBegin(c buffalo.Context) error {
+	var req WebAuthn2FABeginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.TempToken = strings.TrimSpace(req.TempToken)
+	if req.TempToken == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Temp token is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, _, errResp := tempTokenUser(tx, req.TempToken, "temp_2fa")
+	if errResp != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(errResp))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	if len(waUser.credentials) == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "No passkeys registered for this user",
+			ErrorCode: "NO_PASSKEYS",
+		}))
+	}
+
+	assertion, session, err := webAuthn.BeginLogin(waUser)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey verification",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	challengeID, err := saveWebAuthnChallenge(tx, &user.ID, "second_factor", session)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey verification",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"challenge_id": challengeID,
+			"options":      assertion,
+		},
+	}))
+}
+
+// Auth2FAWebAuthnVerify completes the temp_2fa step with a passkey instead
+// of a TOTP code. Failures count against the same limit as TOTP codes.
+func Auth2FAWebAuthnVerify(c buffalo.Context) error {
+	var req WebAuthn2FAVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.TempToken = strings.TrimSpace(req.TempToken)
+
+	if req.TempToken == "" || strings.TrimSpace(req.ChallengeID) == "" || len(req.Credential) == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Temp token, challenge ID and credential are required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, claims, errResp := tempTokenUser(tx, req.TempToken, "temp_2fa")
+	if errResp != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(errResp))
+	}
+	tokenID, _ := claims["jti"].(string)
+
+	if lock, locked := accountLock(tx, user.ID); locked {
+		return renderAccountLocked(c, lock)
+	}
+
+	failedAttempts := count2FAFailures(tx, tokenID)
+	if failedAttempts >= Max2FAAttempts {
+		return c.Render(http.StatusTooManyRequests, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Too many failed 2FA attempts. Please log in again.",
+			ErrorCode: "TOO_MANY_ATTEMPTS",
+		}))
+	}
+
+	session, err := consumeWebAuthnChallenge(req.ChallengeID, "second_factor", &user.ID)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired challenge",
+			ErrorCode: "INVALID_CHALLENGE",
+		}))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid passkey credential",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	validated, err := webAuthn.ValidateLogin(waUser, session, parsed)
+	if err != nil || validated.Authenticator.CloneWarning {
+		if err == nil {
+			stored, _ := waUser.find(validated.ID)
+			recordPasskeyUse(models.DB, stored, validated)
+		}
+		record2FAFailure(user, tokenID, "2fa_failed", c.Request())
+
+		attemptsRemaining := Max2FAAttempts - failedAttempts - 1
+		if attemptsRemaining < 0 {
+			attemptsRemaining = 0
+		}
+
+		return c.Render(http.StatusBadRequest, r.JSON(Verify2FAErrorResponse{
+			Success:           false,
+			Error:             "Passkey verification failed",
+			ErrorCode:         "INVALID_CREDENTIAL",
+			AttemptsRemaining: attemptsRemaining,
+		}))
+	}
+
+	stored, _ := waUser.find(validated.ID)
+	if err := recordPasskeyUse(tx, stored, validated); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to update passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordLoginAttempt(tx, &user.ID, user.Email, true, "webauthn", c.Request())
+
+	return generateAndReturnTokens(c, tx, user, withMethod(claimedAMR(claims), AMRPasskey))
+}
+
+// tempTokenUser resolves the user a temp token of tokenType (temp_2fa or
+// temp_step_up) was issued for, and returns the token's claims: the jti
+// failed attempts are counted against and the amr of the login so far.
+func tempTokenUser(tx *pop.Connection, tempToken, tokenType string) (models.User, jwt.MapClaims, *ErrorResponse) {
+	var user models.User
+
+	token, err := parseToken(tempToken)
+	if err != nil || !token.Valid {
+		return user, nil, &ErrorResponse{Success: false, Error: "Invalid or expired temp token", ErrorCode: "INVALID_TOKEN"}
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return user, nil, &ErrorResponse{Success: false, Error: "Invalid token claims", ErrorCode: "INVALID_CLAIMS"}
+	}
+
+	if claimed, _ := claims["token_type"].(string); claimed != tokenType {
+		return user, nil, &ErrorResponse{Success: false, Error: "Invalid token type", ErrorCode: "INVALID_TOKEN_TYPE"}
+	}
+
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.FromString(userIDStr)
+	if err != nil {
+		return user, nil, &ErrorResponse{Success: false, Error: "Invalid token", ErrorCode: "INVALID_TOKEN"}
+	}
+
+	if err := tx.Find(&user, userID); err != nil {
+		return user, nil, &ErrorResponse{Success: false, Error: "User not found", ErrorCode: "USER_NOT_FOUND"}
+	}
+
+	return user, claims, nil
+}
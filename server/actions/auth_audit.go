@@ -0,0 +1,325 @@
+package actions
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// Audit event types.
+const (
+	AuditUserRegistered = "user.registered"
+	AuditEmailVerified  = "email.verified"
+
+	AuditLoginSucceeded      = "login.succeeded"
+	AuditLoginFailed         = "login.failed"
+	AuditLoginNewDevice      = "login.new_device"
+	AuditLoginStepUpRequired = "login.step_up_required"
+	AuditAccountLocked       = "account.locked"
+	AuditLogout              = "logout"
+
+	AuditReauthenticated = "reauth.succeeded"
+	AuditReauthFailed    = "reauth.failed"
+
+	AuditPasswordChanged        = "password.changed"
+	AuditPasswordSet            = "password.set"
+	AuditPasswordResetRequested = "password.reset_requested"
+	AuditPasswordReset          = "password.reset"
+
+	AuditMagicLinkRequested = "magic_link.requested"
+
+	AuditProfileUpdated      = "profile.updated"
+	AuditProfileImageDeleted = "profile.image_deleted"
+
+	AuditEmailChangeRequested = "email.change_requested"
+	AuditEmailChanged         = "email.changed"
+	AuditEmailChangeCancelled = "email.change_cancelled"
+
+	AuditDataExported             = "account.exported"
+	AuditAccountDeletionScheduled = "account.deletion_scheduled"
+	AuditAccountDeletionCancelled = "account.deletion_cancelled"
+
+	Audit2FAEnabled                = "2fa.enabled"
+	Audit2FADisabled               = "2fa.disabled"
+	Audit2FABackupCodesRegenerated = "2fa.backup_codes_regenerated"
+	Audit2FABackupCodeUsed         = "2fa.backup_code_used"
+
+	AuditPasskeyRegistered = "passkey.registered"
+	AuditPasskeyRenamed    = "passkey.renamed"
+	AuditPasskeyDeleted    = "passkey.deleted"
+
+	AuditOAuthLinked   = "oauth.linked"
+	AuditOAuthUnlinked = "oauth.unlinked"
+
+	AuditSessionRevoked     = "session.revoked"
+	AuditSessionsRevokedAll = "sessions.revoked_all"
+
+	AuditTokenCreated = "token.created"
+	AuditTokenRevoked = "token.revoked"
+
+	AuditUserUpdatedByAdmin     = "admin.user_updated"
+	AuditUserUnlockedByAdmin    = "admin.user_unlocked"
+	AuditSessionsRevokedByAdmin = "admin.sessions_revoked"
+)
+
+// recordAuditEvent appends an event to auth.audit_events. Changes are
+// recorded on the request transaction so the event is only kept if the
+// change commits; failures that end in an error response are recorded on
+// models.DB instead, like login attempts.
+func recordAuditEvent(tx *pop.Connection, r *http.Request, eventType string, actorID, targetID *uuid.UUID, metadata map[string]any) {
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	payload, err := json.Marshal(metadata)
+	if err != nil {
+		payload = []byte("{}")
+	}
+
+	event := models.AuditEvent{
+		EventType: eventType,
+		ActorID:   actorID,
+		TargetID:  targetID,
+		Metadata:  payload,
+		CreatedAt: time.Now().UTC(),
+	}
+	if r != nil {
+		if ip := clientIP(r); ip != "" {
+			event.IPAddress = &ip
+		}
+		if ua := r.UserAgent(); ua != "" {
+			event.UserAgent = &ua
+		}
+	}
+	tx.Create(&event)
+}
+
+// recordUserEvent records a change users make to their own account.
+func recordUserEvent(tx *pop.Connection, c buffalo.Context, user models.User, eventType string, metadata map[string]any) {
+	recordAuditEvent(tx, c.Request(), eventType, &user.ID, &user.ID, metadata)
+}
+
+// recordAdminEvent records a change made by the current admin to target.
+func recordAdminEvent(tx *pop.Connection, c buffalo.Context, target models.User, eventType string, metadata map[string]any) {
+	var actorID *uuid.UUID
+	if admin, err := GetCurrentUser(c); err == nil {
+		actorID = &admin.ID
+	}
+	recordAuditEvent(tx, c.Request(), eventType, actorID, &target.ID, metadata)
+}
+
+type AuditEventInfo struct {
+	ID        string          `json:"id"`
+	EventType string          `json:"event_type"`
+	ActorID   *string         `json:"actor_id,omitempty"`
+	TargetID  *string         `json:"target_id,omitempty"`
+	IPAddress *string         `json:"ip_address,omitempty"`
+	UserAgent *string         `json:"user_agent,omitempty"`
+	Metadata  json.RawMessage `json:"metadata"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func newAuditEventInfo(event models.AuditEvent) AuditEventInfo {
+	info := AuditEventInfo{
+		ID:        event.ID.String(),
+		EventType: event.EventType,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		Metadata:  event.Metadata,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.ActorID != nil {
+		id := event.ActorID.String()
+		info.ActorID = &id
+	}
+	if event.TargetID != nil {
+		id := event.TargetID.String()
+		info.TargetID = &id
+	}
+	return info
+}
+
+// auditEventFilters turns the query params shared by both event endpoints
+// into SQL conditions: type (comma separated, "login.*" matches a prefix),
+// from and to (RFC 3339) and ip (address or CIDR).
+func auditEventFilters(c buffalo.Context, conditions []string, args []interface{}, details map[string]any) ([]string, []interface{}) {
+	if types := strings.TrimSpace(c.Param("type")); types != "" {
+		var matches []string
+		for _, t := range strings.Split(types, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+			if prefix, ok := strings.CutSuffix(t, "*"); ok {
+				matches = append(matches, `event_type LIKE ? ESCAPE '\'`)
+				args = append(args, likeEscaper.Replace(prefix)+"%")
+				continue
+			}
+			matches = append(matches, "event_type = ?")
+			args = append(args, t)
+		}
+		if len(matches) > 0 {
+			conditions = append(conditions, "("+strings.Join(matches, " OR ")+")")
+		}
+	}
+
+	for _, f := range []struct {
+		param string
+		op    string
+	}{{"from", ">="}, {"to", "<"}} {
+		value := c.Param(f.param)
+		if value == "" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			details[f.param] = "Must be an RFC 3339 date, e.g. 2026-03-01T00:00:00Z"
+			continue
+		}
+		conditions = append(conditions, "created_at "+f.op+" ?")
+		args = append(args, at.UTC())
+	}
+
+	if ip := strings.TrimSpace(c.Param("ip")); ip != "" {
+		_, _, cidrErr := net.ParseCIDR(ip)
+		if net.ParseIP(ip) == nil && cidrErr != nil {
+			details["ip"] = "Must be an IP address or CIDR"
+		} else {
+			conditions = append(conditions, "ip_address <<= ?::inet")
+			args = append(args, ip)
+		}
+	}
+
+	return conditions, args
+}
+
+// listAuditEvents renders a page of the events matching conditions.
+func listAuditEvents(c buffalo.Context, tx *pop.Connection, conditions []string, args []interface{}) error {
+	limit, offset := parseLimitOffset(c)
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	tx.RawQuery("SELECT COUNT(*) FROM auth.audit_events WHERE "+where, args...).First(&total)
+
+	var events []models.AuditEvent
+	err := tx.RawQuery(`
+		SELECT * FROM auth.audit_events
+		WHERE `+where+`
+		ORDER BY created_at DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...).All(&events)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to get security events",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	infos := make([]AuditEventInfo, len(events))
+	for i, event := range events {
+		infos[i] = newAuditEventInfo(event)
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+			"events": infos,
+		},
+	}))
+}
+
+// AuthSecurityEvents lists the events of the current user's account.
+func AuthSecurityEvents(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	details := map[string]any{}
+	conditions, args := auditEventFilters(c, []string{"target_id = ?"}, []interface{}{user.ID}, details)
+	if len(details) > 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   details,
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	return listAuditEvents(c, tx, conditions, args)
+}
+
+// AdminAuditEvents lists the events of every account. Besides the shared
+// filters it takes user_id (actor or target), actor_id and target_id.
+func AdminAuditEvents(c buffalo.Context) error {
+	details := map[string]any{}
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+
+	for _, f := range []struct {
+		param string
+		where string
+	}{
+		{"user_id", "(actor_id = ? OR target_id = ?)"},
+		{"actor_id", "actor_id = ?"},
+		{"target_id", "target_id = ?"},
+	} {
+		value := c.Param(f.param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.FromString(value)
+		if err != nil {
+			details[f.param] = "Must be a UUID"
+			continue
+		}
+		conditions = append(conditions, f.where)
+		for range strings.Count(f.where, "?") {
+			args = append(args, id)
+		}
+	}
+
+	conditions, args = auditEventFilters(c, conditions, args, details)
+	if len(details) > 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   details,
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	return listAuditEvents(c, tx, conditions, args)
+}
@@ -0,0 +1,30 @@
+package actions
+
+import (
+	"log"
+	"net/http"
+	"server/clientip"
+	"strings"
+
+	"github.com/gobuffalo/envy"
+)
+
+// TrustedProxies lists the CIDRs of the reverse proxies in front of the app.
+// Forwarding headers are ignored unless the request comes from one of them.
+var TrustedProxies = envy.Get("TRUSTED_PROXIES", "127.0.0.0/8,::1")
+
+var ipResolver *clientip.Resolver
+
+func init() {
+	var err error
+	ipResolver, err = clientip.New(strings.Split(TrustedProxies, ","))
+	if err != nil {
+		log.Fatalf("TRUSTED_PROXIES: %v", err)
+	}
+}
+
+// clientIP is the address of the client that sent r, or "" if unknown.
+// Every IP stored or rate limited must come from here.
+func clientIP(r *http.Request) string {
+	return ipResolver.ClientIP(r)
+}
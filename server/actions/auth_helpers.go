@@ -6,9 +6,12 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/http"
+	"server/geoip"
 	"server/models"
+	"server/passwordhash"
+	"server/useragent"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,7 +19,6 @@ import (
 	"github.com/gobuffalo/pop/v6"
 	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/argon2"
 )
 
 // -- token generation
@@ -39,108 +41,169 @@ func sha256Hex(data string) string {
 // -- password hashing
 
 func hashPassword(password string) string {
-	salt := make([]byte, 16)
-	rand.Read(salt)
-	hash := argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, 32)
-	return fmt.Sprintf("$argon2id$v=19$m=65536,t=1,p=4$%s$%s",
-		hex.EncodeToString(salt),
-		hex.EncodeToString(hash))
+	return passwordhash.Hash(password)
 }
 
 func verifyPassword(password, encodedHash string) bool {
-	parts := strings.Split(encodedHash, "$")
-	if len(parts) != 6 {
-		return false
-	}
-	salt, _ := hex.DecodeString(parts[4])
-	expectedHash, _ := hex.DecodeString(parts[5])
-	hash := argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, 32)
-	if len(hash) != len(expectedHash) {
-		return false
-	}
-	for i := range hash {
-		if hash[i] != expectedHash[i] {
-			return false
-		}
+	ok, _ := passwordhash.Verify(password, encodedHash)
+	return ok
+}
+
+// rehashPassword replaces a hash that passwordhash.Verify flagged as
+// outdated, once the password is known to be right. It only writes if the
+// hash didn't change since it was read.
+func rehashPassword(tx *pop.Connection, user *models.User, password string) error {
+	newHash := hashPassword(password)
+	err := tx.RawQuery("UPDATE auth.users SET password_hash = ? WHERE id = ? AND password_hash = ?",
+		newHash, user.ID, *user.PasswordHash).Exec()
+	if err != nil {
+		return err
 	}
-	return true
+	user.PasswordHash = &newHash
+	return nil
 }
 
 // -- jwt token generation
 
-func generateToken(user models.User, tokenType string, duration time.Duration) (string, error) {
+// generateSessionToken issues an access or refresh token bound to a
+// server-side session through the sid claim, with when and how the user
+// authenticated.
+func generateSessionToken(user models.User, tokenType string, duration time.Duration, sessionID uuid.UUID, auth authentication) (string, error) {
+	claims := tokenClaims(user, tokenType, duration)
+	claims["sid"] = sessionID.String()
+	auth.setClaims(claims)
+	return signToken(claims)
+}
+
+// generateTempToken issues the token for the next step of a login, carrying
+// the methods the user already authenticated with.
+func generateTempToken(user models.User, tokenType string, duration time.Duration, amr []string) (string, error) {
+	claims := tokenClaims(user, tokenType, duration)
+	claims["amr"] = amr
+	return signToken(claims)
+}
+
+func tokenClaims(user models.User, tokenType string, duration time.Duration) jwt.MapClaims {
 	now := time.Now().UTC()
-	claims := jwt.MapClaims{
+	return jwt.MapClaims{
 		"user_id":    user.ID.String(),
 		"email":      user.Email,
 		"role":       user.Role,
 		"token_type": tokenType,
+		"jti":        uuid.Must(uuid.NewV4()).String(),
 		"iss":        "server",
 		"sub":        user.ID.String(),
 		"exp":        now.Add(duration).Unix(),
 		"nbf":        now.Unix(),
 		"iat":        now.Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(JWTSecret)
 }
 
 // -- device info extraction
 
-func extractDeviceInfo(r *http.Request) map[string]string {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.Header.Get("X-Real-IP")
+// DeviceInfo is what sessions keep in device_info: the client IP and user
+// agent, and what they resolve to.
+type DeviceInfo struct {
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	useragent.Device
+	Location *geoip.Location `json:"location,omitempty"`
+}
+
+// describe is how emails show the device, like "Chrome 120.0 en macOS
+// 10.15.7" and "Lima, Perú". Unrecognised user agents are shown as they
+// are.
+func (d DeviceInfo) describe() (device, location string) {
+	device = strings.TrimSpace(d.Browser + " " + d.BrowserVersion)
+	if os := strings.TrimSpace(d.OS + " " + d.OSVersion); os != "" {
+		if device != "" {
+			device += " en "
+		}
+		device += os
 	}
-	if ip == "" {
-		ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+	if device == "" {
+		device = d.UserAgent
 	}
-	return map[string]string{
-		"ip_address": ip,
-		"user_agent": r.UserAgent(),
+
+	if d.Location != nil {
+		var parts []string
+		for _, p := range []string{d.Location.City, d.Location.Country} {
+			if p != "" {
+				parts = append(parts, p)
+			}
+		}
+		location = strings.Join(parts, ", ")
 	}
+	return device, location
+}
+
+func extractDeviceInfo(r *http.Request) DeviceInfo {
+	info := DeviceInfo{
+		IPAddress: clientIP(r),
+		UserAgent: r.UserAgent(),
+		Device:    useragent.Parse(r.UserAgent()),
+	}
+	if loc := geoip.Lookup(info.IPAddress); loc != (geoip.Location{}) {
+		info.Location = &loc
+	}
+	return info
 }
 
 // -- login attempt recording
 
+// recordLoginAttempt stores a login attempt and its audit event. Failed
+// attempts are recorded on models.DB, or the error response rolls both back.
 func recordLoginAttempt(tx *pop.Connection, userID *uuid.UUID, email string, success bool, failureReason string, r *http.Request) {
-	ip := r.Header.Get("X-Forwarded-For")
-	if ip == "" {
-		ip = r.Header.Get("X-Real-IP")
-	}
-	if ip == "" {
-		host, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err == nil {
-			ip = host
-		}
-	}
+	attempt := newLoginAttempt(userID, email, success, failureReason, r)
+	tx.Create(&attempt)
+	recordLoginEvent(tx, attempt, r)
+}
 
-	var ipAddr *string
-	if ip != "" {
-		ipAddr = &ip
+// recordLoginEvent mirrors a login attempt in the audit log. Successful
+// logins keep the method in failure_reason (magic_link, webauthn, ...).
+func recordLoginEvent(tx *pop.Connection, attempt models.LoginAttempt, r *http.Request) {
+	metadata := map[string]any{"email": attempt.Email}
+	if attempt.Success {
+		if attempt.FailureReason != nil {
+			metadata["method"] = *attempt.FailureReason
+		}
+		recordAuditEvent(tx, r, AuditLoginSucceeded, attempt.UserID, attempt.UserID, metadata)
+		return
 	}
-
-	userAgent := r.UserAgent()
-	var userAgentPtr *string
-	if userAgent != "" {
-		userAgentPtr = &userAgent
+	if attempt.FailureReason != nil {
+		metadata["reason"] = *attempt.FailureReason
 	}
+	recordAuditEvent(tx, r, AuditLoginFailed, nil, attempt.UserID, metadata)
+}
 
-	var failureReasonPtr *string
-	if failureReason != "" {
-		failureReasonPtr = &failureReason
-	}
+func newLoginAttempt(userID *uuid.UUID, email string, success bool, failureReason string, r *http.Request) models.LoginAttempt {
+	info := extractDeviceInfo(r)
 
 	attempt := models.LoginAttempt{
-		UserID:        userID,
-		Email:         &email,
-		Success:       success,
-		FailureReason: failureReasonPtr,
-		IPAddress:     ipAddr,
-		UserAgent:     userAgentPtr,
-		CreatedAt:     time.Now().UTC(),
+		UserID:         userID,
+		Email:          &email,
+		Success:        success,
+		FailureReason:  optionalString(failureReason),
+		IPAddress:      optionalString(info.IPAddress),
+		UserAgent:      optionalString(info.UserAgent),
+		Browser:        optionalString(info.Browser),
+		BrowserVersion: optionalString(info.BrowserVersion),
+		OS:             optionalString(info.OS),
+		OSVersion:      optionalString(info.OSVersion),
+		DeviceType:     optionalString(info.Type),
+		CreatedAt:      time.Now().UTC(),
 	}
-	tx.Create(&attempt)
+	if loc := info.Location; loc != nil {
+		attempt.CountryCode = optionalString(loc.CountryCode)
+		attempt.Country = optionalString(loc.Country)
+		attempt.City = optionalString(loc.City)
+		attempt.ASOrg = optionalString(loc.ASOrg)
+		if loc.ASN != 0 {
+			asn := int64(loc.ASN)
+			attempt.ASN = &asn
+		}
+	}
+	return attempt
 }
 
 func logLoginAttempt(tx *pop.Connection, userID *uuid.UUID, email string, success bool, failureReason string, r *http.Request) {
@@ -165,6 +228,10 @@ func checkAndLockAccount(tx *pop.Connection, userID uuid.UUID) {
 			CreatedAt:   time.Now().UTC(),
 		}
 		tx.Create(&lock)
+		recordAuditEvent(tx, nil, AuditAccountLocked, nil, &userID, map[string]any{
+			"failed_attempts": count,
+			"locked_until":    lock.LockedUntil,
+		})
 	}
 }
 
@@ -174,13 +241,16 @@ func clearAccountLock(tx *pop.Connection, userID uuid.UUID) {
 
 // -- session creation
 
-func createSession(tx *pop.Connection, user models.User, r *http.Request) (string, string, error) {
-	accessToken, err := generateToken(user, "access", AccessTokenDuration)
+func createSession(tx *pop.Connection, user models.User, r *http.Request, amr []string) (string, string, error) {
+	sessionID := uuid.Must(uuid.NewV4())
+	auth := authentication{Time: time.Now().UTC(), Methods: amr}
+
+	accessToken, err := generateSessionToken(user, "access", AccessTokenDuration, sessionID, auth)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err := generateToken(user, "refresh", RefreshTokenDuration)
+	refreshToken, err := generateSessionToken(user, "refresh", RefreshTokenDuration, sessionID, auth)
 	if err != nil {
 		return "", "", err
 	}
@@ -190,6 +260,7 @@ func createSession(tx *pop.Connection, user models.User, r *http.Request) (strin
 	deviceInfoJSON, _ := json.Marshal(deviceInfo)
 
 	session := models.Session{
+		ID:               sessionID,
 		UserID:           user.ID,
 		RefreshTokenHash: refreshTokenHash,
 		DeviceInfo:       deviceInfoJSON,
@@ -206,6 +277,39 @@ func createSession(tx *pop.Connection, user models.User, r *http.Request) (strin
 	return accessToken, refreshToken, nil
 }
 
+// -- session revocation
+
+// revokeUserSessions revokes every active session of userID except
+// keepSessionID (pass uuid.Nil to revoke all of them).
+func revokeUserSessions(c buffalo.Context, tx *pop.Connection, userID, keepSessionID uuid.UUID) (int, error) {
+	count, err := tx.RawQuery(`
+		UPDATE auth.sessions 
+		SET revoked = true, revoked_at = ? 
+		WHERE user_id = ? AND revoked = false AND id != ?
+	`, time.Now().UTC(), userID, keepSessionID).ExecWithCount()
+	if err != nil {
+		return 0, err
+	}
+	forgetUserSessions(c, userID)
+	return count, nil
+}
+
+// -- pagination
+
+func parseLimitOffset(c buffalo.Context) (int, int) {
+	limit := 20
+	offset := 0
+
+	if l, err := strconv.Atoi(c.Param("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(c.Param("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	return limit, offset
+}
+
 // -- get current user from context
 
 func GetCurrentUser(c buffalo.Context) (models.User, error) {
@@ -216,8 +320,34 @@ func GetCurrentUser(c buffalo.Context) (models.User, error) {
 	return user, nil
 }
 
+// -- get current session id from context
+
+func GetCurrentSessionID(c buffalo.Context) (uuid.UUID, error) {
+	sessionID, ok := c.Value("session_id").(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("session not found in context")
+	}
+	return sessionID, nil
+}
+
 // -- utility functions
 
 func stringPtr(s string) *string {
 	return &s
 }
+
+// optionalString is nil for "", for nullable columns.
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// valueOf is "" for nil, the other way round of optionalString.
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,55 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+
+	"github.com/gobuffalo/httptest"
+)
+
+const testPassword = "correct-horse-battery"
+
+// createUser stores an active, verified user with testPassword.
+func (as *ActionSuite) createUser(email, role string) models.User {
+	hash := hashPassword(testPassword)
+	user := models.User{
+		Email:         email,
+		EmailVerified: true,
+		PasswordHash:  &hash,
+		Name:          "Test",
+		LastName:      "User",
+		Role:          role,
+		Active:        true,
+	}
+	as.NoError(as.DB.Create(&user))
+	return user
+}
+
+// grant gives role permission; the seeds of the migrations don't survive
+// the cleanup between tests.
+func (as *ActionSuite) grant(role, permission string) {
+	as.NoError(as.DB.Create(&models.RolePermission{Role: role, Permission: permission}))
+}
+
+// signIn opens a session for user as a password login does and returns its
+// access and refresh tokens.
+func (as *ActionSuite) signIn(user models.User) (string, string) {
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", nil)
+	accessToken, refreshToken, err := createSession(as.DB, user, req, []string{AMRPassword})
+	as.NoError(err)
+	return accessToken, refreshToken
+}
+
+// authJSON is as.JSON for a request authenticated with token.
+func (as *ActionSuite) authJSON(token, u string, args ...interface{}) *httptest.JSON {
+	req := as.JSON(u, args...)
+	req.Headers["Authorization"] = "Bearer " + token
+	return req
+}
+
+// errorCode is the error_code of a failed response.
+func errorCode(res *httptest.JSONResponse) string {
+	var body ErrorResponse
+	res.Bind(&body)
+	return body.ErrorCode
+}
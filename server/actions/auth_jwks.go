@@ -0,0 +1,56 @@
+package actions
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+// AuthJWKS publishes the public part of every configured signing key,
+// including retired ones, so other services can verify our tokens.
+func AuthJWKS(c buffalo.Context) error {
+	keys := make([]JWK, 0, len(jwtKeys.order))
+	for _, kid := range jwtKeys.order {
+		key := jwtKeys.keys[kid]
+		jwk := JWK{
+			Kid: key.ID,
+			Alg: key.Method.Alg(),
+			Use: "sig",
+		}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		keys = append(keys, jwk)
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.Render(http.StatusOK, r.JSON(JWKSResponse{Keys: keys}))
+}
@@ -0,0 +1,14 @@
+package actions
+
+import "net/http"
+
+func (as *ActionSuite) Test_AuthJWKS() {
+	res := as.JSON("/.well-known/jwks.json").Get()
+
+	as.Equal(http.StatusOK, res.Code)
+
+	var body JWKSResponse
+	res.Bind(&body)
+	as.Len(body.Keys, len(jwtKeys.order))
+	as.Equal(jwtKeys.signing.ID, body.Keys[0].Kid)
+}
@@ -0,0 +1,189 @@
+package actions
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gobuffalo/envy"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWT signing keys are configured as a comma separated list of kid=path
+// pairs, e.g. JWT_KEYS="2026-01=/etc/redorange/jwt-2026-01.pem,2025-07=/etc/redorange/jwt-2025-07.pem".
+// Each PEM file holds either a private key (RSA or Ed25519) or only a public
+// key. JWT_SIGNING_KEY_ID selects the key used to sign new tokens; every other
+// key is only used to verify tokens issued before it was retired.
+var (
+	JWTKeys         = envy.Get("JWT_KEYS", "")
+	JWTSigningKeyID = envy.Get("JWT_SIGNING_KEY_ID", "")
+)
+
+type jwtKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+type jwtKeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+	order   []string
+}
+
+var jwtKeys *jwtKeySet
+
+func init() {
+	var err error
+	jwtKeys, err = loadJWTKeySet(JWTKeys, JWTSigningKeyID)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadJWTKeySet(spec, signingKeyID string) (*jwtKeySet, error) {
+	set := &jwtKeySet{keys: map[string]*jwtKey{}}
+
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		if ENV == "production" {
+			return nil, fmt.Errorf("JWT_KEYS must be configured in production")
+		}
+
+		// Sin llaves configuradas: llave efímera solo para desarrollo/tests
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key := &jwtKey{
+			ID:         "dev-" + randomToken(4),
+			Method:     jwt.SigningMethodEdDSA,
+			PrivateKey: private,
+			PublicKey:  private.Public(),
+		}
+		log.Printf("[WARN] JWT_KEYS not set, using ephemeral signing key %s", key.ID)
+		set.add(key)
+		set.signing = key
+		return set, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid JWT_KEYS entry %q, expected kid=path", entry)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading jwt key %s: %w", kid, err)
+		}
+		key, err := parseJWTKey(kid, data)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := set.keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %s", kid)
+		}
+		set.add(key)
+	}
+
+	if len(set.order) == 0 {
+		return nil, fmt.Errorf("JWT_KEYS does not contain any key")
+	}
+
+	if signingKeyID == "" {
+		signingKeyID = set.order[0]
+	}
+	signing, ok := set.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s not found in JWT_KEYS", signingKeyID)
+	}
+	if signing.PrivateKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKeyID)
+	}
+	set.signing = signing
+
+	return set, nil
+}
+
+func (s *jwtKeySet) add(key *jwtKey) {
+	s.keys[key.ID] = key
+	s.order = append(s.order, key.ID)
+}
+
+func parseJWTKey(kid string, data []byte) (*jwtKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt key %s is not PEM encoded", kid)
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwt key %s has unsupported PEM type %s", kid, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing jwt key %s: %w", kid, err)
+	}
+
+	key := &jwtKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("jwt key %s must be RSA or Ed25519", kid)
+	}
+
+	if rsaKey, ok := key.PublicKey.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("jwt key %s: RSA keys must be at least 2048 bits", kid)
+	}
+
+	return key, nil
+}
+
+// -- signing and verification
+
+func signToken(claims jwt.Claims) (string, error) {
+	key := jwtKeys.signing
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
+}
+
+func parseToken(tokenString string) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := jwtKeys.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}))
+}
@@ -3,6 +3,7 @@ package actions
 import (
 	"net/http"
 	"server/models"
+	"server/passwordhash"
 	"strings"
 	"time"
 
@@ -16,9 +17,11 @@ const (
 	TempTokenDuration    = 5 * time.Minute
 	MaxLoginAttempts     = 5
 	LockDuration         = 15 * time.Minute
-)
 
-var JWTSecret = []byte("your-secret-key-change-in-production")
+	EmailVerificationTokenDuration = 24 * time.Hour
+	PasswordResetTokenDuration     = 1 * time.Hour
+	EmailChangeTokenDuration       = 24 * time.Hour
+)
 
 type LoginRequest struct {
 	Email    string `json:"email"`
@@ -44,8 +47,9 @@ type LoginUser struct {
 }
 
 type Login2FAResponse struct {
-	TempToken string `json:"temp_token"`
-	Message   string `json:"message"`
+	TempToken string   `json:"temp_token"`
+	Message   string   `json:"message"`
+	Methods   []string `json:"methods"`
 }
 
 func AuthLogin(c buffalo.Context) error {
@@ -80,7 +84,9 @@ func AuthLogin(c buffalo.Context) error {
 	var user models.User
 	err := tx.Where("email = ?", req.Email).First(&user)
 	if err != nil {
-		recordLoginAttempt(tx, nil, req.Email, false, "user_not_found", c.Request())
+		// failed attempts are written outside the request transaction, which
+		// the error response rolls back
+		recordLoginAttempt(models.DB, nil, req.Email, false, "user_not_found", c.Request())
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Invalid email or password",
@@ -99,9 +105,14 @@ func AuthLogin(c buffalo.Context) error {
 		}))
 	}
 
-	if user.PasswordHash == nil || !verifyPassword(req.Password, *user.PasswordHash) {
-		recordLoginAttempt(tx, &user.ID, req.Email, false, "invalid_password", c.Request())
-		checkAndLockAccount(tx, user.ID)
+	var passwordOK, rehash bool
+	if user.PasswordHash != nil {
+		passwordOK, rehash = passwordhash.Verify(req.Password, *user.PasswordHash)
+	}
+	if !passwordOK {
+		// outside the request transaction, which the 401 rolls back
+		recordLoginAttempt(models.DB, &user.ID, req.Email, false, "invalid_password", c.Request())
+		checkAndLockAccount(models.DB, user.ID)
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Invalid email or password",
@@ -110,7 +121,7 @@ func AuthLogin(c buffalo.Context) error {
 	}
 
 	if !user.Active {
-		recordLoginAttempt(tx, &user.ID, req.Email, false, "account_inactive", c.Request())
+		recordLoginAttempt(models.DB, &user.ID, req.Email, false, "account_inactive", c.Request())
 		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Account is inactive",
@@ -118,11 +129,19 @@ func AuthLogin(c buffalo.Context) error {
 		}))
 	}
 
+	// outdated parameters or an imported bcrypt hash; outside the request
+	// transaction so a failure doesn't abort the login
+	if rehash {
+		if err := rehashPassword(models.DB, &user, req.Password); err != nil {
+			c.Logger().Errorf("rehashing password of user %s: %v", user.ID, err)
+		}
+	}
+
 	clearAccountLock(tx, user.ID)
 	recordLoginAttempt(tx, &user.ID, req.Email, true, "", c.Request())
 
-	if user.TwoFactorEnabled {
-		tempToken, err := generateToken(user, "temp_2fa", TempTokenDuration)
+	if methods := secondFactorMethods(tx, user); len(methods) > 0 {
+		tempToken, err := generateTempToken(user, "temp_2fa", TempTokenDuration, []string{AMRPassword})
 		if err != nil {
 			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 				Success:   false,
@@ -137,15 +156,25 @@ func AuthLogin(c buffalo.Context) error {
 			"data": Login2FAResponse{
 				TempToken: tempToken,
 				Message:   "Please provide 2FA code",
+				Methods:   methods,
 			},
 		}))
 	}
 
-	return generateAndReturnTokens(c, tx, user)
+	return generateAndReturnTokens(c, tx, user, []string{AMRPassword})
 }
 
-func generateAndReturnTokens(c buffalo.Context, tx *pop.Connection, user models.User) error {
-	accessToken, refreshToken, err := createSession(tx, user, c.Request())
+// generateAndReturnTokens opens a session for user, who authenticated with
+// the methods in amr. The login is scored first: a high-risk one gets a
+// step-up verification instead of tokens, and one from a new device is
+// notified.
+func generateAndReturnTokens(c buffalo.Context, tx *pop.Connection, user models.User, amr []string) error {
+	risk := assessLogin(tx, user, c.Request())
+	if risk.StepUp() && stepUpApplies(amr) {
+		return startLoginStepUp(c, tx, user, risk, amr)
+	}
+
+	accessToken, refreshToken, err := createSession(tx, user, c.Request(), amr)
 	if err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
@@ -158,6 +187,8 @@ func generateAndReturnTokens(c buffalo.Context, tx *pop.Connection, user models.
 	user.LastLoginAt = &now
 	tx.Update(&user)
 
+	notifyNewDevice(c, user, risk)
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"data": LoginResponse{
@@ -0,0 +1,279 @@
+package actions
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"server/loginrisk"
+	"server/mailers"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const (
+	// StepUpTokenDuration is how long the temp token and the emailed code
+	// of a step-up verification are valid.
+	StepUpTokenDuration = 10 * time.Minute
+
+	// loginRiskHistory is how many previous sessions a login is compared
+	// with.
+	loginRiskHistory = 100
+)
+
+type LoginStepUpResponse struct {
+	TempToken string   `json:"temp_token"`
+	Message   string   `json:"message"`
+	Methods   []string `json:"methods"`
+	Reasons   []string `json:"reasons"`
+	ExpiresIn int      `json:"expires_in"`
+}
+
+type LoginStepUpVerifyRequest struct {
+	TempToken string `json:"temp_token"`
+	Code      string `json:"code"`
+}
+
+// -- scoring
+
+// assessLogin scores a login of user against the sessions the user opened
+// before. Sessions only exist for logins that were completed, so a device
+// that never got past a step-up doesn't become known.
+func assessLogin(tx *pop.Connection, user models.User, r *http.Request) loginrisk.Assessment {
+	now := time.Now().UTC()
+
+	var sessions models.Sessions
+	tx.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(loginRiskHistory).All(&sessions)
+
+	history := make([]loginrisk.Login, 0, len(sessions))
+	for _, s := range sessions {
+		var info DeviceInfo
+		json.Unmarshal(s.DeviceInfo, &info)
+		history = append(history, riskLogin(info, s.CreatedAt))
+	}
+
+	var failures int
+	tx.RawQuery(`
+		SELECT COUNT(*) FROM auth.login_attempts
+		WHERE user_id = ? AND success = false AND created_at > ?
+	`, user.ID, now.Add(-LockDuration)).First(&failures)
+
+	return loginrisk.Assess(riskLogin(extractDeviceInfo(r), now), history, failures)
+}
+
+func riskLogin(info DeviceInfo, at time.Time) loginrisk.Login {
+	login := loginrisk.Login{IPAddress: info.IPAddress, UserAgent: info.UserAgent, At: at}
+	if info.Location != nil && info.Location.Located() {
+		login.Located = true
+		login.Latitude, login.Longitude = *info.Location.Latitude, *info.Location.Longitude
+	}
+	return login
+}
+
+// notifyNewDevice tells the user about a login from a device they never
+// used before. It writes outside the request transaction so a failure
+// doesn't abort the login.
+func notifyNewDevice(c buffalo.Context, user models.User, risk loginrisk.Assessment) {
+	if !risk.NewDevice {
+		return
+	}
+	recordAuditEvent(models.DB, c.Request(), AuditLoginNewDevice, &user.ID, &user.ID, map[string]any{
+		"score":   risk.Score,
+		"reasons": risk.Reasons,
+	})
+	info := extractDeviceInfo(c.Request())
+	device, location := info.describe()
+	if err := mailers.SendNewDeviceLoginEmail(models.DB, user, info.IPAddress, device, location, time.Now().UTC()); err != nil {
+		c.Logger().Errorf("sending new device email to user %s: %v", user.ID, err)
+	}
+}
+
+// -- step-up
+
+// stepUpApplies tells whether a login with amr can be stepped up: only
+// those that proved a password or a provider account alone. The others
+// already passed a second factor or proved control of the email, which the
+// step-up couldn't add to.
+func stepUpApplies(amr []string) bool {
+	return len(amr) == 1 && (amr[0] == AMRPassword || amr[0] == AMRFederated)
+}
+
+// startLoginStepUp answers a high-risk login with a temp token instead of
+// tokens, and emails a code bound to it. The login completes with
+// AuthLoginStepUpVerify.
+func startLoginStepUp(c buffalo.Context, tx *pop.Connection, user models.User, risk loginrisk.Assessment, amr []string) error {
+	claims := tokenClaims(user, "temp_step_up", StepUpTokenDuration)
+	claims["amr"] = amr
+	tempToken, err := signToken(claims)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to generate token",
+			ErrorCode: "TOKEN_GENERATION_FAILED",
+		}))
+	}
+	tokenID, _ := claims["jti"].(string)
+
+	code, err := numericCode(6)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to generate code",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	vt := models.VerificationToken{
+		UserID:    &user.ID,
+		TokenHash: stepUpCodeHash(tokenID, code),
+		TokenType: "login_step_up",
+		ExpiresAt: time.Now().UTC().Add(StepUpTokenDuration),
+		Used:      false,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := tx.Create(&vt); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to create verification code",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	info := extractDeviceInfo(c.Request())
+	device, location := info.describe()
+	if err := mailers.SendLoginStepUpEmail(tx, user, code, info.IPAddress, device, location, StepUpTokenDuration); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to send verification code",
+			ErrorCode: "EMAIL_SEND_FAILED",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditLoginStepUpRequired, map[string]any{
+		"score":   risk.Score,
+		"reasons": risk.Reasons,
+	})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success":          true,
+		"requires_step_up": true,
+		"data": LoginStepUpResponse{
+			TempToken: tempToken,
+			Message:   "We sent a verification code to your email",
+			Methods:   []string{"email"},
+			Reasons:   risk.Reasons,
+			ExpiresIn: int(StepUpTokenDuration.Seconds()),
+		},
+	}))
+}
+
+// AuthLoginStepUpVerify completes a login that needed a step-up with the
+// code emailed for its temp token. Wrong codes count against the same
+// limits as 2FA codes.
+func AuthLoginStepUpVerify(c buffalo.Context) error {
+	var req LoginStepUpVerifyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.TempToken = strings.TrimSpace(req.TempToken)
+	req.Code = strings.TrimSpace(req.Code)
+
+	if req.TempToken == "" || req.Code == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Temp token and code are required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	user, claims, errResp := tempTokenUser(tx, req.TempToken, "temp_step_up")
+	if errResp != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(errResp))
+	}
+	tokenID, _ := claims["jti"].(string)
+
+	if lock, locked := accountLock(tx, user.ID); locked {
+		return renderAccountLocked(c, lock)
+	}
+
+	if !user.Active {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account is inactive",
+			ErrorCode: "ACCOUNT_INACTIVE",
+		}))
+	}
+
+	failedAttempts := count2FAFailures(tx, tokenID)
+	if failedAttempts >= Max2FAAttempts {
+		return c.Render(http.StatusTooManyRequests, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Too many failed attempts. Please log in again.",
+			ErrorCode: "TOO_MANY_ATTEMPTS",
+		}))
+	}
+
+	// single use: the update only matches an unused, unexpired code
+	now := time.Now().UTC()
+	var vt models.VerificationToken
+	err := tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = ?
+		WHERE token_hash = ? AND token_type = ? AND user_id = ? AND used = false AND expires_at > ?
+		RETURNING *
+	`, now, stepUpCodeHash(tokenID, req.Code), "login_step_up", user.ID, now).First(&vt)
+	if err != nil {
+		record2FAFailure(user, tokenID, "step_up_failed", c.Request())
+
+		attemptsRemaining := Max2FAAttempts - failedAttempts - 1
+		if attemptsRemaining < 0 {
+			attemptsRemaining = 0
+		}
+
+		return c.Render(http.StatusBadRequest, r.JSON(Verify2FAErrorResponse{
+			Success:           false,
+			Error:             "Invalid or expired code",
+			ErrorCode:         "INVALID_CODE",
+			AttemptsRemaining: attemptsRemaining,
+		}))
+	}
+
+	recordLoginAttempt(tx, &user.ID, user.Email, true, "email_code", c.Request())
+
+	return generateAndReturnTokens(c, tx, user, withMethod(claimedAMR(claims), AMREmail))
+}
+
+// stepUpCodeHash binds a code to the temp token it was sent for, so it
+// can't be used with another login.
+func stepUpCodeHash(tokenID, code string) string {
+	return sha256Hex(tokenID + ":" + code)
+}
+
+// numericCode returns a random code of digits, easy to type from an email.
+func numericCode(digits int) (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
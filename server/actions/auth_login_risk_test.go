@@ -0,0 +1,60 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// stepUpToken is the temp token of a password login that needed a step-up,
+// with code stored as the one emailed for it.
+func (as *ActionSuite) stepUpToken(user models.User, code string) string {
+	claims := tokenClaims(user, "temp_step_up", StepUpTokenDuration)
+	claims["amr"] = []string{AMRPassword}
+	tempToken, err := signToken(claims)
+	as.NoError(err)
+
+	as.NoError(as.DB.Create(&models.VerificationToken{
+		UserID:    &user.ID,
+		TokenHash: stepUpCodeHash(claims["jti"].(string), code),
+		TokenType: "login_step_up",
+		ExpiresAt: time.Now().UTC().Add(StepUpTokenDuration),
+		CreatedAt: time.Now().UTC(),
+	}))
+	return tempToken
+}
+
+func (as *ActionSuite) Test_AuthLoginStepUpVerify() {
+	user := as.createUser("stepup@example.com", RoleSupport)
+	tempToken := as.stepUpToken(user, "123456")
+	otherToken := as.stepUpToken(user, "654321")
+
+	res := as.JSON("/api/v1/auth/login/step-up").Post(LoginStepUpVerifyRequest{TempToken: tempToken, Code: "000000"})
+	as.Equal(http.StatusBadRequest, res.Code)
+	var failure Verify2FAErrorResponse
+	res.Bind(&failure)
+	as.Equal("INVALID_CODE", failure.ErrorCode)
+	as.Equal(Max2FAAttempts-1, failure.AttemptsRemaining)
+
+	// the code only works with the login it was sent for
+	res = as.JSON("/api/v1/auth/login/step-up").Post(LoginStepUpVerifyRequest{TempToken: tempToken, Code: "654321"})
+	as.Equal(http.StatusBadRequest, res.Code)
+
+	res = as.JSON("/api/v1/auth/login/step-up").Post(LoginStepUpVerifyRequest{TempToken: tempToken, Code: "123456"})
+	as.Equal(http.StatusOK, res.Code)
+	var body struct {
+		Data LoginResponse `json:"data"`
+	}
+	res.Bind(&body)
+	token, err := parseToken(body.Data.AccessToken)
+	as.NoError(err)
+	as.Equal([]string{AMRPassword, AMREmail}, claimedAMR(token.Claims.(jwt.MapClaims)))
+
+	res = as.JSON("/api/v1/auth/login/step-up").Post(LoginStepUpVerifyRequest{TempToken: tempToken, Code: "123456"})
+	as.Equal(http.StatusBadRequest, res.Code)
+
+	res = as.JSON("/api/v1/auth/login/step-up").Post(LoginStepUpVerifyRequest{TempToken: otherToken, Code: "654321"})
+	as.Equal(http.StatusOK, res.Code)
+}
@@ -0,0 +1,35 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+)
+
+// loginFailures counts the login.failed audit events recorded for email.
+func (as *ActionSuite) loginFailures(email, reason string) int {
+	count, err := as.DB.Where("event_type = ? AND metadata->>'email' = ? AND metadata->>'reason' = ?",
+		AuditLoginFailed, email, reason).Count(&models.AuditEvent{})
+	as.NoError(err)
+	return count
+}
+
+func (as *ActionSuite) Test_AuthLogin_FailuresAreAudited() {
+	user := as.createUser("login@example.com", RoleSupport)
+
+	res := as.JSON("/api/v1/auth/login").Post(LoginRequest{Email: user.Email, Password: "wrong-password"})
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal(1, as.loginFailures(user.Email, "invalid_password"))
+
+	res = as.JSON("/api/v1/auth/login").Post(LoginRequest{Email: "nobody@example.com", Password: testPassword})
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal(1, as.loginFailures("nobody@example.com", "user_not_found"))
+
+	as.NoError(as.DB.RawQuery("UPDATE auth.users SET active = false WHERE id = ?", user.ID).Exec())
+	res = as.JSON("/api/v1/auth/login").Post(LoginRequest{Email: user.Email, Password: testPassword})
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal(1, as.loginFailures(user.Email, "account_inactive"))
+
+	count, err := as.DB.Where("email = ? AND success = ?", user.Email, false).Count(&models.LoginAttempt{})
+	as.NoError(err)
+	as.Equal(2, count)
+}
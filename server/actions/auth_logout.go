@@ -67,6 +67,11 @@ func AuthLogout(c buffalo.Context) error {
 			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
+	forgetSession(c, session.ID)
+
+	recordAuditEvent(tx, c.Request(), AuditLogout, &session.UserID, &session.UserID, map[string]any{
+		"session_id": session.ID,
+	})
 
 	return c.Render(http.StatusOK, r.JSON(LogoutResponse{
 		Success: true,
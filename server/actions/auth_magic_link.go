@@ -0,0 +1,243 @@
+package actions
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"server/mailers"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const MagicLinkTokenDuration = 15 * time.Minute
+
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+type MagicLinkConsumeRequest struct {
+	Token        string `json:"token"`
+	DeviceSecret string `json:"device_secret"`
+}
+
+// AuthMagicLinkRequest emails a one-time sign-in link. The response carries
+// a device secret the browser keeps until the link is opened: the token is
+// bound to it, so a link forwarded or opened elsewhere is useless. The
+// response is the same whether the email exists or not.
+func AuthMagicLinkRequest(c buffalo.Context) error {
+	var req MagicLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	if req.Email == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Email is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	deviceSecret := randomToken(32)
+
+	successResponse := map[string]interface{}{
+		"success": true,
+		"message": "If the email exists, a sign-in link has been sent",
+		"data": map[string]interface{}{
+			"device_secret": deviceSecret,
+			"expires_in":    int(MagicLinkTokenDuration.Seconds()),
+		},
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusOK, r.JSON(successResponse))
+	}
+
+	var user models.User
+	if err := tx.Where("email = ?", req.Email).First(&user); err != nil || !user.Active {
+		return c.Render(http.StatusOK, r.JSON(successResponse))
+	}
+
+	// only the latest link works
+	tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = NOW()
+		WHERE user_id = ? AND token_type = ? AND used = false
+	`, user.ID, "magic_link").Exec()
+
+	rawToken := randomToken(32)
+	deviceHash := sha256Hex(deviceSecret)
+
+	vt := models.VerificationToken{
+		UserID:     &user.ID,
+		Email:      &user.Email,
+		TokenHash:  sha256Hex(rawToken),
+		TokenType:  "magic_link",
+		DeviceHash: &deviceHash,
+		ExpiresAt:  time.Now().UTC().Add(MagicLinkTokenDuration),
+		Used:       false,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	if err := tx.Create(&vt); err != nil {
+		return c.Render(http.StatusOK, r.JSON(successResponse))
+	}
+
+	recordAuditEvent(tx, c.Request(), AuditMagicLinkRequested, nil, &user.ID, nil)
+
+	if err := mailers.SendMagicLinkEmail(tx, user, rawToken, MagicLinkTokenDuration); err != nil {
+		c.Logger().Errorf("queue magic link email for %s: %v", user.Email, err)
+	}
+
+	return c.Render(http.StatusOK, r.JSON(successResponse))
+}
+
+// AuthMagicLinkConsume signs in with a magic link token and the device
+// secret of the browser that requested it. Users with a second factor get a
+// temp_2fa token like a password login.
+func AuthMagicLinkConsume(c buffalo.Context) error {
+	var req MagicLinkConsumeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	req.DeviceSecret = strings.TrimSpace(req.DeviceSecret)
+
+	if req.Token == "" || req.DeviceSecret == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token and device secret are required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	var vt models.VerificationToken
+	err := tx.Where("token_hash = ? AND token_type = ? AND used = ?", sha256Hex(req.Token), "magic_link", false).First(&vt)
+	if err != nil || vt.UserID == nil || vt.DeviceHash == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired token",
+			ErrorCode: "INVALID_TOKEN",
+		}))
+	}
+
+	if time.Now().UTC().After(vt.ExpiresAt) {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token has expired",
+			ErrorCode: "TOKEN_EXPIRED",
+		}))
+	}
+
+	// the token stays usable from the right browser
+	if subtle.ConstantTimeCompare([]byte(sha256Hex(req.DeviceSecret)), []byte(*vt.DeviceHash)) != 1 {
+		// outside the request transaction, which the 400 rolls back
+		recordAuditEvent(models.DB, c.Request(), AuditLoginFailed, nil, vt.UserID, map[string]any{
+			"email":  vt.Email,
+			"reason": "magic_link_device_mismatch",
+		})
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Open the link in the browser where it was requested",
+			ErrorCode: "DEVICE_MISMATCH",
+		}))
+	}
+
+	// claimed with a conditional update so two requests can't both use it
+	n, err := tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = ?
+		WHERE id = ? AND used = false
+	`, time.Now().UTC(), vt.ID).ExecWithCount()
+	if err != nil || n == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired token",
+			ErrorCode: "INVALID_TOKEN",
+		}))
+	}
+
+	var user models.User
+	if err := tx.Find(&user, *vt.UserID); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	if lock, locked := accountLock(tx, user.ID); locked {
+		return renderAccountLocked(c, lock)
+	}
+
+	if !user.Active {
+		recordLoginAttempt(models.DB, &user.ID, user.Email, false, "account_inactive", c.Request())
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account is inactive",
+			ErrorCode: "ACCOUNT_INACTIVE",
+		}))
+	}
+
+	// the link was delivered to the address, which proves it
+	if !user.EmailVerified {
+		user.EmailVerified = true
+		if err := tx.Update(&user); err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to update user",
+				ErrorCode: "INTERNAL_ERROR",
+			}))
+		}
+	}
+
+	recordLoginAttempt(tx, &user.ID, user.Email, true, "magic_link", c.Request())
+
+	if methods := secondFactorMethods(tx, user); len(methods) > 0 {
+		tempToken, err := generateTempToken(user, "temp_2fa", TempTokenDuration, []string{AMREmail})
+		if err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to generate token",
+				ErrorCode: "TOKEN_GENERATION_FAILED",
+			}))
+		}
+
+		return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+			"success":      true,
+			"requires_2fa": true,
+			"data": Login2FAResponse{
+				TempToken: tempToken,
+				Message:   "Please provide 2FA code",
+				Methods:   methods,
+			},
+		}))
+	}
+
+	// the link already proved control of the email, like a step-up code
+	return generateAndReturnTokens(c, tx, user, []string{AMREmail})
+}
@@ -0,0 +1,111 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gobuffalo/httptest"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// magicLink stores a magic link for user bound to deviceSecret, as
+// AuthMagicLinkRequest does, and returns its raw token.
+func (as *ActionSuite) magicLink(user models.User, deviceSecret string, expiresAt time.Time) string {
+	rawToken := randomToken(32)
+	deviceHash := sha256Hex(deviceSecret)
+	as.NoError(as.DB.Create(&models.VerificationToken{
+		UserID:     &user.ID,
+		Email:      &user.Email,
+		TokenHash:  sha256Hex(rawToken),
+		TokenType:  "magic_link",
+		DeviceHash: &deviceHash,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  time.Now().UTC(),
+	}))
+	return rawToken
+}
+
+func (as *ActionSuite) consumeMagicLink(token, deviceSecret string) *httptest.JSONResponse {
+	return as.JSON("/api/v1/auth/magic-link/consume").Post(MagicLinkConsumeRequest{Token: token, DeviceSecret: deviceSecret})
+}
+
+func (as *ActionSuite) Test_AuthMagicLinkConsume_SignsInOnce() {
+	user := as.createUser("magic@example.com", RoleSupport)
+	token := as.magicLink(user, "device-secret", time.Now().UTC().Add(MagicLinkTokenDuration))
+
+	res := as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusOK, res.Code)
+	var body struct {
+		Data LoginResponse `json:"data"`
+	}
+	res.Bind(&body)
+	as.NotEmpty(body.Data.AccessToken)
+
+	res = as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+}
+
+func (as *ActionSuite) Test_AuthMagicLinkConsume_DeviceMismatch() {
+	user := as.createUser("magic@example.com", RoleSupport)
+	token := as.magicLink(user, "device-secret", time.Now().UTC().Add(MagicLinkTokenDuration))
+
+	res := as.consumeMagicLink(token, "other-browser")
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("DEVICE_MISMATCH", errorCode(res))
+
+	count, err := as.DB.Where("event_type = ? AND target_id = ? AND metadata->>'reason' = ?",
+		AuditLoginFailed, user.ID, "magic_link_device_mismatch").Count(&models.AuditEvent{})
+	as.NoError(err)
+	as.Equal(1, count)
+
+	// the link still works from the browser that requested it
+	res = as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusOK, res.Code)
+}
+
+func (as *ActionSuite) Test_AuthMagicLinkConsume_Expired() {
+	user := as.createUser("magic@example.com", RoleSupport)
+	token := as.magicLink(user, "device-secret", time.Now().UTC().Add(-time.Minute))
+
+	res := as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("TOKEN_EXPIRED", errorCode(res))
+}
+
+func (as *ActionSuite) Test_AuthMagicLinkConsume_NewerRequestInvalidatesOlder() {
+	user := as.createUser("magic@example.com", RoleSupport)
+	token := as.magicLink(user, "device-secret", time.Now().UTC().Add(MagicLinkTokenDuration))
+
+	res := as.JSON("/api/v1/auth/magic-link/request").Post(MagicLinkRequest{Email: user.Email})
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+}
+
+func (as *ActionSuite) Test_AuthMagicLinkConsume_Requires2FA() {
+	user := as.createUser("magic@example.com", RoleSupport)
+	as.enable2FA(&user)
+	token := as.magicLink(user, "device-secret", time.Now().UTC().Add(MagicLinkTokenDuration))
+
+	res := as.consumeMagicLink(token, "device-secret")
+	as.Equal(http.StatusOK, res.Code)
+	var body struct {
+		Requires2FA bool             `json:"requires_2fa"`
+		Data        Login2FAResponse `json:"data"`
+	}
+	res.Bind(&body)
+	as.True(body.Requires2FA)
+	as.Equal([]string{"totp"}, body.Data.Methods)
+
+	token2FA, err := parseToken(body.Data.TempToken)
+	as.NoError(err)
+	as.Equal([]string{AMREmail}, claimedAMR(token2FA.Claims.(jwt.MapClaims)))
+
+	count, err := as.DB.Where("user_id = ?", user.ID).Count(&models.Session{})
+	as.NoError(err)
+	as.Equal(0, count)
+}
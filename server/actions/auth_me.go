@@ -18,10 +18,13 @@ type MeResponse struct {
 	Role             string   `json:"role"`
 	Active           bool     `json:"active"`
 	TwoFactorEnabled bool     `json:"two_factor_enabled"`
+	Permissions      []string `json:"permissions"`
 	OAuthProviders   []string `json:"oauth_providers"`
 	HasPassword      bool     `json:"has_password"`
 	CreatedAt        string   `json:"created_at"`
 	LastLoginAt      *string  `json:"last_login_at,omitempty"`
+
+	DeletionScheduledAt *string `json:"deletion_scheduled_at,omitempty"`
 }
 
 func AuthMe(c buffalo.Context) error {
@@ -57,6 +60,12 @@ func AuthMe(c buffalo.Context) error {
 		lastLoginAt = &formatted
 	}
 
+	var deletionScheduledAt *string
+	if user.DeletionScheduledAt != nil {
+		formatted := user.DeletionScheduledAt.Format("2006-01-02T15:04:05Z")
+		deletionScheduledAt = &formatted
+	}
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"data": MeResponse{
@@ -69,10 +78,13 @@ func AuthMe(c buffalo.Context) error {
 			Role:             user.Role,
 			Active:           user.Active,
 			TwoFactorEnabled: user.TwoFactorEnabled,
+			Permissions:      rolePermissions(tx, user.Role),
 			OAuthProviders:   providers,
 			HasPassword:      user.PasswordHash != nil,
 			CreatedAt:        user.CreatedAt.Format("2006-01-02T15:04:05Z"),
 			LastLoginAt:      lastLoginAt,
+
+			DeletionScheduledAt: deletionScheduledAt,
 		},
 	}))
 }
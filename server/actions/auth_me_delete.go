@@ -0,0 +1,137 @@
+package actions
+
+import (
+	"net/http"
+	"server/mailers"
+	"server/privacy"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// AuthMeDelete schedules the deletion of the current account after
+// privacy.GracePeriod, signs out every other session and revokes the
+// personal access tokens. Routes put it behind RequireRecentAuth.
+func AuthMeDelete(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	if user.DeletionScheduledAt != nil {
+		return c.Render(http.StatusConflict, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account deletion already scheduled",
+			ErrorCode: "DELETION_ALREADY_SCHEDULED",
+			Details: map[string]any{
+				"deletion_scheduled_at": user.DeletionScheduledAt,
+			},
+		}))
+	}
+
+	sessionID, _ := GetCurrentSessionID(c)
+
+	scheduledAt := time.Now().UTC().Add(privacy.GracePeriod)
+	user.DeletionScheduledAt = &scheduledAt
+	user.UpdatedAt = time.Now().UTC()
+	if err := tx.Update(&user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to schedule account deletion",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	if _, err := revokeUserSessions(c, tx, user.ID, sessionID); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke sessions",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if _, err := revokeUserTokens(tx, user.ID); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke tokens",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditAccountDeletionScheduled, map[string]any{"deletion_scheduled_at": scheduledAt})
+
+	if err := mailers.SendAccountDeletionScheduledEmail(tx, user, scheduledAt); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to schedule account deletion",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Account deletion scheduled",
+		"data": map[string]interface{}{
+			"deletion_scheduled_at": scheduledAt,
+		},
+	}))
+}
+
+// AuthMeDeleteCancel cancels a scheduled deletion during the grace period.
+func AuthMeDeleteCancel(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	if user.DeletionScheduledAt == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "No account deletion scheduled",
+			ErrorCode: "DELETION_NOT_SCHEDULED",
+		}))
+	}
+
+	user.DeletionScheduledAt = nil
+	user.UpdatedAt = time.Now().UTC()
+	if err := tx.Update(&user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to cancel account deletion",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditAccountDeletionCancelled, nil)
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Account deletion cancelled",
+	}))
+}
@@ -0,0 +1,120 @@
+package actions
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"server/models"
+	"server/privacy"
+	"time"
+)
+
+func (as *ActionSuite) Test_AuthMeDelete_SecondRequestConflicts() {
+	user := as.createUser("delete@example.com", RoleSupport)
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/delete").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+
+	var reloaded models.User
+	as.NoError(as.DB.Find(&reloaded, user.ID))
+	as.NotNil(reloaded.DeletionScheduledAt)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/delete").Post(nil)
+	as.Equal(http.StatusConflict, res.Code)
+	as.Equal("DELETION_ALREADY_SCHEDULED", errorCode(res))
+}
+
+func (as *ActionSuite) Test_AuthMeDeleteCancel() {
+	user := as.createUser("delete@example.com", RoleSupport)
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/delete/cancel").Post(nil)
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("DELETION_NOT_SCHEDULED", errorCode(res))
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/delete").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/delete/cancel").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+
+	var reloaded models.User
+	as.NoError(as.DB.Find(&reloaded, user.ID))
+	as.Nil(reloaded.DeletionScheduledAt)
+
+	// nothing left for the job to erase
+	deleted, err := privacy.DeleteDue(as.DB, time.Now().UTC().Add(privacy.GracePeriod+time.Hour))
+	as.NoError(err)
+	as.Equal(0, deleted)
+}
+
+func (as *ActionSuite) Test_DeleteDue_AnonymisesLoginAttempts() {
+	user := as.createUser("delete@example.com", RoleSupport)
+	other := as.createUser("other@example.com", RoleSupport)
+	past := time.Now().UTC().Add(-time.Minute)
+	user.DeletionScheduledAt = &past
+	as.NoError(as.DB.Update(&user))
+
+	ip := "203.0.113.7"
+	for _, attempt := range []models.LoginAttempt{
+		{UserID: &user.ID, Email: &user.Email, Success: true, IPAddress: &ip},
+		// failed with the email, before or without a user id
+		{Email: &user.Email, Success: false, IPAddress: &ip},
+		{UserID: &other.ID, Email: &other.Email, Success: true, IPAddress: &ip},
+	} {
+		attempt.CreatedAt = time.Now().UTC()
+		as.NoError(as.DB.Create(&attempt))
+	}
+
+	deleted, err := privacy.DeleteDue(as.DB, time.Now().UTC())
+	as.NoError(err)
+	as.Equal(1, deleted)
+
+	count, err := as.DB.Where("id = ?", user.ID).Count(&models.User{})
+	as.NoError(err)
+	as.Equal(0, count)
+
+	var attempts []models.LoginAttempt
+	as.NoError(as.DB.Where("user_id IS NULL").All(&attempts))
+	as.Len(attempts, 2)
+	for _, attempt := range attempts {
+		as.Nil(attempt.Email)
+		as.Nil(attempt.IPAddress)
+	}
+
+	count, err = as.DB.Where("user_id = ? AND email = ? AND ip_address = ?", other.ID, other.Email, ip).Count(&models.LoginAttempt{})
+	as.NoError(err)
+	as.Equal(1, count)
+}
+
+func (as *ActionSuite) Test_AuthMeExport_Zip() {
+	user := as.createUser("export@example.com", RoleSupport)
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/export?format=zip").Get()
+	as.Equal(http.StatusOK, res.Code)
+	as.Equal("application/zip", res.Header().Get("Content-Type"))
+
+	body := res.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	as.NoError(err)
+
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	as.Contains(files, "profile.json")
+	as.Contains(files, "sessions.json")
+
+	rc, err := files["profile.json"].Open()
+	as.NoError(err)
+	defer rc.Close()
+	var profile map[string]any
+	as.NoError(json.NewDecoder(rc).Decode(&profile))
+	as.Equal(user.Email, profile["email"])
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/export?format=csv").Get()
+	as.Equal(http.StatusBadRequest, res.Code)
+}
@@ -0,0 +1,318 @@
+package actions
+
+import (
+	"net/http"
+	"net/mail"
+	"server/mailers"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+type EmailChangeRequest struct {
+	NewEmail string `json:"new_email"`
+}
+
+type EmailChangeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// AuthMeEmailChange records a pending email change. The new address gets a
+// token to confirm it and the current one a link to cancel it; the email
+// only changes on confirmation.
+func AuthMeEmailChange(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	var req EmailChangeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.NewEmail = strings.ToLower(strings.TrimSpace(req.NewEmail))
+
+	if addr, err := mail.ParseAddress(req.NewEmail); err != nil || addr.Address != req.NewEmail {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "A valid new email is required",
+			ErrorCode: "VALIDATION_ERROR",
+			Details: map[string]any{
+				"new_email": "Invalid email",
+			},
+		}))
+	}
+
+	if req.NewEmail == user.Email {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "New email is the current email",
+			ErrorCode: "SAME_EMAIL",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	if emailInUse(tx, req.NewEmail, user.ID) {
+		return c.Render(http.StatusConflict, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Email already registered",
+			ErrorCode: "EMAIL_ALREADY_EXISTS",
+		}))
+	}
+
+	// a new request replaces the pending one
+	burnEmailChangeTokens(tx, user.ID)
+
+	confirmToken := randomToken(32)
+	cancelToken := randomToken(32)
+	expiresAt := time.Now().UTC().Add(EmailChangeTokenDuration)
+
+	for _, vt := range []models.VerificationToken{
+		{UserID: &user.ID, Email: &req.NewEmail, TokenHash: sha256Hex(confirmToken), TokenType: "email_change"},
+		{UserID: &user.ID, Email: &req.NewEmail, TokenHash: sha256Hex(cancelToken), TokenType: "email_change_cancel"},
+	} {
+		vt.ExpiresAt = expiresAt
+		vt.CreatedAt = time.Now().UTC()
+		if err := tx.Create(&vt); err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to create verification token",
+				ErrorCode: "TOKEN_CREATE_FAILED",
+			}))
+		}
+	}
+
+	recordUserEvent(tx, c, user, AuditEmailChangeRequested, map[string]any{"new_email": req.NewEmail})
+
+	if err := mailers.SendEmailChangeEmail(tx, user, req.NewEmail, confirmToken, EmailChangeTokenDuration); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to send confirmation email",
+			ErrorCode: "EMAIL_SEND_FAILED",
+		}))
+	}
+	if err := mailers.SendEmailChangeNoticeEmail(tx, user, req.NewEmail, cancelToken, EmailChangeTokenDuration); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to send confirmation email",
+			ErrorCode: "EMAIL_SEND_FAILED",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Confirmation sent to the new email",
+		"data": map[string]interface{}{
+			"pending_email": req.NewEmail,
+			"expires_at":    expiresAt,
+		},
+	}))
+}
+
+// AuthMeEmailConfirm applies a pending email change. It must be called by
+// the same user, logged in, with the token sent to the new address. Every
+// other session is revoked.
+func AuthMeEmailConfirm(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	var req EmailChangeTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	var vt models.VerificationToken
+	err = tx.Where("token_hash = ? AND token_type = ? AND used = ? AND user_id = ?", sha256Hex(req.Token), "email_change", false, user.ID).First(&vt)
+	if err != nil || vt.Email == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired token",
+			ErrorCode: "INVALID_TOKEN",
+		}))
+	}
+
+	if time.Now().UTC().After(vt.ExpiresAt) {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token has expired",
+			ErrorCode: "TOKEN_EXPIRED",
+		}))
+	}
+
+	// someone may have taken the address since the request
+	if emailInUse(tx, *vt.Email, user.ID) {
+		return c.Render(http.StatusConflict, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Email already registered",
+			ErrorCode: "EMAIL_ALREADY_EXISTS",
+		}))
+	}
+
+	previousEmail := user.Email
+	user.Email = *vt.Email
+	user.EmailVerified = true
+	user.UpdatedAt = time.Now().UTC()
+	if err := tx.Update(&user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to change email",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	// links mailed to the old address (reset, magic link, cancel) stop working
+	tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = ?
+		WHERE user_id = ? AND used = false
+	`, time.Now().UTC(), user.ID).Exec()
+
+	sessionID, _ := GetCurrentSessionID(c)
+	if _, err := revokeUserSessions(c, tx, user.ID, sessionID); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke sessions",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if _, err := revokeUserTokens(tx, user.ID); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke tokens",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditEmailChanged, map[string]any{
+		"previous_email": previousEmail,
+		"email":          user.Email,
+	})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Email changed successfully",
+		"data": map[string]interface{}{
+			"email": user.Email,
+		},
+	}))
+}
+
+// AuthEmailChangeCancel cancels a pending email change with the link sent
+// to the current address. It needs no session: whoever owns the old mailbox
+// may no longer be logged in.
+func AuthEmailChangeCancel(c buffalo.Context) error {
+	var req EmailChangeTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	var vt models.VerificationToken
+	err := tx.Where("token_hash = ? AND token_type = ? AND used = ?", sha256Hex(req.Token), "email_change_cancel", false).First(&vt)
+	if err != nil || vt.UserID == nil || time.Now().UTC().After(vt.ExpiresAt) {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired token",
+			ErrorCode: "INVALID_TOKEN",
+		}))
+	}
+
+	burnEmailChangeTokens(tx, *vt.UserID)
+	recordAuditEvent(tx, c.Request(), AuditEmailChangeCancelled, nil, vt.UserID, map[string]any{
+		"new_email": vt.Email,
+	})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Email change cancelled",
+	}))
+}
+
+// emailInUse reports whether email belongs to another user, as their
+// account email or the email of a linked OAuth provider.
+func emailInUse(tx *pop.Connection, email string, userID uuid.UUID) bool {
+	var inUse bool
+	err := tx.RawQuery(`
+		SELECT EXISTS (SELECT 1 FROM auth.users WHERE email = ? AND id != ?)
+		    OR EXISTS (SELECT 1 FROM auth.oauth_providers WHERE LOWER(provider_email) = ? AND user_id != ?)
+	`, email, userID, email, userID).First(&inUse)
+	return err != nil || inUse
+}
+
+func burnEmailChangeTokens(tx *pop.Connection, userID uuid.UUID) {
+	tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = ?
+		WHERE user_id = ? AND token_type IN (?, ?) AND used = false
+	`, time.Now().UTC(), userID, "email_change", "email_change_cancel").Exec()
+}
@@ -0,0 +1,99 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+)
+
+// emailChange stores a pending change of user's email, as AuthMeEmailChange
+// does, and returns its confirm and cancel tokens.
+func (as *ActionSuite) emailChange(user models.User, newEmail string) (string, string) {
+	confirmToken, cancelToken := randomToken(32), randomToken(32)
+	for token, tokenType := range map[string]string{confirmToken: "email_change", cancelToken: "email_change_cancel"} {
+		as.NoError(as.DB.Create(&models.VerificationToken{
+			UserID:    &user.ID,
+			Email:     &newEmail,
+			TokenHash: sha256Hex(token),
+			TokenType: tokenType,
+			ExpiresAt: time.Now().UTC().Add(EmailChangeTokenDuration),
+			CreatedAt: time.Now().UTC(),
+		}))
+	}
+	return confirmToken, cancelToken
+}
+
+func (as *ActionSuite) Test_AuthMeEmailChange_RejectsAnotherUsersProviderEmail() {
+	user := as.createUser("change@example.com", RoleSupport)
+	other := as.createUser("other@example.com", RoleSupport)
+	providerEmail := "Linked@Example.com"
+	as.NoError(as.DB.Create(&models.OAuthProvider{
+		UserID:         other.ID,
+		Provider:       "google",
+		ProviderUserID: "google-other",
+		ProviderEmail:  &providerEmail,
+	}))
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/email").Post(EmailChangeRequest{NewEmail: "linked@example.com"})
+	as.Equal(http.StatusConflict, res.Code)
+	as.Equal("EMAIL_ALREADY_EXISTS", errorCode(res))
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/email").Post(EmailChangeRequest{NewEmail: "new@example.com"})
+	as.Equal(http.StatusOK, res.Code)
+}
+
+func (as *ActionSuite) Test_AuthMeEmailConfirm_RejectsAnotherUsersToken() {
+	user := as.createUser("change@example.com", RoleSupport)
+	other := as.createUser("other@example.com", RoleSupport)
+	confirmToken, _ := as.emailChange(user, "new@example.com")
+	otherToken, _ := as.signIn(other)
+
+	res := as.authJSON(otherToken, "/api/v1/auth/me/email/confirm").Post(EmailChangeTokenRequest{Token: confirmToken})
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+
+	for _, u := range []models.User{user, other} {
+		var reloaded models.User
+		as.NoError(as.DB.Find(&reloaded, u.ID))
+		as.Equal(u.Email, reloaded.Email)
+	}
+}
+
+func (as *ActionSuite) Test_AuthMeEmailConfirm_RevokesOtherSessions() {
+	user := as.createUser("change@example.com", RoleSupport)
+	confirmToken, _ := as.emailChange(user, "new@example.com")
+	accessToken, _ := as.signIn(user)
+	otherSession, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/email/confirm").Post(EmailChangeTokenRequest{Token: confirmToken})
+	as.Equal(http.StatusOK, res.Code)
+
+	var reloaded models.User
+	as.NoError(as.DB.Find(&reloaded, user.ID))
+	as.Equal("new@example.com", reloaded.Email)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(otherSession, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("SESSION_INVALID", errorCode(res))
+}
+
+func (as *ActionSuite) Test_AuthEmailChangeCancel() {
+	user := as.createUser("change@example.com", RoleSupport)
+	confirmToken, cancelToken := as.emailChange(user, "new@example.com")
+	accessToken, _ := as.signIn(user)
+
+	// no session needed
+	res := as.JSON("/api/v1/auth/email-change/cancel").Post(EmailChangeTokenRequest{Token: cancelToken})
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/me/email/confirm").Post(EmailChangeTokenRequest{Token: confirmToken})
+	as.Equal(http.StatusBadRequest, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+
+	res = as.JSON("/api/v1/auth/email-change/cancel").Post(EmailChangeTokenRequest{Token: cancelToken})
+	as.Equal(http.StatusBadRequest, res.Code)
+}
@@ -0,0 +1,75 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"server/privacy"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// AuthMeExport downloads everything stored about the current user as JSON
+// (default) or, with ?format=zip, as a zip with one JSON file per section.
+func AuthMeExport(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Unauthorized",
+			ErrorCode: "UNAUTHORIZED",
+		}))
+	}
+
+	format := c.Param("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "zip" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Format must be json or zip",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	archive, err := privacy.Export(tx, user)
+	if err != nil {
+		c.Logger().Errorf("export data of %s: %v", user.ID, err)
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to export data",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditDataExported, map[string]any{"format": format})
+
+	filename := fmt.Sprintf("redorange-export-%s.%s", archive.ExportedAt.Format("20060102"), format)
+
+	res := c.Response()
+	res.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	res.Header().Set("Cache-Control", "no-store")
+
+	if format == "zip" {
+		res.Header().Set("Content-Type", "application/zip")
+		res.WriteHeader(http.StatusOK)
+		return archive.WriteZip(res)
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(res)
+	enc.SetIndent("", "  ")
+	return enc.Encode(archive)
+}
@@ -45,14 +45,18 @@ func AuthMeUpdate(c buffalo.Context) error {
 		}))
 	}
 
+	fields := []string{}
 	if req.Name != "" {
 		user.Name = strings.TrimSpace(req.Name)
+		fields = append(fields, "name")
 	}
 	if req.LastName != "" {
 		user.LastName = strings.TrimSpace(req.LastName)
+		fields = append(fields, "last_name")
 	}
 	if req.Profile != nil {
 		user.Profile = req.Profile
+		fields = append(fields, "profile")
 	}
 
 	user.UpdatedAt = time.Now().UTC()
@@ -65,6 +69,8 @@ func AuthMeUpdate(c buffalo.Context) error {
 		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditProfileUpdated, map[string]any{"fields": fields})
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"message": "Profile updated successfully",
@@ -7,9 +7,13 @@ import (
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AuthMiddleware authenticates requests with a session access token or a
+// personal access token, which authenticateToken checks against the
+// scope of the route.
 func AuthMiddleware(next buffalo.Handler) buffalo.Handler {
 	return func(c buffalo.Context) error {
 		authHeader := c.Request().Header.Get("Authorization")
@@ -32,9 +36,11 @@ func AuthMiddleware(next buffalo.Handler) buffalo.Handler {
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return JWTSecret, nil
-		})
+		if strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) {
+			return authenticateToken(c, next, tokenString)
+		}
+
+		token, err := parseToken(tokenString)
 
 		if err != nil || !token.Valid {
 			return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -63,6 +69,15 @@ func AuthMiddleware(next buffalo.Handler) buffalo.Handler {
 		}
 
 		userID, _ := claims["user_id"].(string)
+		sessionIDStr, _ := claims["sid"].(string)
+		sessionID, err := uuid.FromString(sessionIDStr)
+		if err != nil {
+			return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Invalid token claims",
+				ErrorCode: "INVALID_CLAIMS",
+			}))
+		}
 
 		tx, ok := c.Value("tx").(*pop.Connection)
 		if !ok || tx == nil {
@@ -90,8 +105,18 @@ func AuthMiddleware(next buffalo.Handler) buffalo.Handler {
 			}))
 		}
 
+		if !isSessionActive(tx, sessionID, user.ID) {
+			return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Session revoked or expired",
+				ErrorCode: "SESSION_INVALID",
+			}))
+		}
+
 		c.Set("current_user", user)
 		c.Set("user_id", userID)
+		c.Set("session_id", sessionID)
+		c.Set("authentication", claimedAuthentication(claims))
 
 		return next(c)
 	}
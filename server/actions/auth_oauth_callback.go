@@ -0,0 +1,232 @@
+package actions
+
+import (
+	"net/http"
+	"net/url"
+	"server/models"
+	"server/oauth"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// AuthOAuthCallback is where the provider sends the browser back to. It
+// logs the user in, creating the account on first use, or attaches the
+// provider to the user who started a link flow. Either way it ends with a
+// redirect to the frontend.
+func AuthOAuthCallback(c buffalo.Context) error {
+	code := c.Param("code")
+	errorParam := c.Param("error")
+
+	provider, ok := oauthProvider(c)
+	if !ok {
+		return oauthProviderNotFound(c)
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	// without a valid state there is no trusted place to redirect to
+	oauthState, err := consumeOAuthState(tx, provider.Name(), c.Param("state"))
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired OAuth state",
+			ErrorCode: "INVALID_STATE",
+		}))
+	}
+
+	redirectError := func(reason string) error {
+		return c.Redirect(http.StatusTemporaryRedirect, frontendRedirectURL(oauthState, url.Values{"error": {reason}}))
+	}
+
+	if errorParam != "" {
+		return redirectError(errorParam)
+	}
+
+	if code == "" {
+		return redirectError("missing_code")
+	}
+
+	ctx := c.Request().Context()
+	tokens, err := provider.Exchange(ctx, code, oauthState.CodeVerifier)
+	if err != nil {
+		c.Logger().Errorf("oauth %s: %v", provider.Name(), err)
+		return redirectError("token_exchange_failed")
+	}
+
+	nonce := ""
+	if oauthState.Nonce != nil {
+		nonce = *oauthState.Nonce
+	}
+	identity, err := provider.Identity(ctx, tokens, nonce)
+	if err != nil {
+		c.Logger().Errorf("oauth %s: %v", provider.Name(), err)
+		return redirectError("user_info_failed")
+	}
+
+	if oauthState.UserID != nil {
+		return oauthLinkCallback(c, tx, provider.Name(), oauthState, identity, tokens)
+	}
+
+	var oauthLink models.OAuthProvider
+	err = tx.Where("provider = ? AND provider_user_id = ?", provider.Name(), identity.Subject).First(&oauthLink)
+
+	var user models.User
+
+	if err == nil {
+		if err := tx.Find(&user, oauthLink.UserID); err != nil {
+			return redirectError("user_not_found")
+		}
+
+		setOAuthTokens(&oauthLink, tokens)
+		oauthLink.UpdatedAt = time.Now().UTC()
+		if err := tx.Update(&oauthLink); err != nil {
+			c.Logger().Errorf("oauth %s: update tokens: %v", provider.Name(), err)
+		}
+	} else {
+		email := strings.ToLower(strings.TrimSpace(identity.Email))
+		if email == "" {
+			return redirectError("email_required")
+		}
+
+		err = tx.Where("email = ?", email).First(&user)
+
+		if err == nil {
+			// only an address the provider verified proves it is the same
+			// person, otherwise anyone able to claim that email at some
+			// provider could take the account over
+			if !identity.EmailVerified {
+				return redirectError("account_exists")
+			}
+		} else {
+			name := identity.GivenName
+			if name == "" {
+				name = identity.Name
+			}
+			if name == "" {
+				name = "User"
+			}
+
+			user = models.User{
+				Email:            email,
+				EmailVerified:    identity.EmailVerified,
+				Name:             name,
+				LastName:         identity.FamilyName,
+				Role:             DefaultRole,
+				Active:           true,
+				TwoFactorEnabled: false,
+				CreatedAt:        time.Now().UTC(),
+				UpdatedAt:        time.Now().UTC(),
+			}
+			if identity.Picture != "" {
+				user.Profile = &identity.Picture
+			}
+
+			if err := tx.Create(&user); err != nil {
+				return redirectError("user_creation_failed")
+			}
+			recordAuditEvent(tx, c.Request(), AuditUserRegistered, &user.ID, &user.ID, map[string]any{
+				"email":    user.Email,
+				"provider": provider.Name(),
+			})
+		}
+
+		newOAuthLink := newOAuthProviderLink(user, provider.Name(), identity, tokens)
+		if err := tx.Create(&newOAuthLink); err != nil {
+			return redirectError("oauth_link_failed")
+		}
+		recordAuditEvent(tx, c.Request(), AuditOAuthLinked, &user.ID, &user.ID, map[string]any{"provider": provider.Name()})
+	}
+
+	if !user.Active {
+		return redirectError("account_inactive")
+	}
+
+	recordLoginAttempt(tx, &user.ID, user.Email, true, "oauth_"+provider.Name(), c.Request())
+
+	loginCode, err := issueOAuthLoginCode(tx, user.ID)
+	if err != nil {
+		return redirectError("server_error")
+	}
+
+	return c.Redirect(http.StatusTemporaryRedirect, frontendRedirectURL(oauthState, url.Values{"code": {loginCode}}))
+}
+
+// oauthLinkCallback finishes a flow started with POST
+// /auth/oauth/{provider}/link and redirects with ?linked=<provider>.
+func oauthLinkCallback(c buffalo.Context, tx *pop.Connection, provider string, oauthState models.OAuthState, identity *oauth.Identity, tokens *oauth.Token) error {
+	redirect := func(key, value string) error {
+		return c.Redirect(http.StatusTemporaryRedirect, frontendRedirectURL(oauthState, url.Values{key: {value}}))
+	}
+
+	var user models.User
+	if err := tx.Find(&user, *oauthState.UserID); err != nil {
+		return redirect("error", "user_not_found")
+	}
+
+	if !user.Active {
+		return redirect("error", "account_inactive")
+	}
+
+	var existing models.OAuthProvider
+	err := tx.Where("provider = ? AND provider_user_id = ?", provider, identity.Subject).First(&existing)
+	if err == nil {
+		if existing.UserID == user.ID {
+			return redirect("linked", provider)
+		}
+		return redirect("error", "provider_account_in_use")
+	}
+
+	err = tx.Where("user_id = ? AND provider = ?", user.ID, provider).First(&existing)
+	if err == nil {
+		return redirect("error", "already_linked")
+	}
+
+	oauthLink := newOAuthProviderLink(user, provider, identity, tokens)
+	if err := tx.Create(&oauthLink); err != nil {
+		return redirect("error", "oauth_link_failed")
+	}
+	recordUserEvent(tx, c, user, AuditOAuthLinked, map[string]any{"provider": provider})
+
+	return redirect("linked", provider)
+}
+
+func newOAuthProviderLink(user models.User, provider string, identity *oauth.Identity, tokens *oauth.Token) models.OAuthProvider {
+	link := models.OAuthProvider{
+		UserID:         user.ID,
+		Provider:       provider,
+		ProviderUserID: identity.Subject,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+	if identity.Email != "" {
+		link.ProviderEmail = &identity.Email
+	}
+	setOAuthTokens(&link, tokens)
+	return link
+}
+
+// setOAuthTokens keeps the refresh token we have when the provider doesn't
+// send a new one (google only does on consent).
+func setOAuthTokens(link *models.OAuthProvider, tokens *oauth.Token) {
+	accessToken := models.EncryptedString(tokens.AccessToken)
+	link.AccessToken = &accessToken
+	if tokens.RefreshToken != "" {
+		refreshToken := models.EncryptedString(tokens.RefreshToken)
+		link.RefreshToken = &refreshToken
+	}
+	link.ExpiresAt = nil
+	if tokens.ExpiresIn > 0 {
+		expiresAt := time.Now().UTC().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+		link.ExpiresAt = &expiresAt
+	}
+}
@@ -0,0 +1,103 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+type OAuthExchangeRequest struct {
+	Code string `json:"code"`
+}
+
+// AuthOAuthExchange trades the one-time code from the oauth callback
+// redirect for tokens, or for a temp token when the user has 2FA.
+func AuthOAuthExchange(c buffalo.Context) error {
+	var req OAuthExchangeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+	if req.Code == "" {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Code is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	// single use: the update only matches an unused, unexpired code
+	now := time.Now().UTC()
+	var vt models.VerificationToken
+	err := tx.RawQuery(`
+		UPDATE auth.verification_tokens
+		SET used = true, used_at = ?
+		WHERE token_hash = ? AND token_type = ? AND used = false AND expires_at > ?
+		RETURNING *
+	`, now, sha256Hex(req.Code), "oauth_login_code", now).First(&vt)
+	if err != nil || vt.UserID == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired code",
+			ErrorCode: "INVALID_CODE",
+		}))
+	}
+
+	var user models.User
+	if err := tx.Find(&user, *vt.UserID); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired code",
+			ErrorCode: "INVALID_CODE",
+		}))
+	}
+
+	if !user.Active {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account is inactive",
+			ErrorCode: "ACCOUNT_INACTIVE",
+		}))
+	}
+
+	if methods := secondFactorMethods(tx, user); len(methods) > 0 {
+		tempToken, err := generateTempToken(user, "temp_2fa", TempTokenDuration, []string{AMRFederated})
+		if err != nil {
+			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Failed to generate token",
+				ErrorCode: "TOKEN_GENERATION_FAILED",
+			}))
+		}
+
+		return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+			"success":      true,
+			"requires_2fa": true,
+			"data": Login2FAResponse{
+				TempToken: tempToken,
+				Message:   "Please provide 2FA code",
+				Methods:   methods,
+			},
+		}))
+	}
+
+	return generateAndReturnTokens(c, tx, user, []string{AMRFederated})
+}
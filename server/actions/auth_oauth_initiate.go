@@ -0,0 +1,54 @@
+package actions
+
+import (
+	"net/http"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// AuthOAuthInitiate redirects the browser to the provider's authorization
+// endpoint to log in (or sign up) with it.
+func AuthOAuthInitiate(c buffalo.Context) error {
+	redirectURI := c.Param("redirect_uri")
+	clientState := c.Param("state")
+
+	if errResp := validateOAuthRedirect(redirectURI, clientState); errResp != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(errResp))
+	}
+
+	provider, ok := oauthProvider(c)
+	if !ok {
+		return oauthProviderNotFound(c)
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	authReq, err := newOAuthState(tx, provider.Name(), redirectURI, clientState, nil)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start OAuth flow",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request().Context(), authReq)
+	if err != nil {
+		c.Logger().Errorf("oauth %s: %v", provider.Name(), err)
+		return c.Render(http.StatusBadGateway, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "OAuth provider is not available",
+			ErrorCode: "OAUTH_PROVIDER_ERROR",
+		}))
+	}
+
+	return c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
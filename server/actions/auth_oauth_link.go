@@ -0,0 +1,96 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"strings"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+type LinkOAuthRequest struct {
+	RedirectURI string `json:"redirect_uri"`
+	State       string `json:"state"`
+}
+
+// AuthOAuthLink starts a flow that attaches the provider to the current
+// user. It answers with the authorization URL; the provider sends the
+// browser to the callback, which links the account and redirects to
+// redirect_uri with ?linked=<provider>.
+func AuthOAuthLink(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req LinkOAuthRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.RedirectURI = strings.TrimSpace(req.RedirectURI)
+
+	if errResp := validateOAuthRedirect(req.RedirectURI, req.State); errResp != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(errResp))
+	}
+
+	provider, ok := oauthProvider(c)
+	if !ok {
+		return oauthProviderNotFound(c)
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	var existingProvider models.OAuthProvider
+	err = tx.Where("user_id = ? AND provider = ?", user.ID, provider.Name()).First(&existingProvider)
+	if err == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "OAuth account is already linked",
+			ErrorCode: "ALREADY_LINKED",
+		}))
+	}
+
+	authReq, err := newOAuthState(tx, provider.Name(), req.RedirectURI, req.State, &user.ID)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start OAuth flow",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	authURL, err := provider.AuthCodeURL(c.Request().Context(), authReq)
+	if err != nil {
+		c.Logger().Errorf("oauth %s: %v", provider.Name(), err)
+		return c.Render(http.StatusBadGateway, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "OAuth provider is not available",
+			ErrorCode: "OAUTH_PROVIDER_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"provider":          provider.Name(),
+			"authorization_url": authURL,
+		},
+	}))
+}
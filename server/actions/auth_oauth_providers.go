@@ -0,0 +1,46 @@
+package actions
+
+import (
+	"log"
+	"net/http"
+	"server/oauth"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/envy"
+)
+
+// OAuthProviders are the identity providers enabled through env, see
+// oauth.FromEnv for the variables each one reads.
+var OAuthProviders *oauth.Registry
+
+func init() {
+	var err error
+	OAuthProviders, err = oauth.FromEnv(envy.Get)
+	if err != nil {
+		log.Fatalf("oauth providers: %v", err)
+	}
+}
+
+// oauthProvider resolves the {provider} route param to an enabled provider.
+func oauthProvider(c buffalo.Context) (oauth.Provider, bool) {
+	return OAuthProviders.Get(c.Param("provider"))
+}
+
+func oauthProviderNotFound(c buffalo.Context) error {
+	return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+		Success:   false,
+		Error:     "OAuth provider not found",
+		ErrorCode: "OAUTH_PROVIDER_NOT_FOUND",
+	}))
+}
+
+// AuthOAuthProviders lists the enabled providers so the frontend can show
+// a button for each one.
+func AuthOAuthProviders(c buffalo.Context) error {
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"providers": OAuthProviders.Names(),
+		},
+	}))
+}
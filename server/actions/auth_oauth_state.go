@@ -0,0 +1,170 @@
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"server/models"
+	"server/oauth"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	OAuthStateDuration = 10 * time.Minute
+	OAuthCodeDuration  = 1 * time.Minute
+)
+
+// OAuthRedirectOrigins are the frontend origins the oauth callback may
+// redirect to, comma separated.
+var OAuthRedirectOrigins = envy.Get("OAUTH_REDIRECT_ORIGINS", "http://localhost:3000,http://localhost:3001")
+
+var errInvalidOAuthState = errors.New("invalid or expired oauth state")
+
+// -- redirect allowlist
+
+func isAllowedRedirect(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.User != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+
+	for _, allowed := range strings.Split(OAuthRedirectOrigins, ",") {
+		if origin == strings.ToLower(strings.TrimRight(strings.TrimSpace(allowed), "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOAuthRedirect checks the redirect_uri and state the frontend
+// starts a flow with. It returns nil when they are fine.
+func validateOAuthRedirect(redirectURI, clientState string) *ErrorResponse {
+	if redirectURI == "" {
+		return &ErrorResponse{
+			Success:   false,
+			Error:     "redirect_uri is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}
+	}
+
+	if !isAllowedRedirect(redirectURI) {
+		return &ErrorResponse{
+			Success:   false,
+			Error:     "redirect_uri origin is not allowed",
+			ErrorCode: "INVALID_REDIRECT_URI",
+		}
+	}
+
+	if len(clientState) > 255 {
+		return &ErrorResponse{
+			Success:   false,
+			Error:     "state is too long",
+			ErrorCode: "VALIDATION_ERROR",
+		}
+	}
+	return nil
+}
+
+// frontendRedirectURL appends params (and the frontend's own state, if it
+// sent one) to the stored redirect_uri.
+func frontendRedirectURL(state models.OAuthState, params url.Values) string {
+	u, _ := url.Parse(state.RedirectURI)
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	if state.ClientState != nil {
+		q.Set("state", *state.ClientState)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// -- state + pkce
+
+// newOAuthState stores a single use state for provider and returns what
+// goes in the authorization request: the raw state, the PKCE S256 challenge
+// and the OIDC nonce. userID is set when a signed in user is linking the
+// provider rather than logging in.
+func newOAuthState(tx *pop.Connection, provider, redirectURI, clientState string, userID *uuid.UUID) (oauth.AuthRequest, error) {
+	state := randomToken(32)
+	// 64 hex chars, within the 43-128 unreserved chars RFC 7636 asks for
+	verifier := randomToken(32)
+	nonce := randomToken(16)
+
+	record := models.OAuthState{
+		StateHash:    sha256Hex(state),
+		Provider:     provider,
+		RedirectURI:  redirectURI,
+		CodeVerifier: verifier,
+		Nonce:        &nonce,
+		UserID:       userID,
+		ExpiresAt:    time.Now().UTC().Add(OAuthStateDuration),
+		CreatedAt:    time.Now().UTC(),
+	}
+	if clientState != "" {
+		record.ClientState = &clientState
+	}
+
+	if err := tx.Create(&record); err != nil {
+		return oauth.AuthRequest{}, err
+	}
+	return oauth.AuthRequest{
+		State:         state,
+		CodeChallenge: pkceChallenge(verifier),
+		Nonce:         nonce,
+	}, nil
+}
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// consumeOAuthState marks state used and returns it. A state can only be
+// consumed once, before it expires, by the provider it was issued for.
+func consumeOAuthState(tx *pop.Connection, provider, state string) (models.OAuthState, error) {
+	var record models.OAuthState
+	if state == "" {
+		return record, errInvalidOAuthState
+	}
+
+	now := time.Now().UTC()
+	err := tx.RawQuery(`
+		UPDATE auth.oauth_states
+		SET used = true, used_at = ?
+		WHERE state_hash = ? AND provider = ? AND used = false AND expires_at > ?
+		RETURNING *
+	`, now, sha256Hex(state), provider, now).First(&record)
+	if err != nil {
+		return record, errInvalidOAuthState
+	}
+	return record, nil
+}
+
+// -- one time login code
+
+// issueOAuthLoginCode returns a short lived code the frontend exchanges for
+// tokens with POST /auth/oauth/exchange, so tokens never travel in a URL.
+func issueOAuthLoginCode(tx *pop.Connection, userID uuid.UUID) (string, error) {
+	code := randomToken(32)
+	vt := models.VerificationToken{
+		UserID:    &userID,
+		TokenHash: sha256Hex(code),
+		TokenType: "oauth_login_code",
+		ExpiresAt: time.Now().UTC().Add(OAuthCodeDuration),
+		Used:      false,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := tx.Create(&vt); err != nil {
+		return "", err
+	}
+	return code, nil
+}
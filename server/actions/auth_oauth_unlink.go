@@ -3,17 +3,15 @@ package actions
 import (
 	"net/http"
 	"server/models"
-	"strings"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
 )
 
-type UnlinkGoogleRequest struct {
-	Password string `json:"password"`
-}
-
-func AuthOAuthGoogleUnlink(c buffalo.Context) error {
+// AuthOAuthUnlink removes a linked provider. It works for providers that
+// are no longer configured, so users can clean up old links. Routes put it
+// behind RequireRecentAuth.
+func AuthOAuthUnlink(c buffalo.Context) error {
 	user, err := GetCurrentUser(c)
 	if err != nil {
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -23,16 +21,7 @@ func AuthOAuthGoogleUnlink(c buffalo.Context) error {
 		}))
 	}
 
-	var req UnlinkGoogleRequest
-	if err := c.Bind(&req); err != nil {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Invalid request body",
-			ErrorCode: "INVALID_BODY",
-		}))
-	}
-
-	req.Password = strings.TrimSpace(req.Password)
+	provider := c.Param("provider")
 
 	tx, ok := c.Value("tx").(*pop.Connection)
 	if !ok || tx == nil {
@@ -44,11 +33,11 @@ func AuthOAuthGoogleUnlink(c buffalo.Context) error {
 	}
 
 	var oauthProvider models.OAuthProvider
-	err = tx.Where("user_id = ? AND provider = ?", user.ID, "google").First(&oauthProvider)
+	err = tx.Where("user_id = ? AND provider = ?", user.ID, provider).First(&oauthProvider)
 	if err != nil {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Google account is not linked",
+			Error:     "OAuth account is not linked",
 			ErrorCode: "NOT_LINKED",
 		}))
 	}
@@ -56,44 +45,30 @@ func AuthOAuthGoogleUnlink(c buffalo.Context) error {
 	hasPassword := user.PasswordHash != nil && *user.PasswordHash != ""
 
 	var otherProviders int
-	tx.RawQuery("SELECT COUNT(*) FROM auth.oauth_providers WHERE user_id = ? AND provider != 'google'", user.ID).First(&otherProviders)
+	tx.RawQuery("SELECT COUNT(*) FROM auth.oauth_providers WHERE user_id = ? AND provider != ?", user.ID, provider).First(&otherProviders)
+
+	passkeys, _ := tx.Where("user_id = ?", user.ID).Count(&models.WebAuthnCredential{})
 
-	if !hasPassword && otherProviders == 0 {
+	if !hasPassword && otherProviders == 0 && passkeys == 0 {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Cannot unlink Google account. Please set a password first.",
+			Error:     "Cannot unlink the only sign in method. Please set a password first.",
 			ErrorCode: "NO_OTHER_AUTH_METHOD",
 		}))
 	}
 
-	if hasPassword {
-		if req.Password == "" {
-			return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-				Success:   false,
-				Error:     "Password is required to unlink Google account",
-				ErrorCode: "PASSWORD_REQUIRED",
-			}))
-		}
-
-		if !verifyPassword(req.Password, *user.PasswordHash) {
-			return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
-				Success:   false,
-				Error:     "Invalid password",
-				ErrorCode: "INVALID_PASSWORD",
-			}))
-		}
-	}
-
 	if err := tx.Destroy(&oauthProvider); err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
-			Error:     "Failed to unlink Google account",
+			Error:     "Failed to unlink OAuth account",
 			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditOAuthUnlinked, map[string]any{"provider": oauthProvider.Provider})
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
-		"message": "Google account unlinked successfully",
+		"message": "OAuth account unlinked successfully",
 	}))
 }
@@ -2,6 +2,7 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"strings"
 
 	"github.com/gobuffalo/buffalo"
@@ -39,9 +40,6 @@ func AuthPasswordChange(c buffalo.Context) error {
 	if req.CurrentPassword == "" {
 		details["current_password"] = "Current password is required"
 	}
-	if len(req.NewPassword) < 8 {
-		details["new_password"] = "New password must be at least 8 characters"
-	}
 	if len(details) > 0 {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
@@ -76,6 +74,26 @@ func AuthPasswordChange(c buffalo.Context) error {
 		}))
 	}
 
+	violations, err := checkPasswordPolicy(tx, user, req.NewPassword)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to change password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if len(violations) > 0 {
+		return renderPasswordPolicy(c, "new_password", violations)
+	}
+
+	if err := recordPasswordHistory(tx, user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to change password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
 	newHash := hashPassword(req.NewPassword)
 	user.PasswordHash = &newHash
 	if err := tx.Update(&user); err != nil {
@@ -86,6 +104,25 @@ func AuthPasswordChange(c buffalo.Context) error {
 		}))
 	}
 
+	// tokens for scripts were created under the old password
+	if _, err := revokeUserTokens(tx, user.ID); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to change password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditPasswordChanged, nil)
+
+	if err := mailers.SendPasswordChangedEmail(tx, user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to change password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"message": "Password changed successfully",
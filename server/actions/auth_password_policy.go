@@ -0,0 +1,89 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"server/passwordpolicy"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// checkPasswordPolicy returns the rules of passwordpolicy.Default that
+// password breaks for user, including reuse of the current password and the
+// ones kept in auth.password_history. New users have no ID and skip the
+// reuse check.
+func checkPasswordPolicy(tx *pop.Connection, user models.User, password string) ([]passwordpolicy.Violation, error) {
+	policy := passwordpolicy.Default
+	violations := policy.Check(password, user.Name, user.LastName, user.Email)
+
+	if policy.HistorySize == 0 || user.ID == uuid.Nil {
+		return violations, nil
+	}
+
+	// the current password counts as one of the last HistorySize
+	var hashes []string
+	if user.PasswordHash != nil && *user.PasswordHash != "" {
+		hashes = append(hashes, *user.PasswordHash)
+	}
+	if policy.HistorySize > 1 {
+		var history models.PasswordHistories
+		err := tx.Where("user_id = ?", user.ID).
+			Order("created_at DESC").
+			Limit(policy.HistorySize - 1).
+			All(&history)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range history {
+			hashes = append(hashes, h.PasswordHash)
+		}
+	}
+
+	for _, hash := range hashes {
+		if verifyPassword(password, hash) {
+			return append(violations, policy.ReusedViolation()), nil
+		}
+	}
+	return violations, nil
+}
+
+// renderPasswordPolicy answers 400 with the violations under the request
+// field that holds the password.
+func renderPasswordPolicy(c buffalo.Context, field string, violations []passwordpolicy.Violation) error {
+	return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+		Success:   false,
+		Error:     violations[0].Message,
+		ErrorCode: "PASSWORD_POLICY",
+		Details: map[string]any{
+			field: violations,
+		},
+	}))
+}
+
+// recordPasswordHistory keeps the password user is about to replace and trims
+// the history to what the policy checks.
+func recordPasswordHistory(tx *pop.Connection, user models.User) error {
+	if user.PasswordHash == nil || *user.PasswordHash == "" {
+		return nil
+	}
+	keep := passwordpolicy.Default.HistorySize - 1
+	if keep <= 0 {
+		return tx.RawQuery("DELETE FROM auth.password_history WHERE user_id = ?", user.ID).Exec()
+	}
+
+	entry := models.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: *user.PasswordHash,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := tx.Create(&entry); err != nil {
+		return err
+	}
+
+	return tx.RawQuery(`DELETE FROM auth.password_history WHERE user_id = ? AND id NOT IN (
+		SELECT id FROM auth.password_history WHERE user_id = ? ORDER BY created_at DESC LIMIT ?)`,
+		user.ID, user.ID, keep).Exec()
+}
@@ -2,13 +2,14 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"server/models"
 	"strings"
 	"time"
 
-	"github.com/alexedwards/argon2id"
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 )
 
 // -- reset password
@@ -39,8 +40,8 @@ func AuthResetPassword(c buffalo.Context) error {
 	if req.Token == "" {
 		details["token"] = "Token is required"
 	}
-	if len(req.NewPassword) < 8 {
-		details["new_password"] = "Password must be at least 8 characters"
+	if req.NewPassword == "" {
+		details["new_password"] = "New password is required"
 	}
 	if len(details) > 0 {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
@@ -100,8 +101,8 @@ func AuthResetPassword(c buffalo.Context) error {
 		}))
 	}
 
-	// Hashear nueva contraseña
-	pwHash, err := argon2id.CreateHash(req.NewPassword, argon2id.DefaultParams)
+	// Validar la política de contraseñas
+	violations, err := checkPasswordPolicy(tx, user, req.NewPassword)
 	if err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
@@ -109,6 +110,20 @@ func AuthResetPassword(c buffalo.Context) error {
 			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
+	if len(violations) > 0 {
+		return renderPasswordPolicy(c, "new_password", violations)
+	}
+
+	if err := recordPasswordHistory(tx, user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to reset password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	// Hashear nueva contraseña
+	pwHash := hashPassword(req.NewPassword)
 
 	// Actualizar contraseña
 	user.PasswordHash = &pwHash
@@ -126,12 +141,19 @@ func AuthResetPassword(c buffalo.Context) error {
 	vt.UsedAt = &now
 	tx.Update(&vt)
 
-	// Revocar todas las sesiones del usuario (seguridad)
-	tx.RawQuery(`
-		UPDATE auth.sessions 
-		SET revoked = true, revoked_at = NOW() 
-		WHERE user_id = ? AND revoked = false
-	`, user.ID).Exec()
+	// Revocar todas las sesiones y tokens del usuario (seguridad)
+	revokeUserSessions(c, tx, user.ID, uuid.Nil)
+	revokeUserTokens(tx, user.ID)
+
+	recordUserEvent(tx, c, user, AuditPasswordReset, nil)
+
+	if err := mailers.SendPasswordChangedEmail(tx, user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to reset password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
 
 	return c.Render(http.StatusOK, r.JSON(ResetPasswordResponse{
 		Success: true,
@@ -2,6 +2,7 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"strings"
 
 	"github.com/gobuffalo/buffalo"
@@ -33,14 +34,6 @@ func AuthPasswordSet(c buffalo.Context) error {
 
 	req.Password = strings.TrimSpace(req.Password)
 
-	if len(req.Password) < 8 {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Password must be at least 8 characters",
-			ErrorCode: "VALIDATION_ERROR",
-		}))
-	}
-
 	if user.PasswordHash != nil && *user.PasswordHash != "" {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
@@ -58,6 +51,18 @@ func AuthPasswordSet(c buffalo.Context) error {
 		}))
 	}
 
+	violations, err := checkPasswordPolicy(tx, user, req.Password)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to set password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if len(violations) > 0 {
+		return renderPasswordPolicy(c, "password", violations)
+	}
+
 	pwHash := hashPassword(req.Password)
 	user.PasswordHash = &pwHash
 	if err := tx.Update(&user); err != nil {
@@ -68,6 +73,16 @@ func AuthPasswordSet(c buffalo.Context) error {
 		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditPasswordSet, nil)
+
+	if err := mailers.SendPasswordChangedEmail(tx, user); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to set password",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"message": "Password set successfully",
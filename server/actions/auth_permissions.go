@@ -0,0 +1,87 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const (
+	RoleSupport = "support"
+	RoleDev     = "dev"
+	RoleAdmin   = "admin"
+
+	// DefaultRole is the only role that can be obtained without an admin
+	// granting it (self-registration, first oauth login).
+	DefaultRole = RoleSupport
+)
+
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersManage = "users:manage"
+	PermissionAuditRead   = "audit:read"
+)
+
+var ValidRoles = map[string]bool{RoleSupport: true, RoleDev: true, RoleAdmin: true}
+
+// RequirePermission returns a middleware that only lets through users whose
+// role has been granted permission in auth.role_permissions. It must run
+// after AuthMiddleware.
+func RequirePermission(permission string) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			user, err := GetCurrentUser(c)
+			if err != nil {
+				return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+					Success:   false,
+					Error:     "Unauthorized",
+					ErrorCode: "UNAUTHORIZED",
+				}))
+			}
+
+			tx, ok := c.Value("tx").(*pop.Connection)
+			if !ok || tx == nil {
+				return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+					Success:   false,
+					Error:     "Database connection not available",
+					ErrorCode: "DB_NOT_AVAILABLE",
+				}))
+			}
+
+			if !hasPermission(tx, user.Role, permission) {
+				return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+					Success:   false,
+					Error:     "You do not have permission to perform this action",
+					ErrorCode: "FORBIDDEN",
+					Details: map[string]any{
+						"required_permission": permission,
+					},
+				}))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func hasPermission(tx *pop.Connection, role, permission string) bool {
+	var count int
+	err := tx.RawQuery(`
+		SELECT COUNT(*) FROM auth.role_permissions 
+		WHERE role = ? AND permission = ?
+	`, role, permission).First(&count)
+	return err == nil && count > 0
+}
+
+func rolePermissions(tx *pop.Connection, role string) []string {
+	var grants []models.RolePermission
+	tx.Where("role = ?", role).Order("permission").All(&grants)
+
+	permissions := make([]string, len(grants))
+	for i, grant := range grants {
+		permissions[i] = grant.Permission
+	}
+	return permissions
+}
@@ -0,0 +1,48 @@
+package actions
+
+import "net/http"
+
+func (as *ActionSuite) Test_RequirePermission_DeniesRoleWithoutGrant() {
+	as.grant(RoleAdmin, PermissionUsersRead)
+	as.grant(RoleAdmin, PermissionUsersManage)
+
+	user := as.createUser("support@example.com", RoleSupport)
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/admin/users").Get()
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("FORBIDDEN", errorCode(res))
+
+	res = as.authJSON(accessToken, "/api/v1/admin/users/%s/unlock", user.ID).Post(nil)
+	as.Equal(http.StatusForbidden, res.Code)
+}
+
+func (as *ActionSuite) Test_RequirePermission_AllowsGrantedRole() {
+	as.grant(RoleAdmin, PermissionUsersRead)
+	as.grant(RoleAdmin, PermissionUsersManage)
+
+	admin := as.createUser("admin@example.com", RoleAdmin)
+	target := as.createUser("target@example.com", RoleSupport)
+	accessToken, _ := as.signIn(admin)
+
+	res := as.authJSON(accessToken, "/api/v1/admin/users").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/admin/users/%s/unlock", target.ID).Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+}
+
+func (as *ActionSuite) Test_RequirePermission_ManageNeedsItsOwnGrant() {
+	as.grant(RoleAdmin, PermissionUsersRead)
+
+	admin := as.createUser("reader@example.com", RoleAdmin)
+	target := as.createUser("target@example.com", RoleSupport)
+	accessToken, _ := as.signIn(admin)
+
+	res := as.authJSON(accessToken, "/api/v1/admin/users").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/admin/users/%s/unlock", target.ID).Post(nil)
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("FORBIDDEN", errorCode(res))
+}
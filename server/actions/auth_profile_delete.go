@@ -40,6 +40,8 @@ func AuthProfileDelete(c buffalo.Context) error {
 		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditProfileImageDeleted, nil)
+
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"message": "Profile image deleted successfully",
@@ -0,0 +1,163 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"server/models"
+	"server/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/envy"
+)
+
+// RateLimitBackend selects where token buckets live: memory (per instance)
+// or postgres (shared by every instance).
+var RateLimitBackend = envy.Get("RATE_LIMIT_BACKEND", defaultRateLimitBackend())
+
+var rateLimitStore ratelimit.Store
+
+func init() {
+	switch RateLimitBackend {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		rateLimitStore = ratelimit.NewPostgresStore(models.DB)
+	default:
+		log.Fatalf("unknown RATE_LIMIT_BACKEND %q, expected memory or postgres", RateLimitBackend)
+	}
+}
+
+func defaultRateLimitBackend() string {
+	if ENV == "production" {
+		return "postgres"
+	}
+	return "memory"
+}
+
+// RateLimitRule limits one route per client IP and, when the request body
+// has an email, per email.
+type RateLimitRule struct {
+	Name     string
+	PerIP    ratelimit.Limit
+	PerEmail ratelimit.Limit
+}
+
+// Defaults can be overridden with RATE_LIMIT_<NAME>_IP and
+// RATE_LIMIT_<NAME>_EMAIL, e.g. RATE_LIMIT_LOGIN_EMAIL=10/15m ("0" disables).
+var (
+	RateLimitRegister     = rateLimitRule("register", "5/1h", "")
+	RateLimitVerifyEmail  = rateLimitRule("verify_email", "20/15m", "")
+	RateLimitLogin        = rateLimitRule("login", "20/5m", "10/15m")
+	RateLimitRefresh      = rateLimitRule("refresh", "60/1m", "")
+	RateLimitRequestReset = rateLimitRule("request_reset", "10/1h", "3/1h")
+	RateLimitResetPass    = rateLimitRule("reset_password", "10/15m", "")
+	RateLimitMagicLink    = rateLimitRule("magic_link", "10/1h", "3/15m")
+	RateLimit2FAVerify    = rateLimitRule("2fa_verify", "10/5m", "")
+	RateLimitSecStatus    = rateLimitRule("security_status", "20/15m", "10/15m")
+	RateLimitOAuth        = rateLimitRule("oauth", "30/5m", "")
+	RateLimitReauth       = rateLimitRule("reauth", "10/5m", "")
+)
+
+func rateLimitRule(name, perIP, perEmail string) RateLimitRule {
+	env := "RATE_LIMIT_" + strings.ToUpper(name)
+	return RateLimitRule{
+		Name:     name,
+		PerIP:    mustParseLimit(env+"_IP", perIP),
+		PerEmail: mustParseLimit(env+"_EMAIL", perEmail),
+	}
+}
+
+func mustParseLimit(key, fallback string) ratelimit.Limit {
+	value := envy.Get(key, fallback)
+	if value == "" {
+		return ratelimit.Limit{}
+	}
+	limit, err := ratelimit.ParseLimit(value)
+	if err != nil {
+		log.Fatalf("%s: %v", key, err)
+	}
+	return limit
+}
+
+// RateLimit returns a middleware that answers 429 with Retry-After once the
+// client IP or the email in the body runs out of requests for rule.
+func RateLimit(rule RateLimitRule) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			type check struct {
+				key   string
+				limit ratelimit.Limit
+			}
+			var checks []check
+
+			if rule.PerIP.Enabled() {
+				checks = append(checks, check{rule.Name + ":ip:" + clientIP(c.Request()), rule.PerIP})
+			}
+			if rule.PerEmail.Enabled() {
+				if email := requestEmail(c.Request()); email != "" {
+					// no plain emails in auth.rate_limits
+					checks = append(checks, check{rule.Name + ":email:" + sha256Hex(email)[:32], rule.PerEmail})
+				}
+			}
+
+			for _, ch := range checks {
+				res, err := rateLimitStore.Allow(ch.key, ch.limit)
+				if err != nil {
+					// fail open, a broken limiter must not take login down
+					c.Logger().Errorf("rate limit %s: %v", rule.Name, err)
+					continue
+				}
+				if !res.Allowed {
+					return renderRateLimited(c, res.RetryAfter)
+				}
+			}
+
+			return next(c)
+		}
+	}
+}
+
+func renderRateLimited(c buffalo.Context, retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+	return c.Render(http.StatusTooManyRequests, r.JSON(ErrorResponse{
+		Success:   false,
+		Error:     "Too many requests, try again later",
+		ErrorCode: "RATE_LIMITED",
+		Details: map[string]any{
+			"retry_after": seconds,
+		},
+	}))
+}
+
+// requestEmail peeks at the JSON body for an email and puts the body back
+// for the handler.
+func requestEmail(req *http.Request) string {
+	if req.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var payload struct {
+		Email string `json:"email"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(payload.Email))
+}
@@ -0,0 +1,411 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Authentication methods of the amr claim, named after RFC 8176 where it
+// has a name for them.
+const (
+	AMRPassword  = "pwd"
+	AMROTP       = "otp" // TOTP or backup code
+	AMRPasskey   = "hwk"
+	AMREmail     = "email" // magic link or emailed code
+	AMRFederated = "fed"   // OAuth or OIDC provider
+)
+
+// RecentAuthMaxAge is how long after authenticating the user can make
+// sensitive changes without authenticating again.
+const RecentAuthMaxAge = 5 * time.Minute
+
+type ReauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type ReauthResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int       `json:"expires_in"`
+	AuthTime    time.Time `json:"auth_time"`
+	AMR         []string  `json:"amr"`
+}
+
+// -- claims
+
+// authentication is when and how the user proved who they are. Session
+// tokens carry it as the auth_time and amr claims; a refresh keeps it and
+// a re-authentication renews it.
+type authentication struct {
+	Time    time.Time
+	Methods []string
+}
+
+func (a authentication) setClaims(claims jwt.MapClaims) {
+	claims["auth_time"] = a.Time.Unix()
+	claims["amr"] = a.Methods
+}
+
+// claimedAuthentication reads auth_time and amr back. Tokens issued before
+// they existed have a zero Time, which is never recent.
+func claimedAuthentication(claims jwt.MapClaims) authentication {
+	var auth authentication
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		auth.Time = time.Unix(int64(authTime), 0).UTC()
+	}
+	auth.Methods = claimedAMR(claims)
+	return auth
+}
+
+func claimedAMR(claims jwt.MapClaims) []string {
+	amr := []string{}
+	values, _ := claims["amr"].([]interface{})
+	for _, v := range values {
+		if method, ok := v.(string); ok {
+			amr = append(amr, method)
+		}
+	}
+	return amr
+}
+
+// withMethod adds method to the methods a login already went through.
+func withMethod(amr []string, method string) []string {
+	if slices.Contains(amr, method) {
+		return amr
+	}
+	return append(slices.Clone(amr), method)
+}
+
+// GetCurrentAuthentication returns when and how the user of the access
+// token authenticated, as set by AuthMiddleware.
+func GetCurrentAuthentication(c buffalo.Context) authentication {
+	auth, _ := c.Value("authentication").(authentication)
+	return auth
+}
+
+// -- middleware
+
+// RequireRecentAuth only lets requests through whose access token was
+// issued by a login or a re-authentication at most maxAge ago. Others get
+// 403 REAUTH_REQUIRED with the methods the user can re-authenticate with
+// in POST /auth/reauth; an empty list means logging in again. It goes after
+// AuthMiddleware.
+func RequireRecentAuth(maxAge time.Duration) buffalo.MiddlewareFunc {
+	return func(next buffalo.Handler) buffalo.Handler {
+		return func(c buffalo.Context) error {
+			auth := GetCurrentAuthentication(c)
+			if !auth.Time.IsZero() && time.Since(auth.Time) <= maxAge {
+				return next(c)
+			}
+
+			methods := []string{}
+			user, err := GetCurrentUser(c)
+			tx, ok := c.Value("tx").(*pop.Connection)
+			if err == nil && ok && tx != nil {
+				methods = reauthMethods(tx, user)
+			}
+
+			return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "Recent authentication required",
+				ErrorCode: "REAUTH_REQUIRED",
+				Details: map[string]any{
+					"max_age": int(maxAge.Seconds()),
+					"methods": methods,
+				},
+			}))
+		}
+	}
+}
+
+// reauthMethods lists what the user can re-authenticate with, named like
+// secondFactorMethods.
+func reauthMethods(tx *pop.Connection, user models.User) []string {
+	methods := []string{}
+	if user.PasswordHash != nil && *user.PasswordHash != "" {
+		methods = append(methods, "password")
+	}
+	return append(methods, secondFactorMethods(tx, user)...)
+}
+
+// -- handlers
+
+// AuthReauth proves again that the user is who the access token says, with
+// the password or a TOTP code, and returns an access token for the same
+// session whose auth_time is now. The refresh token isn't touched, so
+// refreshed access tokens go back to the time of the login.
+func AuthReauth(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req ReauthRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Code = strings.TrimSpace(req.Code)
+
+	if (req.Password == "") == (req.Code == "") {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Either password or code is required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	if req.Password != "" {
+		if user.PasswordHash == nil || *user.PasswordHash == "" {
+			return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "No password set for this account",
+				ErrorCode: "NO_PASSWORD",
+			}))
+		}
+		if !verifyPassword(req.Password, *user.PasswordHash) {
+			return renderReauthFailed(c, user, "password", "Invalid password", "INVALID_PASSWORD")
+		}
+		return issueReauthToken(c, user, AMRPassword)
+	}
+
+	if !user.TwoFactorEnabled || user.TwoFactorSecret == nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Two-factor authentication is not enabled",
+			ErrorCode: "2FA_NOT_ENABLED",
+		}))
+	}
+	if !useTOTPCode(tx, &user, req.Code) {
+		return renderReauthFailed(c, user, "totp", "Invalid 2FA code", "INVALID_CODE")
+	}
+	return issueReauthToken(c, user, AMROTP)
+}
+
+// AuthReauthWebAuthnBegin starts a passkey assertion to re-authenticate,
+// limited to the passkeys of the current user.
+func AuthReauthWebAuthnBegin(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	if len(waUser.credentials) == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "No passkeys registered for this user",
+			ErrorCode: "NO_PASSKEYS",
+		}))
+	}
+
+	assertion, session, err := webAuthn.BeginLogin(waUser)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey verification",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	challengeID, err := saveWebAuthnChallenge(tx, &user.ID, "reauth", session)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey verification",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"challenge_id": challengeID,
+			"options":      assertion,
+		},
+	}))
+}
+
+// AuthReauthWebAuthnFinish completes AuthReauthWebAuthnBegin and answers
+// like AuthReauth.
+func AuthReauthWebAuthnFinish(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req WebAuthnFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	if strings.TrimSpace(req.ChallengeID) == "" || len(req.Credential) == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Challenge ID and credential are required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	session, err := consumeWebAuthnChallenge(req.ChallengeID, "reauth", &user.ID)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired challenge",
+			ErrorCode: "INVALID_CHALLENGE",
+		}))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid passkey credential",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	validated, err := webAuthn.ValidateLogin(waUser, session, parsed)
+	if err != nil || validated.Authenticator.CloneWarning {
+		if err == nil {
+			stored, _ := waUser.find(validated.ID)
+			recordPasskeyUse(models.DB, stored, validated)
+		}
+		return renderReauthFailed(c, user, "webauthn", "Passkey verification failed", "INVALID_CREDENTIAL")
+	}
+
+	stored, _ := waUser.find(validated.ID)
+	if err := recordPasskeyUse(tx, stored, validated); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to update passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return issueReauthToken(c, user, AMRPasskey)
+}
+
+// issueReauthToken answers a successful re-authentication with method.
+func issueReauthToken(c buffalo.Context, user models.User, method string) error {
+	sessionID, err := GetCurrentSessionID(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Session not found",
+			ErrorCode: "SESSION_INVALID",
+		}))
+	}
+
+	auth := authentication{Time: time.Now().UTC(), Methods: []string{method}}
+	accessToken, err := generateSessionToken(user, "access", AccessTokenDuration, sessionID, auth)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to generate access token",
+			ErrorCode: "TOKEN_GENERATION_FAILED",
+		}))
+	}
+
+	if tx, ok := c.Value("tx").(*pop.Connection); ok && tx != nil {
+		recordUserEvent(tx, c, user, AuditReauthenticated, map[string]any{"method": method})
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": ReauthResponse{
+			AccessToken: accessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(AccessTokenDuration.Seconds()),
+			AuthTime:    auth.Time,
+			AMR:         auth.Methods,
+		},
+	}))
+}
+
+// renderReauthFailed records the failure outside the request transaction,
+// which the error response rolls back. Failures don't lock the account:
+// whoever holds the access token could lock its owner out with them. The
+// reauth rate limit slows guessing instead.
+func renderReauthFailed(c buffalo.Context, user models.User, method, message, code string) error {
+	recordAuditEvent(models.DB, c.Request(), AuditReauthFailed, &user.ID, &user.ID, map[string]any{"method": method})
+
+	return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+		Success:   false,
+		Error:     message,
+		ErrorCode: code,
+	}))
+}
@@ -0,0 +1,88 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+)
+
+// staleToken is an access token for a session of user whose login is older
+// than RecentAuthMaxAge.
+func (as *ActionSuite) staleToken(user models.User) string {
+	as.signIn(user)
+
+	var session models.Session
+	as.NoError(as.DB.Where("user_id = ?", user.ID).First(&session))
+
+	auth := authentication{Time: time.Now().UTC().Add(-2 * RecentAuthMaxAge), Methods: []string{AMRPassword}}
+	accessToken, err := generateSessionToken(user, "access", AccessTokenDuration, session.ID, auth)
+	as.NoError(err)
+	return accessToken
+}
+
+func (as *ActionSuite) Test_RequireRecentAuth_RejectsStaleAuthentication() {
+	user := as.createUser("stale@example.com", RoleSupport)
+	accessToken := as.staleToken(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/me/delete").Post(nil)
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("REAUTH_REQUIRED", errorCode(res))
+
+	// routes without the requirement still take the token
+	res = as.authJSON(accessToken, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	var stored models.User
+	as.NoError(as.DB.Find(&stored, user.ID))
+	as.Nil(stored.DeletionScheduledAt)
+}
+
+func (as *ActionSuite) Test_RequireRecentAuth_PassesAfterReauth() {
+	user := as.createUser("reauth@example.com", RoleSupport)
+	staleToken := as.staleToken(user)
+
+	res := as.authJSON(staleToken, "/api/v1/auth/reauth").Post(ReauthRequest{Password: "wrong-password"})
+	as.Equal(http.StatusUnauthorized, res.Code)
+
+	res = as.authJSON(staleToken, "/api/v1/auth/reauth").Post(ReauthRequest{Password: testPassword})
+	as.Equal(http.StatusOK, res.Code)
+
+	var body struct {
+		Data ReauthResponse `json:"data"`
+	}
+	res.Bind(&body)
+	as.NotEmpty(body.Data.AccessToken)
+	as.WithinDuration(time.Now(), body.Data.AuthTime, time.Minute)
+
+	res = as.authJSON(body.Data.AccessToken, "/api/v1/auth/me/delete").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+
+	var stored models.User
+	as.NoError(as.DB.Find(&stored, user.ID))
+	as.NotNil(stored.DeletionScheduledAt)
+}
+
+func (as *ActionSuite) Test_RequireRecentAuth_GuardsNewLoginMethods() {
+	user := as.createUser("methods@example.com", RoleSupport)
+	accessToken := as.staleToken(user)
+
+	for _, path := range []string{
+		"/api/v1/auth/2fa/enable",
+		"/api/v1/auth/2fa/verify-enable",
+		"/api/v1/auth/webauthn/register/begin",
+		"/api/v1/auth/webauthn/register/finish",
+		"/api/v1/auth/oauth/google/link",
+	} {
+		res := as.authJSON(accessToken, "%s", path).Post(nil)
+		as.Equal(http.StatusForbidden, res.Code, path)
+		as.Equal("REAUTH_REQUIRED", errorCode(res), path)
+	}
+
+	count, err := as.DB.Where("user_id = ?", user.ID).Count(&models.TwoFactorSetup{})
+	as.NoError(err)
+	as.Equal(0, count)
+
+	freshToken, _ := as.signIn(user)
+	res := as.authJSON(freshToken, "/api/v1/auth/2fa/enable").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+}
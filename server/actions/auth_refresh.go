@@ -15,9 +15,10 @@ type RefreshRequest struct {
 }
 
 type RefreshResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 func AuthRefresh(c buffalo.Context) error {
@@ -38,9 +39,7 @@ func AuthRefresh(c buffalo.Context) error {
 		}))
 	}
 
-	token, err := jwt.Parse(req.RefreshToken, func(token *jwt.Token) (interface{}, error) {
-		return JWTSecret, nil
-	})
+	token, err := parseToken(req.RefreshToken)
 
 	if err != nil || !token.Valid {
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -83,11 +82,7 @@ func AuthRefresh(c buffalo.Context) error {
 	var session models.Session
 	err = tx.Where("refresh_token_hash = ? AND revoked = ?", refreshTokenHash, false).First(&session)
 	if err != nil {
-		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Session not found or revoked",
-			ErrorCode: "SESSION_INVALID",
-		}))
+		return rejectRefreshToken(c, tx, refreshTokenHash)
 	}
 
 	if time.Now().UTC().After(session.ExpiresAt) {
@@ -98,6 +93,14 @@ func AuthRefresh(c buffalo.Context) error {
 		}))
 	}
 
+	if session.UserID.String() != userID {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Session not found or revoked",
+			ErrorCode: "SESSION_INVALID",
+		}))
+	}
+
 	var user models.User
 	if err := tx.Find(&user, userID); err != nil {
 		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
@@ -115,7 +118,14 @@ func AuthRefresh(c buffalo.Context) error {
 		}))
 	}
 
-	accessToken, err := generateToken(user, "access", AccessTokenDuration)
+	// the new tokens keep when and how the session was authenticated;
+	// refresh tokens from before the claims existed go back to the login
+	auth := claimedAuthentication(claims)
+	if auth.Time.IsZero() {
+		auth.Time = session.CreatedAt
+	}
+
+	accessToken, err := generateSessionToken(user, "access", AccessTokenDuration, session.ID, auth)
 	if err != nil {
 		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
 			Success:   false,
@@ -124,15 +134,106 @@ func AuthRefresh(c buffalo.Context) error {
 		}))
 	}
 
-	session.LastActivityAt = time.Now().UTC()
-	tx.Update(&session)
+	// the session keeps the deadline set at login, so refreshing can't keep
+	// it alive forever; the new refresh token expires along with it
+	now := time.Now().UTC()
+	newRefreshToken, err := generateSessionToken(user, "refresh", session.ExpiresAt.Sub(now), session.ID, auth)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to generate refresh token",
+			ErrorCode: "TOKEN_GENERATION_FAILED",
+		}))
+	}
+
+	// Rotar refresh token: solo la petición que aún encuentra el hash anterior
+	// rota la sesión; otra con el mismo token, aunque sea concurrente, es reuso
+	rotatedCount, err := tx.RawQuery(`
+		UPDATE auth.sessions
+		SET refresh_token_hash = ?, last_activity_at = ?
+		WHERE id = ? AND refresh_token_hash = ? AND revoked = false
+	`, sha256Hex(newRefreshToken), now, session.ID, refreshTokenHash).ExecWithCount()
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to rotate refresh token",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if rotatedCount == 0 {
+		return rejectRefreshToken(c, tx, refreshTokenHash)
+	}
+
+	rotated := models.RotatedRefreshToken{
+		SessionID: session.ID,
+		TokenHash: refreshTokenHash,
+		RotatedAt: now,
+	}
+	if err := tx.Create(&rotated); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to rotate refresh token",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
 
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
 		"data": RefreshResponse{
-			AccessToken: accessToken,
-			TokenType:   "Bearer",
-			ExpiresIn:   int(AccessTokenDuration.Seconds()),
+			AccessToken:  accessToken,
+			RefreshToken: newRefreshToken,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(AccessTokenDuration.Seconds()),
 		},
 	}))
 }
+
+// rejectRefreshToken answers a refresh with a token that no longer matches
+// an active session: either it was rotated out already, which revokes the
+// session, or the session is gone.
+func rejectRefreshToken(c buffalo.Context, tx *pop.Connection, tokenHash string) error {
+	if revokeReusedRefreshToken(tx, tokenHash, c.Request()) {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Refresh token has already been used. Session revoked",
+			ErrorCode: "REFRESH_TOKEN_REUSED",
+		}))
+	}
+	return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+		Success:   false,
+		Error:     "Session not found or revoked",
+		ErrorCode: "SESSION_INVALID",
+	}))
+}
+
+// revokeReusedRefreshToken checks whether tokenHash belongs to a refresh token
+// that was already rotated out. If so, the session it belonged to is revoked
+// and a security event is recorded. Both writes go through models.DB because
+// the request transaction is rolled back on the 401 response.
+func revokeReusedRefreshToken(tx *pop.Connection, tokenHash string, req *http.Request) bool {
+	var rotated models.RotatedRefreshToken
+	if err := tx.Where("token_hash = ?", tokenHash).First(&rotated); err != nil {
+		return false
+	}
+
+	var session models.Session
+	if err := tx.Find(&session, rotated.SessionID); err != nil {
+		return true
+	}
+
+	models.DB.RawQuery(`
+		UPDATE auth.sessions 
+		SET revoked = true, revoked_at = ? 
+		WHERE id = ? AND revoked = false
+	`, time.Now().UTC(), session.ID).Exec()
+	activeSessions.forget(session.ID)
+
+	var user models.User
+	email := ""
+	if err := tx.Find(&user, session.UserID); err == nil {
+		email = user.Email
+	}
+	recordLoginAttempt(models.DB, &session.UserID, email, false, "refresh_token_reuse", req)
+
+	return true
+}
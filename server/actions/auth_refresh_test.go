@@ -0,0 +1,66 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+)
+
+func (as *ActionSuite) refresh(refreshToken string) (*RefreshResponse, string) {
+	res := as.JSON("/api/v1/auth/refresh").Post(RefreshRequest{RefreshToken: refreshToken})
+	if res.Code != http.StatusOK {
+		return nil, errorCode(res)
+	}
+	var body struct {
+		Data RefreshResponse `json:"data"`
+	}
+	res.Bind(&body)
+	return &body.Data, ""
+}
+
+func (as *ActionSuite) Test_AuthRefresh_RotatesToken() {
+	user := as.createUser("refresh@example.com", "support")
+	_, refreshToken := as.signIn(user)
+
+	var before models.Session
+	as.NoError(as.DB.Where("user_id = ?", user.ID).First(&before))
+
+	tokens, code := as.refresh(refreshToken)
+	as.Empty(code)
+	as.NotEqual(refreshToken, tokens.RefreshToken)
+
+	var after models.Session
+	as.NoError(as.DB.Find(&after, before.ID))
+	as.Equal(sha256Hex(tokens.RefreshToken), after.RefreshTokenHash)
+	as.True(after.ExpiresAt.Equal(before.ExpiresAt), "refresh must not extend the session")
+
+	count, err := as.DB.Where("session_id = ? AND token_hash = ?", before.ID, sha256Hex(refreshToken)).Count(&models.RotatedRefreshToken{})
+	as.NoError(err)
+	as.Equal(1, count)
+
+	// the new refresh token keeps working
+	_, code = as.refresh(tokens.RefreshToken)
+	as.Empty(code)
+}
+
+func (as *ActionSuite) Test_AuthRefresh_ReuseRevokesSession() {
+	user := as.createUser("reuse@example.com", "support")
+	_, refreshToken := as.signIn(user)
+
+	tokens, code := as.refresh(refreshToken)
+	as.Empty(code)
+
+	_, code = as.refresh(refreshToken)
+	as.Equal("REFRESH_TOKEN_REUSED", code)
+
+	var session models.Session
+	as.NoError(as.DB.Where("user_id = ?", user.ID).First(&session))
+	as.True(session.Revoked)
+
+	// the token handed out by the legitimate refresh dies with the session
+	_, code = as.refresh(tokens.RefreshToken)
+	as.Equal("SESSION_INVALID", code)
+
+	count, err := as.DB.Where("user_id = ? AND failure_reason = ?", user.ID, "refresh_token_reuse").Count(&models.LoginAttempt{})
+	as.NoError(err)
+	as.Equal(1, count)
+}
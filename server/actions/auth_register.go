@@ -5,8 +5,8 @@ package actions
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"fmt"
 	"net/http"
+	"server/mailers"
 	"server/models"
 	"strings"
 	"time"
@@ -49,25 +49,23 @@ func AuthRegister(c buffalo.Context) error {
 
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 
-	if len(req.Password) < 8 {
-		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
-			Success:   false,
-			Error:     "Password must be at least 8 characters",
-			ErrorCode: "PASSWORD_TOO_SHORT",
-		}))
-	}
-
-	validRoles := map[string]bool{"support": true, "admin": true, "dev": true}
 	if req.Role == "" {
-		req.Role = "support"
+		req.Role = DefaultRole
 	}
-	if !validRoles[req.Role] {
+	if !ValidRoles[req.Role] {
 		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
 			Success:   false,
 			Error:     "Invalid role. Must be: support, admin, or dev",
 			ErrorCode: "INVALID_ROLE",
 		}))
 	}
+	if req.Role != DefaultRole {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Only the support role can be self-registered. Ask an admin for other roles",
+			ErrorCode: "ROLE_NOT_ALLOWED",
+		}))
+	}
 
 	tx, ok := c.Value("tx").(*pop.Connection)
 	if !ok || tx == nil {
@@ -78,8 +76,24 @@ func AuthRegister(c buffalo.Context) error {
 		}))
 	}
 
+	violations, err := checkPasswordPolicy(tx, models.User{
+		Email:    req.Email,
+		Name:     req.Name,
+		LastName: req.LastName,
+	}, req.Password)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to create user",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+	if len(violations) > 0 {
+		return renderPasswordPolicy(c, "password", violations)
+	}
+
 	var existingUser models.User
-	err := tx.Where("email = ?", req.Email).First(&existingUser)
+	err = tx.Where("email = ?", req.Email).First(&existingUser)
 	if err == nil {
 		return c.Render(http.StatusConflict, r.JSON(ErrorResponse{
 			Success:   false,
@@ -119,7 +133,7 @@ func AuthRegister(c buffalo.Context) error {
 		UserID:    &user.ID,
 		TokenHash: tokenHash,
 		TokenType: "email_verification",
-		ExpiresAt: time.Now().UTC().Add(24 * time.Hour),
+		ExpiresAt: time.Now().UTC().Add(EmailVerificationTokenDuration),
 		Used:      false,
 		CreatedAt: time.Now().UTC(),
 	}
@@ -132,7 +146,15 @@ func AuthRegister(c buffalo.Context) error {
 		}))
 	}
 
-	fmt.Printf("[DEV] Verification token for %s: %s\n", user.Email, verificationToken)
+	if err := mailers.SendVerificationEmail(tx, user, verificationToken, EmailVerificationTokenDuration); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to send verification email",
+			ErrorCode: "EMAIL_SEND_FAILED",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditUserRegistered, map[string]any{"email": user.Email})
 
 	return c.Render(http.StatusCreated, r.JSON(map[string]interface{}{
 		"success": true,
@@ -142,6 +164,5 @@ func AuthRegister(c buffalo.Context) error {
 			Email:         user.Email,
 			EmailVerified: user.EmailVerified,
 		},
-		"_dev_verification_token": verificationToken,
 	}))
 }
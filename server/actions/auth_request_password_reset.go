@@ -2,6 +2,7 @@ package actions
 
 import (
 	"net/http"
+	"server/mailers"
 	"server/models"
 	"strings"
 	"time"
@@ -63,7 +64,7 @@ func AuthRequestPasswordReset(c buffalo.Context) error {
 		UserID:    &user.ID,
 		TokenHash: tokenHash,
 		TokenType: "password_reset",
-		ExpiresAt: time.Now().UTC().Add(1 * time.Hour),
+		ExpiresAt: time.Now().UTC().Add(PasswordResetTokenDuration),
 		Used:      false,
 		CreatedAt: time.Now().UTC(),
 	}
@@ -72,12 +73,10 @@ func AuthRequestPasswordReset(c buffalo.Context) error {
 		return c.Render(http.StatusOK, r.JSON(successResponse))
 	}
 
-	if ENV == "development" {
-		return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
-			"success":                   true,
-			"message":                   "If the email exists, a password reset link has been sent",
-			"_dev_password_reset_token": rawToken,
-		}))
+	recordAuditEvent(tx, c.Request(), AuditPasswordResetRequested, nil, &user.ID, nil)
+
+	if err := mailers.SendPasswordResetEmail(tx, user, rawToken, PasswordResetTokenDuration); err != nil {
+		c.Logger().Errorf("queue password reset email for %s: %v", user.Email, err)
 	}
 
 	return c.Render(http.StatusOK, r.JSON(successResponse))
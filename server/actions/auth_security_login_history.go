@@ -2,8 +2,8 @@ package actions
 
 import (
 	"net/http"
+	"server/geoip"
 	"server/models"
-	"strconv"
 	"time"
 
 	"github.com/gobuffalo/buffalo"
@@ -11,12 +11,49 @@ import (
 )
 
 type LoginAttemptInfo struct {
-	ID            string    `json:"id"`
-	Success       bool      `json:"success"`
-	FailureReason *string   `json:"failure_reason,omitempty"`
-	IPAddress     *string   `json:"ip_address,omitempty"`
-	UserAgent     *string   `json:"user_agent,omitempty"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID             string          `json:"id"`
+	Success        bool            `json:"success"`
+	FailureReason  *string         `json:"failure_reason,omitempty"`
+	IPAddress      *string         `json:"ip_address,omitempty"`
+	UserAgent      *string         `json:"user_agent,omitempty"`
+	Browser        *string         `json:"browser,omitempty"`
+	BrowserVersion *string         `json:"browser_version,omitempty"`
+	OS             *string         `json:"os,omitempty"`
+	OSVersion      *string         `json:"os_version,omitempty"`
+	DeviceType     *string         `json:"device_type,omitempty"`
+	Location       *geoip.Location `json:"location,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+func newLoginAttemptInfo(attempt models.LoginAttempt) LoginAttemptInfo {
+	info := LoginAttemptInfo{
+		ID:             attempt.ID.String(),
+		Success:        attempt.Success,
+		FailureReason:  attempt.FailureReason,
+		IPAddress:      attempt.IPAddress,
+		UserAgent:      attempt.UserAgent,
+		Browser:        attempt.Browser,
+		BrowserVersion: attempt.BrowserVersion,
+		OS:             attempt.OS,
+		OSVersion:      attempt.OSVersion,
+		DeviceType:     attempt.DeviceType,
+		CreatedAt:      attempt.CreatedAt,
+	}
+
+	// coordinates aren't kept for attempts, only what the user can read
+	location := geoip.Location{
+		CountryCode: valueOf(attempt.CountryCode),
+		Country:     valueOf(attempt.Country),
+		City:        valueOf(attempt.City),
+		ASOrg:       valueOf(attempt.ASOrg),
+	}
+	if attempt.ASN != nil {
+		location.ASN = uint(*attempt.ASN)
+	}
+	if location != (geoip.Location{}) {
+		info.Location = &location
+	}
+	return info
 }
 
 func AuthSecurityLoginHistory(c buffalo.Context) error {
@@ -29,23 +66,7 @@ func AuthSecurityLoginHistory(c buffalo.Context) error {
 		}))
 	}
 
-	limitStr := c.Param("limit")
-	offsetStr := c.Param("offset")
-
-	limit := 20
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
-	}
+	limit, offset := parseLimitOffset(c)
 
 	tx, ok := c.Value("tx").(*pop.Connection)
 	if !ok || tx == nil {
@@ -77,14 +98,7 @@ func AuthSecurityLoginHistory(c buffalo.Context) error {
 
 	attemptInfos := make([]LoginAttemptInfo, len(attempts))
 	for i, attempt := range attempts {
-		attemptInfos[i] = LoginAttemptInfo{
-			ID:            attempt.ID.String(),
-			Success:       attempt.Success,
-			FailureReason: attempt.FailureReason,
-			IPAddress:     attempt.IPAddress,
-			UserAgent:     attempt.UserAgent,
-			CreatedAt:     attempt.CreatedAt,
-		}
+		attemptInfos[i] = newLoginAttemptInfo(attempt)
 	}
 
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
@@ -0,0 +1,151 @@
+package actions
+
+import (
+	"server/models"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// SessionCacheTTL bounds how long a revoked session can keep being accepted
+// by an instance that did not perform the revocation itself.
+const SessionCacheTTL = 30 * time.Second
+
+type sessionCacheEntry struct {
+	userID    uuid.UUID
+	expiresAt time.Time
+}
+
+// sessionCache keeps the ids of sessions recently confirmed as active so
+// AuthMiddleware does not query auth.sessions on every request.
+type sessionCache struct {
+	mu      sync.RWMutex
+	entries map[uuid.UUID]sessionCacheEntry
+	// generation changes on every forget, so a lookup that read the session
+	// before a revocation doesn't cache it after the revocation was dropped
+	generation uint64
+	nextSweep  time.Time
+}
+
+var activeSessions = &sessionCache{entries: map[uuid.UUID]sessionCacheEntry{}}
+
+func (s *sessionCache) get(sessionID uuid.UUID) (uuid.UUID, uint64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[sessionID]
+	if !ok || time.Now().UTC().After(entry.expiresAt) {
+		return uuid.Nil, s.generation, false
+	}
+	return entry.userID, s.generation, true
+}
+
+// set caches the session unless something was forgotten since generation
+// was read. Expired entries are swept at most once per SessionCacheTTL.
+func (s *sessionCache) set(sessionID, userID uuid.UUID, expiresAt time.Time, generation uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if generation != s.generation {
+		return
+	}
+	now := time.Now().UTC()
+	if now.After(s.nextSweep) {
+		for id, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, id)
+			}
+		}
+		s.nextSweep = now.Add(SessionCacheTTL)
+	}
+	s.entries[sessionID] = sessionCacheEntry{userID: userID, expiresAt: expiresAt}
+}
+
+func (s *sessionCache) forget(sessionID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	delete(s.entries, sessionID)
+}
+
+func (s *sessionCache) forgetUser(userID uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	for id, entry := range s.entries {
+		if entry.userID == userID {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// revokedSessions collects the sessions a request revokes so they are
+// dropped from activeSessions once its transaction is over.
+type revokedSessions struct {
+	sessionIDs []uuid.UUID
+	userIDs    []uuid.UUID
+}
+
+// ForgetRevokedSessions goes before popmw.Transaction. Dropping a session
+// from the cache before the revocation commits would let a concurrent
+// request read the still-active row and cache it again.
+func ForgetRevokedSessions(next buffalo.Handler) buffalo.Handler {
+	return func(c buffalo.Context) error {
+		revoked := &revokedSessions{}
+		c.Set("revoked_sessions", revoked)
+		defer func() {
+			for _, id := range revoked.sessionIDs {
+				activeSessions.forget(id)
+			}
+			for _, id := range revoked.userIDs {
+				activeSessions.forgetUser(id)
+			}
+		}()
+		return next(c)
+	}
+}
+
+// forgetSession drops sessionID from activeSessions after the request
+// transaction, or right away outside a request.
+func forgetSession(c buffalo.Context, sessionID uuid.UUID) {
+	if revoked, ok := c.Value("revoked_sessions").(*revokedSessions); ok {
+		revoked.sessionIDs = append(revoked.sessionIDs, sessionID)
+		return
+	}
+	activeSessions.forget(sessionID)
+}
+
+// forgetUserSessions is forgetSession for every session of userID.
+func forgetUserSessions(c buffalo.Context, userID uuid.UUID) {
+	if revoked, ok := c.Value("revoked_sessions").(*revokedSessions); ok {
+		revoked.userIDs = append(revoked.userIDs, userID)
+		return
+	}
+	activeSessions.forgetUser(userID)
+}
+
+// isSessionActive reports whether the session exists for userID and is
+// neither revoked nor expired.
+func isSessionActive(tx *pop.Connection, sessionID, userID uuid.UUID) bool {
+	cachedUserID, generation, ok := activeSessions.get(sessionID)
+	if ok {
+		return cachedUserID == userID
+	}
+
+	now := time.Now().UTC()
+	var session models.Session
+	err := tx.Where("id = ? AND user_id = ? AND revoked = ? AND expires_at > ?",
+		sessionID, userID, false, now).First(&session)
+	if err != nil {
+		return false
+	}
+
+	expiresAt := now.Add(SessionCacheTTL)
+	if session.ExpiresAt.Before(expiresAt) {
+		expiresAt = session.ExpiresAt
+	}
+	activeSessions.set(session.ID, session.UserID, expiresAt, generation)
+
+	return true
+}
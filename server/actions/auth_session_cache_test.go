@@ -0,0 +1,58 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func (as *ActionSuite) Test_SessionCache_LogoutDropsCachedSession() {
+	user := as.createUser("cache@example.com", RoleSupport)
+	accessToken, refreshToken := as.signIn(user)
+
+	// caches the session
+	res := as.authJSON(accessToken, "/api/v1/auth/sessions").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/logout").Post(LogoutRequest{RefreshToken: refreshToken})
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/sessions").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("SESSION_INVALID", errorCode(res))
+}
+
+func (as *ActionSuite) Test_SessionCache_RevokedFromAnotherSession() {
+	user := as.createUser("cache@example.com", RoleSupport)
+	accessToken, _ := as.signIn(user)
+
+	var session models.Session
+	as.NoError(as.DB.Where("user_id = ?", user.ID).First(&session))
+
+	otherToken, _ := as.signIn(user)
+	res := as.authJSON(accessToken, "/api/v1/auth/sessions").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(otherToken, "/api/v1/auth/sessions/%s", session.ID).Delete()
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(accessToken, "/api/v1/auth/sessions").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("SESSION_INVALID", errorCode(res))
+}
+
+func (as *ActionSuite) Test_SessionCache_IgnoresReadsFromBeforeAForget() {
+	sessionID, userID := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+
+	// a lookup reads the session, then a revocation is dropped from the
+	// cache before the lookup stores what it read
+	_, generation, ok := activeSessions.get(sessionID)
+	as.False(ok)
+	activeSessions.forgetUser(userID)
+	activeSessions.set(sessionID, userID, time.Now().UTC().Add(SessionCacheTTL), generation)
+
+	_, _, ok = activeSessions.get(sessionID)
+	as.False(ok)
+}
@@ -37,14 +37,7 @@ func AuthSessionsList(c buffalo.Context) error {
 		}))
 	}
 
-	// Identificar sesión actual por la más reciente actividad
-	currentSessionID := ""
-	var currentSession models.Session
-	err = tx.Where("user_id = ? AND revoked = ? AND expires_at > ?",
-		user.ID, false, time.Now().UTC()).Order("last_activity_at DESC").First(&currentSession)
-	if err == nil {
-		currentSessionID = currentSession.ID.String()
-	}
+	currentSessionID, _ := GetCurrentSessionID(c)
 
 	var sessions []models.Session
 	err = tx.Where("user_id = ? AND revoked = ? AND expires_at > ?",
@@ -64,7 +57,7 @@ func AuthSessionsList(c buffalo.Context) error {
 			DeviceInfo:     session.DeviceInfo,
 			CreatedAt:      session.CreatedAt,
 			LastActivityAt: session.LastActivityAt,
-			Current:        session.ID.String() == currentSessionID,
+			Current:        session.ID == currentSessionID,
 		}
 	}
 
@@ -78,6 +78,9 @@ func AuthSessionsRevoke(c buffalo.Context) error {
 			ErrorCode: "INTERNAL_ERROR",
 		}))
 	}
+	forgetSession(c, session.ID)
+
+	recordUserEvent(tx, c, user, AuditSessionRevoked, map[string]any{"session_id": session.ID})
 
 	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
 		"success": true,
@@ -2,11 +2,10 @@ package actions
 
 import (
 	"net/http"
-	"server/models"
-	"time"
 
 	"github.com/gobuffalo/buffalo"
 	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
 )
 
 type RevokeAllSessionsRequest struct {
@@ -35,59 +34,25 @@ func AuthSessionsRevokeAll(c buffalo.Context) error {
 		}))
 	}
 
-	now := time.Now().UTC()
-	var revokedCount int
-
-	if req.IncludeCurrent {
-		result, err := tx.RawQuery(`
-			UPDATE auth.sessions 
-			SET revoked = true, revoked_at = ? 
-			WHERE user_id = ? AND revoked = false
-		`, now, user.ID).ExecWithCount()
-		if err != nil {
-			return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-				Success:   false,
-				Error:     "Failed to revoke sessions",
-				ErrorCode: "INTERNAL_ERROR",
-			}))
-		}
-		revokedCount = result
-	} else {
-		var currentSession models.Session
-		err := tx.Where("user_id = ? AND revoked = ? AND expires_at > ?",
-			user.ID, false, now).Order("last_activity_at DESC").First(&currentSession)
+	keepSessionID := uuid.Nil
+	if !req.IncludeCurrent {
+		keepSessionID, _ = GetCurrentSessionID(c)
+	}
 
-		if err != nil {
-			result, err := tx.RawQuery(`
-				UPDATE auth.sessions 
-				SET revoked = true, revoked_at = ? 
-				WHERE user_id = ? AND revoked = false
-			`, now, user.ID).ExecWithCount()
-			if err != nil {
-				return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-					Success:   false,
-					Error:     "Failed to revoke sessions",
-					ErrorCode: "INTERNAL_ERROR",
-				}))
-			}
-			revokedCount = result
-		} else {
-			result, err := tx.RawQuery(`
-				UPDATE auth.sessions 
-				SET revoked = true, revoked_at = ? 
-				WHERE user_id = ? AND revoked = false AND id != ?
-			`, now, user.ID, currentSession.ID).ExecWithCount()
-			if err != nil {
-				return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
-					Success:   false,
-					Error:     "Failed to revoke sessions",
-					ErrorCode: "INTERNAL_ERROR",
-				}))
-			}
-			revokedCount = result
-		}
+	revokedCount, err := revokeUserSessions(c, tx, user.ID, keepSessionID)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to revoke sessions",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditSessionsRevokedAll, map[string]any{
+		"revoked_count":   revokedCount,
+		"include_current": req.IncludeCurrent,
+	})
+
 	message := "All other sessions revoked successfully"
 	if req.IncludeCurrent {
 		message = "All sessions revoked successfully"
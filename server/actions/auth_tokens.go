@@ -0,0 +1,163 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	// PersonalAccessTokenPrefix starts every personal access token, which
+	// tells them apart from JWTs and makes leaked ones easy to search for.
+	PersonalAccessTokenPrefix = "rop_"
+
+	// tokenDisplayLength is how much of the token is kept in clear to
+	// identify it in listings.
+	tokenDisplayLength = len(PersonalAccessTokenPrefix) + 8
+
+	// tokenUsageInterval is how often the last use of a token is written;
+	// uses in between aren't recorded.
+	tokenUsageInterval = time.Minute
+)
+
+// Scopes of personal access tokens.
+const (
+	ScopeProfileRead   = "profile:read"
+	ScopeProfileWrite  = "profile:write"
+	ScopeSessionsRead  = "sessions:read"
+	ScopeSessionsWrite = "sessions:write"
+	ScopeSecurityRead  = "security:read"
+	ScopeAdminRead     = "admin:read"
+	ScopeAdminWrite    = "admin:write"
+)
+
+// TokenScopes are the scopes a token can be created with, and the role
+// permission the user needs for the ones that have it. The permission is
+// still checked on every request by RequirePermission.
+var TokenScopes = map[string]string{
+	ScopeProfileRead:   "",
+	ScopeProfileWrite:  "",
+	ScopeSessionsRead:  "",
+	ScopeSessionsWrite: "",
+	ScopeSecurityRead:  "",
+	ScopeAdminRead:     PermissionUsersRead,
+	ScopeAdminWrite:    PermissionUsersManage,
+}
+
+// tokenRoutes maps the routes personal access tokens can be used on, by
+// method and path, to the scope they need. Every other route only accepts
+// session tokens.
+var tokenRoutes = map[string]string{}
+
+// allowTokens opens route to personal access tokens created with scope.
+func allowTokens(scope string, route *buffalo.RouteInfo) {
+	tokenRoutes[route.Method+" "+route.Path] = scope
+}
+
+// authenticateToken is the part of AuthMiddleware for personal access
+// tokens. Requests made with them have no session and no authentication
+// time, so the routes that need one, or a recent authentication, stay out
+// of reach even if they were opened to tokens.
+func authenticateToken(c buffalo.Context, next buffalo.Handler, rawToken string) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	now := time.Now().UTC()
+	var token models.PersonalAccessToken
+	err := tx.Where("token_hash = ? AND revoked = ? AND expires_at > ?", sha256Hex(rawToken), false, now).First(&token)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired token",
+			ErrorCode: "INVALID_TOKEN",
+		}))
+	}
+
+	var user models.User
+	if err := tx.Find(&user, token.UserID); err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	if !user.Active {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account is inactive",
+			ErrorCode: "ACCOUNT_INACTIVE",
+		}))
+	}
+
+	route, _ := c.Value("current_route").(buffalo.RouteInfo)
+	scope, allowed := tokenRoutes[route.Method+" "+route.Path]
+	if !allowed {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "This route can't be used with a personal access token",
+			ErrorCode: "TOKEN_NOT_ALLOWED",
+		}))
+	}
+	if !token.HasScope(scope) {
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Token is missing the required scope",
+			ErrorCode: "INSUFFICIENT_SCOPE",
+			Details:   map[string]any{"required_scope": scope},
+		}))
+	}
+
+	recordTokenUse(c, token, now)
+
+	c.Set("current_user", user)
+	c.Set("user_id", user.ID.String())
+	c.Set("personal_access_token", token)
+
+	return next(c)
+}
+
+// recordTokenUse updates the last use of token outside the request
+// transaction, so failed requests count as uses too.
+func recordTokenUse(c buffalo.Context, token models.PersonalAccessToken, now time.Time) {
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < tokenUsageInterval {
+		return
+	}
+	err := models.DB.RawQuery(`
+		UPDATE auth.personal_access_tokens
+		SET last_used_at = ?, last_used_ip = ?, last_used_user_agent = ?
+		WHERE id = ?
+	`, now, optionalString(clientIP(c.Request())), optionalString(c.Request().UserAgent()), token.ID).Exec()
+	if err != nil {
+		c.Logger().Errorf("recording use of token %s: %v", token.ID, err)
+	}
+}
+
+// revokeUserTokens revokes every personal access token of userID.
+func revokeUserTokens(tx *pop.Connection, userID uuid.UUID) (int, error) {
+	return tx.RawQuery(`
+		UPDATE auth.personal_access_tokens
+		SET revoked = true, revoked_at = ?
+		WHERE user_id = ? AND revoked = false
+	`, time.Now().UTC(), userID).ExecWithCount()
+}
+
+func findUserToken(tx *pop.Connection, user models.User, idParam string) (models.PersonalAccessToken, bool) {
+	var token models.PersonalAccessToken
+	id, err := uuid.FromString(idParam)
+	if err != nil {
+		return token, false
+	}
+	err = tx.Where("id = ? AND user_id = ? AND revoked = ?", id, user.ID, false).First(&token)
+	return token, err == nil
+}
@@ -0,0 +1,156 @@
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"server/models"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const (
+	MaxTokensPerUser       = 20
+	MaxTokenNameLength     = 100
+	DefaultTokenLifetime   = 30 // days
+	MaxTokenLifetimeDays   = 365
+	personalAccessTokenLen = 32 // random bytes
+)
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreatedToken is the only response that carries the token itself; only
+// its hash is kept.
+type CreatedToken struct {
+	models.PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// AuthTokensCreate creates a personal access token. Like any new way into
+// the account it needs a recent authentication (RequireRecentAuth).
+func AuthTokensCreate(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req CreateTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = DefaultTokenLifetime
+	}
+
+	scopes := []string{}
+	details := map[string]any{}
+	for _, scope := range req.Scopes {
+		if _, ok := TokenScopes[scope]; !ok {
+			details["scopes"] = "Unknown scope: " + scope
+			break
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(req.Scopes) == 0 {
+		details["scopes"] = "At least one scope is required"
+	}
+	if req.Name == "" || len(req.Name) > MaxTokenNameLength {
+		details["name"] = fmt.Sprintf("Name is required and must be at most %d characters", MaxTokenNameLength)
+	}
+	if req.ExpiresInDays < 1 || req.ExpiresInDays > MaxTokenLifetimeDays {
+		details["expires_in_days"] = fmt.Sprintf("Must be between 1 and %d", MaxTokenLifetimeDays)
+	}
+	if len(details) > 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   details,
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	for _, scope := range scopes {
+		if permission := TokenScopes[scope]; permission != "" && !hasPermission(tx, user.Role, permission) {
+			return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+				Success:   false,
+				Error:     "You do not have permission to create a token with this scope",
+				ErrorCode: "FORBIDDEN",
+				Details:   map[string]any{"scope": scope, "required_permission": permission},
+			}))
+		}
+	}
+
+	now := time.Now().UTC()
+
+	var active int
+	tx.RawQuery(`
+		SELECT COUNT(*) FROM auth.personal_access_tokens
+		WHERE user_id = ? AND revoked = false AND expires_at > ?
+	`, user.ID, now).First(&active)
+	if active >= MaxTokensPerUser {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Maximum number of tokens reached",
+			ErrorCode: "TOKEN_LIMIT_REACHED",
+		}))
+	}
+
+	rawToken := PersonalAccessTokenPrefix + generateSecureToken(personalAccessTokenLen)
+	token := models.PersonalAccessToken{
+		UserID:      user.ID,
+		Name:        req.Name,
+		TokenHash:   sha256Hex(rawToken),
+		TokenPrefix: rawToken[:tokenDisplayLength],
+		Scopes:      scopes,
+		ExpiresAt:   now.AddDate(0, 0, req.ExpiresInDays),
+		CreatedAt:   now,
+	}
+	if err := tx.Create(&token); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to create token",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditTokenCreated, map[string]any{
+		"token_id":   token.ID,
+		"name":       token.Name,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+
+	return c.Render(http.StatusCreated, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Token created. Copy it now, it won't be shown again.",
+		"data":    CreatedToken{PersonalAccessToken: token, Token: rawToken},
+	}))
+}
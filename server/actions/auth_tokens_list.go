@@ -0,0 +1,45 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// AuthTokensList returns the personal access tokens of the user that
+// haven't been revoked, expired ones included until cleanup removes them.
+func AuthTokensList(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	tokens := models.PersonalAccessTokens{}
+	if err := tx.Where("user_id = ? AND revoked = ?", user.ID, false).Order("created_at DESC").All(&tokens); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load tokens",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data":    tokens,
+	}))
+}
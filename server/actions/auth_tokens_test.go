@@ -0,0 +1,128 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/pquerna/otp/totp"
+)
+
+// createToken stores a personal access token of user and returns it.
+func (as *ActionSuite) createToken(user models.User, expiresAt time.Time, scopes ...string) string {
+	rawToken := PersonalAccessTokenPrefix + generateSecureToken(personalAccessTokenLen)
+	token := models.PersonalAccessToken{
+		UserID:      user.ID,
+		Name:        "CI",
+		TokenHash:   sha256Hex(rawToken),
+		TokenPrefix: rawToken[:tokenDisplayLength],
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	}
+	as.NoError(as.DB.Create(&token))
+	return rawToken
+}
+
+const recentAuthPath = "/test/recent-auth"
+
+var recentAuthRoute sync.Once
+
+// openRecentAuthRoute adds recentAuthPath, a route behind RequireRecentAuth
+// opened to profile:write tokens. No real route is both, so it checks the
+// middleware itself turns tokens away.
+func openRecentAuthRoute() {
+	recentAuthRoute.Do(func() {
+		g := App().Group("/test")
+		g.Use(AuthMiddleware)
+		allowTokens(ScopeProfileWrite, g.POST("/recent-auth", RequireRecentAuth(RecentAuthMaxAge)(func(c buffalo.Context) error {
+			return c.Render(http.StatusOK, r.JSON(map[string]interface{}{"success": true}))
+		})))
+	})
+}
+
+func (as *ActionSuite) Test_PersonalAccessToken_OnlyWorksInScope() {
+	user := as.createUser("pat@example.com", RoleSupport)
+	token := as.createToken(user, time.Now().UTC().Add(time.Hour), ScopeProfileRead)
+
+	res := as.authJSON(token, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusOK, res.Code)
+
+	// opened to tokens, but for another scope
+	res = as.authJSON(token, "/api/v1/auth/sessions").Get()
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("INSUFFICIENT_SCOPE", errorCode(res))
+
+	// not opened to tokens at all
+	res = as.authJSON(token, "/api/v1/auth/tokens").Get()
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("TOKEN_NOT_ALLOWED", errorCode(res))
+}
+
+func (as *ActionSuite) Test_PersonalAccessToken_ExpiredAndRevokedAreRejected() {
+	user := as.createUser("pat@example.com", RoleSupport)
+
+	expired := as.createToken(user, time.Now().UTC().Add(-time.Minute), ScopeProfileRead)
+	res := as.authJSON(expired, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+
+	revoked := as.createToken(user, time.Now().UTC().Add(time.Hour), ScopeProfileRead)
+	_, err := revokeUserTokens(as.DB, user.ID)
+	as.NoError(err)
+	res = as.authJSON(revoked, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("INVALID_TOKEN", errorCode(res))
+}
+
+func (as *ActionSuite) Test_PersonalAccessToken_FailsRecentAuth() {
+	user := as.createUser("pat@example.com", RoleSupport)
+	token := as.createToken(user, time.Now().UTC().Add(time.Hour), ScopeProfileWrite)
+	openRecentAuthRoute()
+
+	res := as.authJSON(token, recentAuthPath).Post(nil)
+	as.Equal(http.StatusForbidden, res.Code)
+	as.Equal("REAUTH_REQUIRED", errorCode(res))
+
+	// a fresh login passes the same route
+	accessToken, _ := as.signIn(user)
+	res = as.authJSON(accessToken, recentAuthPath).Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+}
+
+func (as *ActionSuite) Test_PersonalAccessToken_RevokedOnPasswordChange() {
+	user := as.createUser("pat@example.com", RoleSupport)
+	token := as.createToken(user, time.Now().UTC().Add(time.Hour), ScopeProfileRead)
+	accessToken, _ := as.signIn(user)
+
+	res := as.authJSON(accessToken, "/api/v1/auth/password/change").Post(ChangePasswordRequest{
+		CurrentPassword: testPassword,
+		NewPassword:     "Violet-Harbor-Lantern-42",
+	})
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(token, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+}
+
+func (as *ActionSuite) Test_PersonalAccessToken_RevokedOn2FADisable() {
+	user := as.createUser("pat@example.com", RoleSupport)
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "RedOrange", AccountName: user.Email})
+	as.NoError(err)
+	secret := models.EncryptedString(key.Secret())
+	user.TwoFactorEnabled = true
+	user.TwoFactorSecret = &secret
+	as.NoError(as.DB.Update(&user))
+
+	token := as.createToken(user, time.Now().UTC().Add(time.Hour), ScopeProfileRead)
+	accessToken, _ := as.signIn(user)
+
+	code, err := totp.GenerateCode(key.Secret(), time.Now().UTC())
+	as.NoError(err)
+	res := as.authJSON(accessToken, "/api/v1/auth/2fa/disable").Post(Disable2FARequest{Code: code})
+	as.Equal(http.StatusOK, res.Code)
+
+	res = as.authJSON(token, "/api/v1/auth/me").Get()
+	as.Equal(http.StatusUnauthorized, res.Code)
+}
@@ -113,6 +113,8 @@ func AuthVerifyEmail(c buffalo.Context) error {
 		}))
 	}
 
+	recordUserEvent(tx, c, user, AuditEmailVerified, map[string]any{"email": user.Email})
+
 	return c.Render(http.StatusOK, r.JSON(VerifyEmailResponse{
 		Success: true,
 		Message: "Email verified successfully",
@@ -0,0 +1,203 @@
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+const (
+	WebAuthnChallengeDuration = 5 * time.Minute
+	MaxPasskeyNameLength      = 100
+)
+
+var (
+	// WebAuthnRPID is the domain passkeys are bound to, the frontend host
+	// or a parent domain of it.
+	WebAuthnRPID   = envy.Get("WEBAUTHN_RP_ID", "localhost")
+	WebAuthnRPName = envy.Get("WEBAUTHN_RP_NAME", "RedOrange")
+	// WebAuthnOrigins are the frontend origins allowed to run ceremonies,
+	// comma separated.
+	WebAuthnOrigins = envy.Get("WEBAUTHN_ORIGINS", "http://localhost:3000,http://localhost:3001")
+)
+
+var errInvalidWebAuthnChallenge = errors.New("invalid or expired webauthn challenge")
+
+var webAuthn *webauthn.WebAuthn
+
+func init() {
+	var origins []string
+	for _, origin := range strings.Split(WebAuthnOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+
+	var err error
+	webAuthn, err = webauthn.New(&webauthn.Config{
+		RPID:          WebAuthnRPID,
+		RPDisplayName: WebAuthnRPName,
+		RPOrigins:     origins,
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: WebAuthnChallengeDuration, TimeoutUVD: WebAuthnChallengeDuration},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: WebAuthnChallengeDuration, TimeoutUVD: WebAuthnChallengeDuration},
+		},
+	})
+	if err != nil {
+		log.Fatalf("webauthn: %v", err)
+	}
+}
+
+// -- webauthn.User adapter
+
+type webAuthnUser struct {
+	user        models.User
+	credentials []models.WebAuthnCredential
+}
+
+func loadWebAuthnUser(tx *pop.Connection, user models.User) (webAuthnUser, error) {
+	u := webAuthnUser{user: user}
+	err := tx.Where("user_id = ?", user.ID).Order("created_at ASC").All(&u.credentials)
+	return u, err
+}
+
+// WebAuthnID is the user handle stored in discoverable credentials; the
+// user id is opaque and carries no personal data.
+func (u webAuthnUser) WebAuthnID() []byte { return u.user.ID.Bytes() }
+
+func (u webAuthnUser) WebAuthnName() string { return u.user.Email }
+
+func (u webAuthnUser) WebAuthnDisplayName() string {
+	return strings.TrimSpace(u.user.Name + " " + u.user.LastName)
+}
+
+func (u webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, c := range u.credentials {
+		credential := webauthn.Credential{
+			ID:              c.CredentialID,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Flags: webauthn.CredentialFlags{
+				UserVerified:   c.UserVerified,
+				BackupEligible: c.BackupEligible,
+				BackupState:    c.BackupState,
+			},
+			Authenticator: webauthn.Authenticator{
+				AAGUID:       c.AAGUID,
+				SignCount:    uint32(c.SignCount),
+				CloneWarning: c.CloneWarning,
+			},
+		}
+		if c.Transports != nil {
+			for _, t := range strings.Split(*c.Transports, ",") {
+				credential.Transport = append(credential.Transport, protocol.AuthenticatorTransport(t))
+			}
+		}
+		credentials[i] = credential
+	}
+	return credentials
+}
+
+// find returns the stored row for a credential the library validated.
+func (u webAuthnUser) find(credentialID []byte) (models.WebAuthnCredential, bool) {
+	for _, c := range u.credentials {
+		if string(c.CredentialID) == string(credentialID) {
+			return c, true
+		}
+	}
+	return models.WebAuthnCredential{}, false
+}
+
+// -- ceremony state
+
+// saveWebAuthnChallenge stores the session data of a ceremony and returns
+// the id the client sends back to finish it.
+func saveWebAuthnChallenge(tx *pop.Connection, userID *uuid.UUID, ceremony string, session *webauthn.SessionData) (uuid.UUID, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	challenge := models.WebAuthnChallenge{
+		UserID:      userID,
+		Ceremony:    ceremony,
+		SessionData: data,
+		ExpiresAt:   time.Now().UTC().Add(WebAuthnChallengeDuration),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := tx.Create(&challenge); err != nil {
+		return uuid.Nil, err
+	}
+	return challenge.ID, nil
+}
+
+// consumeWebAuthnChallenge deletes and returns a pending ceremony. Like the
+// oauth state it can only be used once, before it expires, for the
+// ceremony (and user) it was started for. userID is nil for passwordless
+// login.
+func consumeWebAuthnChallenge(id, ceremony string, userID *uuid.UUID) (webauthn.SessionData, error) {
+	var session webauthn.SessionData
+
+	challengeID, err := uuid.FromString(id)
+	if err != nil {
+		return session, errInvalidWebAuthnChallenge
+	}
+
+	// a failed finish rolls the request transaction back, so the delete
+	// goes straight to the database to keep the challenge single use
+	var challenge models.WebAuthnChallenge
+	err = models.DB.RawQuery(`
+		DELETE FROM auth.webauthn_challenges
+		WHERE id = ? AND ceremony = ? AND expires_at > ?
+		RETURNING *
+	`, challengeID, ceremony, time.Now().UTC()).First(&challenge)
+	if err != nil {
+		return session, errInvalidWebAuthnChallenge
+	}
+
+	if (userID == nil) != (challenge.UserID == nil) || (userID != nil && *userID != *challenge.UserID) {
+		return session, errInvalidWebAuthnChallenge
+	}
+
+	if err := json.Unmarshal(challenge.SessionData, &session); err != nil {
+		return session, errInvalidWebAuthnChallenge
+	}
+	return session, nil
+}
+
+// -- credential bookkeeping
+
+// recordPasskeyUse stores the new signature counter and backup state after
+// a successful assertion.
+func recordPasskeyUse(tx *pop.Connection, stored models.WebAuthnCredential, validated *webauthn.Credential) error {
+	now := time.Now().UTC()
+	stored.SignCount = int64(validated.Authenticator.SignCount)
+	stored.CloneWarning = validated.Authenticator.CloneWarning
+	stored.BackupState = validated.Flags.BackupState
+	stored.LastUsedAt = &now
+	stored.UpdatedAt = now
+	return tx.Update(&stored)
+}
+
+// secondFactorMethods lists what the user can complete the temp_2fa step
+// with. Any registered passkey counts as a second factor.
+func secondFactorMethods(tx *pop.Connection, user models.User) []string {
+	methods := []string{}
+	if user.TwoFactorEnabled {
+		methods = append(methods, "totp")
+	}
+	if n, err := tx.Where("user_id = ?", user.ID).Count(&models.WebAuthnCredential{}); err == nil && n > 0 {
+		methods = append(methods, "webauthn")
+	}
+	return methods
+}
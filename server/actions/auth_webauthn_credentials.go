@@ -0,0 +1,198 @@
+package actions
+
+import (
+	"net/http"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+type RenamePasskeyRequest struct {
+	Name string `json:"name"`
+}
+
+func AuthWebAuthnCredentialsList(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	passkeys := models.WebAuthnCredentials{}
+	if err := tx.Where("user_id = ?", user.ID).Order("created_at ASC").All(&passkeys); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data":    passkeys,
+	}))
+}
+
+func AuthWebAuthnCredentialsRename(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req RenamePasskeyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	if req.Name == "" || len(req.Name) > MaxPasskeyNameLength {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   map[string]any{"name": "Name is required and must be at most 100 characters"},
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	passkey, found := findUserPasskey(tx, user, c.Param("credential_id"))
+	if !found {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey not found",
+			ErrorCode: "PASSKEY_NOT_FOUND",
+		}))
+	}
+
+	previousName := passkey.Name
+	passkey.Name = req.Name
+	passkey.UpdatedAt = time.Now().UTC()
+	if err := tx.Update(&passkey); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to rename passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditPasskeyRenamed, map[string]any{
+		"passkey_id":    passkey.ID,
+		"previous_name": previousName,
+		"name":          passkey.Name,
+	})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Passkey renamed successfully",
+		"data":    passkey,
+	}))
+}
+
+// AuthWebAuthnCredentialsDelete removes a passkey. Like unlinking an oauth
+// account it needs a recent authentication (RequireRecentAuth) and refuses
+// to remove the last way to sign in.
+func AuthWebAuthnCredentialsDelete(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	passkey, found := findUserPasskey(tx, user, c.Param("credential_id"))
+	if !found {
+		return c.Render(http.StatusNotFound, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey not found",
+			ErrorCode: "PASSKEY_NOT_FOUND",
+		}))
+	}
+
+	hasPassword := user.PasswordHash != nil && *user.PasswordHash != ""
+
+	var otherMethods int
+	tx.RawQuery(`
+		SELECT (SELECT COUNT(*) FROM auth.oauth_providers WHERE user_id = ?)
+		     + (SELECT COUNT(*) FROM auth.webauthn_credentials WHERE user_id = ? AND id != ?)
+	`, user.ID, user.ID, passkey.ID).First(&otherMethods)
+
+	if !hasPassword && otherMethods == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Cannot remove the only sign in method. Please set a password first.",
+			ErrorCode: "NO_OTHER_AUTH_METHOD",
+		}))
+	}
+
+	if err := tx.Destroy(&passkey); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to remove passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditPasskeyDeleted, map[string]any{
+		"passkey_id": passkey.ID,
+		"name":       passkey.Name,
+	})
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Passkey removed successfully",
+	}))
+}
+
+func findUserPasskey(tx *pop.Connection, user models.User, idParam string) (models.WebAuthnCredential, bool) {
+	var passkey models.WebAuthnCredential
+	id, err := uuid.FromString(idParam)
+	if err != nil {
+		return passkey, false
+	}
+	err = tx.Where("id = ? AND user_id = ?", id, user.ID).First(&passkey)
+	return passkey, err == nil
+}
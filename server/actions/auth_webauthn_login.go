@@ -0,0 +1,194 @@
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+type WebAuthnFinishRequest struct {
+	ChallengeID string          `json:"challenge_id"`
+	Credential  json.RawMessage `json:"credential"`
+}
+
+// AuthWebAuthnLoginBegin starts a passwordless login. The challenge isn't
+// tied to an account: the browser offers the passkeys it has for this site
+// and the one picked tells us who the user is.
+func AuthWebAuthnLoginBegin(c buffalo.Context) error {
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	// user verification stands in for the second factor, so it is required
+	assertion, session, err := webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey login",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	challengeID, err := saveWebAuthnChallenge(tx, nil, "login", session)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey login",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"challenge_id": challengeID,
+			"options":      assertion,
+		},
+	}))
+}
+
+// AuthWebAuthnLoginFinish verifies the assertion and logs the owner of the
+// passkey in. A user-verified passkey is already two factors, so there is
+// no temp_2fa step.
+func AuthWebAuthnLoginFinish(c buffalo.Context) error {
+	var req WebAuthnFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	if strings.TrimSpace(req.ChallengeID) == "" || len(req.Credential) == 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Challenge ID and credential are required",
+			ErrorCode: "VALIDATION_ERROR",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	session, err := consumeWebAuthnChallenge(req.ChallengeID, "login", nil)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired challenge",
+			ErrorCode: "INVALID_CHALLENGE",
+		}))
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(req.Credential)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid passkey credential",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	var waUser webAuthnUser
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		userID, err := uuid.FromBytes(userHandle)
+		if err != nil {
+			return nil, err
+		}
+		var user models.User
+		if err := tx.Find(&user, userID); err != nil {
+			return nil, err
+		}
+		waUser, err = loadWebAuthnUser(tx, user)
+		if err != nil {
+			return nil, err
+		}
+		return waUser, nil
+	}
+
+	validated, err := webAuthn.ValidateDiscoverableLogin(findUser, session, parsed)
+	if err != nil {
+		// outside the request transaction, which the 401 rolls back
+		if waUser.user.ID != uuid.Nil {
+			recordLoginAttempt(models.DB, &waUser.user.ID, waUser.user.Email, false, "webauthn_failed", c.Request())
+			checkAndLockAccount(models.DB, waUser.user.ID)
+		}
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey verification failed",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	user := waUser.user
+
+	var accountLock models.AccountLock
+	err = tx.Where("user_id = ?", user.ID).First(&accountLock)
+	if err == nil && time.Now().UTC().Before(accountLock.LockedUntil) {
+		return c.Render(http.StatusLocked, r.JSON(map[string]interface{}{
+			"success":      false,
+			"error":        "Account temporarily locked due to multiple failed attempts",
+			"error_code":   "ACCOUNT_LOCKED",
+			"locked_until": accountLock.LockedUntil,
+		}))
+	}
+
+	if !user.Active {
+		recordLoginAttempt(models.DB, &user.ID, user.Email, false, "account_inactive", c.Request())
+		return c.Render(http.StatusForbidden, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Account is inactive",
+			ErrorCode: "ACCOUNT_INACTIVE",
+		}))
+	}
+
+	stored, _ := waUser.find(validated.ID)
+
+	// a counter that went backwards means the key may have been cloned; the
+	// flag and the attempt are written outside the request transaction so
+	// they survive the error response
+	if validated.Authenticator.CloneWarning {
+		recordPasskeyUse(models.DB, stored, validated)
+		recordLoginAttempt(models.DB, &user.ID, user.Email, false, "webauthn_clone_warning", c.Request())
+		checkAndLockAccount(models.DB, user.ID)
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey verification failed",
+			ErrorCode: "PASSKEY_CLONE_WARNING",
+		}))
+	}
+
+	if err := recordPasskeyUse(tx, stored, validated); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to update passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	clearAccountLock(tx, user.ID)
+	recordLoginAttempt(tx, &user.ID, user.Email, true, "webauthn", c.Request())
+
+	return generateAndReturnTokens(c, tx, user, []string{AMRPasskey})
+}
@@ -0,0 +1,83 @@
+package actions
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"server/models"
+
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+// beginPasskeyLogin starts a passwordless login and returns the challenge id
+// and the challenge the authenticator has to sign.
+func (as *ActionSuite) beginPasskeyLogin() (string, string) {
+	res := as.JSON("/api/v1/auth/webauthn/login/begin").Post(nil)
+	as.Equal(http.StatusOK, res.Code)
+
+	var body struct {
+		Data struct {
+			ChallengeID string                       `json:"challenge_id"`
+			Options     protocol.CredentialAssertion `json:"options"`
+		} `json:"data"`
+	}
+	res.Bind(&body)
+	return body.Data.ChallengeID, body.Data.Options.Response.Challenge.String()
+}
+
+// forgedAssertion is a well-formed assertion for the passkey credentialID of
+// user whose signature doesn't verify.
+func forgedAssertion(challenge string, credentialID []byte, user models.User) json.RawMessage {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	clientData, _ := json.Marshal(map[string]string{
+		"type":      "webauthn.get",
+		"challenge": challenge,
+		"origin":    "http://localhost:3000",
+	})
+
+	rpIDHash := sha256.Sum256([]byte(WebAuthnRPID))
+	authData := append(rpIDHash[:], byte(protocol.FlagUserPresent|protocol.FlagUserVerified))
+	authData = binary.BigEndian.AppendUint32(authData, 1)
+
+	credential, _ := json.Marshal(map[string]any{
+		"id":    b64(credentialID),
+		"rawId": b64(credentialID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData),
+			"authenticatorData": b64(authData),
+			"signature":         b64([]byte("not a signature")),
+			"userHandle":        b64(user.ID.Bytes()),
+		},
+	})
+	return credential
+}
+
+func (as *ActionSuite) Test_AuthWebAuthnLoginFinish_FailureIsRecorded() {
+	user := as.createUser("passkey@example.com", RoleSupport)
+	passkey := models.WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            "Laptop",
+		CredentialID:    []byte("test-credential-id"),
+		PublicKey:       []byte("not a public key"),
+		AttestationType: "none",
+		UserVerified:    true,
+	}
+	as.NoError(as.DB.Create(&passkey))
+
+	challengeID, challenge := as.beginPasskeyLogin()
+	res := as.JSON("/api/v1/auth/webauthn/login/finish").Post(WebAuthnFinishRequest{
+		ChallengeID: challengeID,
+		Credential:  forgedAssertion(challenge, passkey.CredentialID, user),
+	})
+	as.Equal(http.StatusUnauthorized, res.Code)
+	as.Equal("INVALID_CREDENTIAL", errorCode(res))
+
+	// the 401 rolls back the request transaction, the attempt must survive it
+	count, err := as.DB.Where("user_id = ? AND success = ? AND failure_reason = ?", user.ID, false, "webauthn_failed").Count(&models.LoginAttempt{})
+	as.NoError(err)
+	as.Equal(1, count)
+}
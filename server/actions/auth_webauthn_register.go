@@ -0,0 +1,233 @@
+package actions
+
+import (
+	"encoding/json"
+	"net/http"
+	"server/models"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/gobuffalo/buffalo"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const MaxPasskeysPerUser = 10
+
+type WebAuthnRegisterFinishRequest struct {
+	ChallengeID string          `json:"challenge_id"`
+	Name        string          `json:"name"`
+	Credential  json.RawMessage `json:"credential"`
+}
+
+// AuthWebAuthnRegisterBegin returns the options for
+// navigator.credentials.create() to register a new passkey.
+func AuthWebAuthnRegisterBegin(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	if len(waUser.credentials) >= MaxPasskeysPerUser {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Maximum number of passkeys reached",
+			ErrorCode: "PASSKEY_LIMIT_REACHED",
+		}))
+	}
+
+	// resident keys let the passkey log in without typing an email; the
+	// authenticator's own credentials are excluded so it isn't registered twice
+	creation, session, err := webAuthn.BeginRegistration(waUser,
+		webauthn.WithExclusions(webauthn.Credentials(waUser.WebAuthnCredentials()).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey registration",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	challengeID, err := saveWebAuthnChallenge(tx, &user.ID, "registration", session)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to start passkey registration",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	return c.Render(http.StatusOK, r.JSON(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"challenge_id": challengeID,
+			"options":      creation,
+		},
+	}))
+}
+
+// AuthWebAuthnRegisterFinish verifies the attestation returned by the
+// browser and stores the new credential.
+func AuthWebAuthnRegisterFinish(c buffalo.Context) error {
+	user, err := GetCurrentUser(c)
+	if err != nil {
+		return c.Render(http.StatusUnauthorized, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "User not found",
+			ErrorCode: "USER_NOT_FOUND",
+		}))
+	}
+
+	var req WebAuthnRegisterFinishRequest
+	if err := c.Bind(&req); err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid request body",
+			ErrorCode: "INVALID_BODY",
+		}))
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		req.Name = "Passkey"
+	}
+
+	details := map[string]any{}
+	if strings.TrimSpace(req.ChallengeID) == "" {
+		details["challenge_id"] = "Challenge ID is required"
+	}
+	if len(req.Credential) == 0 {
+		details["credential"] = "Credential is required"
+	}
+	if len(req.Name) > MaxPasskeyNameLength {
+		details["name"] = "Name must be at most 100 characters"
+	}
+	if len(details) > 0 {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Validation error",
+			ErrorCode: "VALIDATION_ERROR",
+			Details:   details,
+		}))
+	}
+
+	tx, ok := c.Value("tx").(*pop.Connection)
+	if !ok || tx == nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Database connection not available",
+			ErrorCode: "DB_NOT_AVAILABLE",
+		}))
+	}
+
+	session, err := consumeWebAuthnChallenge(req.ChallengeID, "registration", &user.ID)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid or expired challenge",
+			ErrorCode: "INVALID_CHALLENGE",
+		}))
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(req.Credential)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Invalid passkey credential",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	waUser, err := loadWebAuthnUser(tx, user)
+	if err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to load passkeys",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	credential, err := webAuthn.CreateCredential(waUser, session, parsed)
+	if err != nil {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey verification failed",
+			ErrorCode: "INVALID_CREDENTIAL",
+		}))
+	}
+
+	exists, _ := tx.Where("credential_id = ?", credential.ID).Exists(&models.WebAuthnCredential{})
+	if exists {
+		return c.Render(http.StatusBadRequest, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Passkey is already registered",
+			ErrorCode: "PASSKEY_ALREADY_REGISTERED",
+		}))
+	}
+
+	passkey := models.WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            req.Name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		UserVerified:    credential.Flags.UserVerified,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now().UTC(),
+		UpdatedAt:       time.Now().UTC(),
+	}
+	if len(credential.Transport) > 0 {
+		transports := make([]string, len(credential.Transport))
+		for i, t := range credential.Transport {
+			transports[i] = string(t)
+		}
+		passkey.Transports = stringPtr(strings.Join(transports, ","))
+	}
+
+	if err := tx.Create(&passkey); err != nil {
+		return c.Render(http.StatusInternalServerError, r.JSON(ErrorResponse{
+			Success:   false,
+			Error:     "Failed to save passkey",
+			ErrorCode: "INTERNAL_ERROR",
+		}))
+	}
+
+	recordUserEvent(tx, c, user, AuditPasskeyRegistered, map[string]any{
+		"passkey_id": passkey.ID,
+		"name":       passkey.Name,
+	})
+
+	return c.Render(http.StatusCreated, r.JSON(map[string]interface{}{
+		"success": true,
+		"message": "Passkey registered successfully",
+		"data":    passkey,
+	}))
+}
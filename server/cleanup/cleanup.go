@@ -0,0 +1,208 @@
+package cleanup
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"server/jobs"
+	"server/models"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// Policy deletes rows of Table matching Where once they are older than
+// Retention. Where receives the cutoff time as its only argument.
+type Policy struct {
+	Name      string
+	Table     string
+	Where     string
+	Retention time.Duration
+}
+
+// BatchSize bounds how many rows a single DELETE removes, so a large backlog
+// does not hold locks for long.
+const BatchSize = 5000
+
+// RetentionJob is the jobs handler that applies every policy.
+const RetentionJob = "cleanup:retention"
+
+// Interval is how often the worker enqueues RetentionJob.
+var Interval = envRetention("CLEANUP_INTERVAL", 1*time.Hour)
+
+var Policies = []Policy{
+	{
+		Name:      "sessions",
+		Table:     "auth.sessions",
+		Where:     "(revoked = true AND COALESCE(revoked_at, created_at) < ?) OR expires_at < ?",
+		Retention: envRetention("RETENTION_SESSIONS", 30*24*time.Hour),
+	},
+	{
+		Name:      "personal_access_tokens",
+		Table:     "auth.personal_access_tokens",
+		Where:     "(revoked = true AND COALESCE(revoked_at, created_at) < ?) OR expires_at < ?",
+		Retention: envRetention("RETENTION_PERSONAL_ACCESS_TOKENS", 30*24*time.Hour),
+	},
+	{
+		Name:      "verification_tokens",
+		Table:     "auth.verification_tokens",
+		Where:     "(used = true AND COALESCE(used_at, created_at) < ?) OR expires_at < ?",
+		Retention: envRetention("RETENTION_VERIFICATION_TOKENS", 7*24*time.Hour),
+	},
+	{
+		Name:      "oauth_states",
+		Table:     "auth.oauth_states",
+		Where:     "(used = true AND COALESCE(used_at, created_at) < ?) OR expires_at < ?",
+		Retention: envRetention("RETENTION_OAUTH_STATES", 24*time.Hour),
+	},
+	{
+		Name:      "webauthn_challenges",
+		Table:     "auth.webauthn_challenges",
+		Where:     "expires_at < ?",
+		Retention: envRetention("RETENTION_WEBAUTHN_CHALLENGES", 0),
+	},
+	{
+		Name:      "two_factor_setups",
+		Table:     "auth.two_factor_setups",
+		Where:     "expires_at < ?",
+		Retention: envRetention("RETENTION_TWO_FACTOR_SETUPS", 0),
+	},
+	{
+		Name:      "account_locks",
+		Table:     "auth.account_locks",
+		Where:     "locked_until < ?",
+		Retention: envRetention("RETENTION_ACCOUNT_LOCKS", 24*time.Hour),
+	},
+	{
+		Name:      "login_attempts",
+		Table:     "auth.login_attempts",
+		Where:     "created_at < ?",
+		Retention: envRetention("RETENTION_LOGIN_ATTEMPTS", 180*24*time.Hour),
+	},
+	{
+		Name:      "rate_limits",
+		Table:     "auth.rate_limits",
+		Where:     "expires_at < ?",
+		Retention: envRetention("RETENTION_RATE_LIMITS", 0),
+	},
+	{
+		Name:      "dead_letters",
+		Table:     "jobs.dead_letters",
+		Where:     "failed_at < ?",
+		Retention: envRetention("RETENTION_DEAD_LETTERS", 30*24*time.Hour),
+	},
+}
+
+func init() {
+	jobs.Register(RetentionJob, func(worker.Args) error {
+		_, err := Run(models.DB, Policies)
+		return err
+	})
+	jobs.Every(Interval, worker.Job{Queue: "maintenance", Handler: RetentionJob})
+}
+
+// Find returns the policies with the given names, or all of them when no
+// name is given.
+func Find(names ...string) ([]Policy, error) {
+	if len(names) == 0 {
+		return Policies, nil
+	}
+
+	var found []Policy
+	for _, name := range names {
+		p, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown cleanup policy %q", name)
+		}
+		found = append(found, p)
+	}
+	return found, nil
+}
+
+func lookup(name string) (Policy, bool) {
+	for _, p := range Policies {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Policy{}, false
+}
+
+// Run applies policies and returns how many rows each one deleted.
+func Run(db *pop.Connection, policies []Policy) (map[string]int, error) {
+	deleted := map[string]int{}
+	for _, p := range policies {
+		n, err := p.Apply(db, time.Now().UTC())
+		deleted[p.Name] = n
+		if err != nil {
+			return deleted, fmt.Errorf("cleanup %s: %w", p.Name, err)
+		}
+		if n > 0 {
+			log.Printf("[CLEANUP] %s: deleted %d rows", p.Name, n)
+		}
+	}
+	return deleted, nil
+}
+
+// Count returns how many rows Apply would delete at now.
+func (p Policy) Count(db *pop.Connection, now time.Time) (int, error) {
+	var count int
+	err := db.RawQuery(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", p.Table, p.Where), p.args(now)...).First(&count)
+	return count, err
+}
+
+// Apply deletes matching rows in batches of BatchSize.
+func (p Policy) Apply(db *pop.Connection, now time.Time) (int, error) {
+	args := append(p.args(now), BatchSize)
+
+	query := fmt.Sprintf(`
+		DELETE FROM %s WHERE id IN (
+			SELECT id FROM %s WHERE %s LIMIT ?
+		)
+	`, p.Table, p.Table, p.Where)
+
+	total := 0
+	for {
+		n, err := db.RawQuery(query, args...).ExecWithCount()
+		if err != nil {
+			return total, err
+		}
+		total += n
+		if n < BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// args repeats the cutoff for every placeholder in Where.
+func (p Policy) args(now time.Time) []interface{} {
+	cutoff := now.Add(-p.Retention)
+	args := make([]interface{}, strings.Count(p.Where, "?"))
+	for i := range args {
+		args[i] = cutoff
+	}
+	return args
+}
+
+// envRetention reads a duration such as "720h" or "30d".
+func envRetention(key string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(envy.Get(key, ""))
+	if value == "" {
+		return fallback
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	} else if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d
+	}
+
+	log.Printf("[WARN] invalid %s=%q, using %s", key, value, fallback)
+	return fallback
+}
@@ -0,0 +1,43 @@
+package cleanup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobuffalo/envy"
+)
+
+func Test_EnvRetention(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      time.Hour,
+		"30d":   30 * 24 * time.Hour,
+		"720h":  720 * time.Hour,
+		"90m":   90 * time.Minute,
+		"bogus": time.Hour,
+		"-1d":   time.Hour,
+	}
+	envy.Temp(func() {
+		for value, want := range cases {
+			envy.Set("RETENTION_TEST", value)
+			if got := envRetention("RETENTION_TEST", time.Hour); got != want {
+				t.Errorf("envRetention(%q) = %s, want %s", value, got, want)
+			}
+		}
+	})
+}
+
+func Test_Find(t *testing.T) {
+	all, err := Find()
+	if err != nil || len(all) != len(Policies) {
+		t.Fatalf("Find() = %d policies, %v", len(all), err)
+	}
+
+	some, err := Find("sessions", "login_attempts")
+	if err != nil || len(some) != 2 || some[0].Name != "sessions" || some[1].Name != "login_attempts" {
+		t.Fatalf("Find(sessions, login_attempts) = %+v, %v", some, err)
+	}
+
+	if _, err := Find("nope"); err == nil {
+		t.Fatal("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,162 @@
+// Package clientip resolves the address of the client behind a chain of
+// reverse proxies, trusting forwarding headers only from known proxies.
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Resolver walks X-Forwarded-For or Forwarded (RFC 7239) from the right,
+// skipping trusted proxies, and returns the first untrusted hop.
+type Resolver struct {
+	trusted []netip.Prefix
+}
+
+// New builds a Resolver that trusts the given CIDRs. Bare addresses are
+// treated as single-host prefixes.
+func New(cidrs []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+			}
+			addr = addr.Unmap()
+			r.trusted = append(r.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", cidr, err)
+		}
+		r.trusted = append(r.trusted, prefix.Masked())
+	}
+	return r, nil
+}
+
+func (r *Resolver) isTrusted(addr netip.Addr) bool {
+	for _, p := range r.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client address, or "" when RemoteAddr is not an IP.
+func (r *Resolver) ClientIP(req *http.Request) string {
+	addr, ok := r.Resolve(req)
+	if !ok {
+		return ""
+	}
+	return addr.String()
+}
+
+func (r *Resolver) Resolve(req *http.Request) (netip.Addr, bool) {
+	remote, ok := parseHost(req.RemoteAddr)
+	if !ok {
+		return netip.Addr{}, false
+	}
+	if !r.isTrusted(remote) {
+		return remote, true
+	}
+
+	var chain []string
+	if values := req.Header.Values("Forwarded"); len(values) > 0 {
+		chain = forwardedFor(values)
+	} else if values := req.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		for _, v := range values {
+			for _, hop := range strings.Split(v, ",") {
+				chain = append(chain, strings.TrimSpace(hop))
+			}
+		}
+	} else if realIP, ok := parseHost(req.Header.Get("X-Real-IP")); ok {
+		return realIP, true
+	}
+
+	// the rightmost hop was added by the closest proxy, the leftmost one
+	// is whatever the client sent
+	client := remote
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop, ok := parseHost(chain[i])
+		if !ok {
+			// unknown, obfuscated or garbage: nothing left of it can be
+			// trusted
+			break
+		}
+		client = hop
+		if !r.isTrusted(hop) {
+			break
+		}
+	}
+	return client, true
+}
+
+// forwardedFor extracts the for= parameter of every RFC 7239 element, in
+// order. Elements without one keep their position as "unknown".
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range splitQuoted(v, ',') {
+			hop := "unknown"
+			for _, pair := range splitQuoted(element, ';') {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(strings.TrimSpace(key), "for") {
+					hop = strings.Trim(strings.TrimSpace(value), `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// splitQuoted splits s on sep outside of double quotes.
+func splitQuoted(s string, sep rune) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i, c := range s {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseHost accepts "ip", "ip:port", "[ipv6]" and "[ipv6]:port".
+func parseHost(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Addr{}, false
+	}
+
+	if addr, err := netip.ParseAddr(strings.Trim(s, "[]")); err == nil {
+		return normalize(addr), true
+	}
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		if addr, err := netip.ParseAddr(host); err == nil {
+			return normalize(addr), true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// normalize drops IPv4-in-IPv6 mapping and zones, which the INET column and
+// CIDR matching don't expect.
+func normalize(addr netip.Addr) netip.Addr {
+	return addr.Unmap().WithZone("")
+}
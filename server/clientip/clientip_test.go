@@ -0,0 +1,54 @@
+package clientip
+
+import (
+	"net/http"
+	"testing"
+)
+
+func Test_ClientIP(t *testing.T) {
+	resolver, err := New([]string{"10.0.0.0/8", "127.0.0.1", "2001:db8:ffff::/48"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer cannot forge xff", "203.0.113.7:5000", map[string][]string{"X-Forwarded-For": {"1.1.1.1"}}, "203.0.113.7"},
+		{"single proxy", "10.0.0.2:80", map[string][]string{"X-Forwarded-For": {"198.51.100.4"}}, "198.51.100.4"},
+		{"spoofed left entries ignored", "10.0.0.2:80", map[string][]string{"X-Forwarded-For": {"6.6.6.6, 198.51.100.4, 10.0.0.9"}}, "198.51.100.4"},
+		{"repeated xff headers", "10.0.0.2:80", map[string][]string{"X-Forwarded-For": {"6.6.6.6", "198.51.100.4"}}, "198.51.100.4"},
+		{"garbage stops walk", "10.0.0.2:80", map[string][]string{"X-Forwarded-For": {"198.51.100.4, nonsense, 10.0.0.9"}}, "10.0.0.9"},
+		{"all trusted", "10.0.0.2:80", map[string][]string{"X-Forwarded-For": {"10.1.1.1, 10.0.0.9"}}, "10.1.1.1"},
+		{"mapped ipv4", "[::ffff:10.0.0.2]:80", map[string][]string{"X-Forwarded-For": {"198.51.100.4"}}, "198.51.100.4"},
+		{"real ip", "127.0.0.1:80", map[string][]string{"X-Real-Ip": {"198.51.100.4"}}, "198.51.100.4"},
+		{"forwarded", "10.0.0.2:80", map[string][]string{"Forwarded": {`for=6.6.6.6, for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.9`}}, "2001:db8:cafe::17"},
+		{"forwarded wins over xff", "10.0.0.2:80", map[string][]string{"Forwarded": {"for=198.51.100.4"}, "X-Forwarded-For": {"6.6.6.6"}}, "198.51.100.4"},
+		{"forwarded unknown", "10.0.0.2:80", map[string][]string{"Forwarded": {"for=unknown, for=10.0.0.9"}}, "10.0.0.9"},
+		{"forwarded ipv4 with port", "[2001:db8:ffff::1]:443", map[string][]string{"Forwarded": {`for="198.51.100.4:1234"`}}, "198.51.100.4"},
+		{"bad remote", "pipe", nil, ""},
+	}
+
+	for _, tc := range cases {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tc.remote
+		for k, values := range tc.headers {
+			for _, v := range values {
+				req.Header.Add(k, v)
+			}
+		}
+		if got := resolver.ClientIP(req); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func Test_NewInvalid(t *testing.T) {
+	if _, err := New([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("expected error")
+	}
+}
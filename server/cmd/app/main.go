@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"server/actions"
+	_ "server/cleanup" // registers the periodic retention job
+	"server/jobs"
+	"server/models"
 )
 
 // main is the starting point for your Buffalo application.
@@ -13,6 +17,12 @@ import (
 // call `app.Serve()`, unless you don't want to start your
 // application that is. :)
 func main() {
+	worker := jobs.NewWorker(models.DB)
+	if err := worker.Start(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	defer worker.Stop()
+
 	app := actions.App()
 	if err := app.Serve(); err != nil {
 		log.Fatal(err)
@@ -0,0 +1,232 @@
+// Package encryption seals values stored at rest with envelope encryption:
+// every value gets its own random data key (AES-256-GCM) and that data key
+// is wrapped with a versioned key encryption key from config. Rotating the
+// key encryption key only re-wraps data keys; the sealed payload is kept.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Prefix marks an encrypted value. Values without it are legacy plaintext,
+// read as is until the rotation command seals them.
+const Prefix = "enc:v1:"
+
+var ErrDecrypt = errors.New("encryption: cannot decrypt value")
+
+// Keyring holds the key encryption keys by id. New values are sealed with
+// the current key; the others only open values sealed before a rotation.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// New parses a comma separated list of kid=base64 pairs, each key 32 bytes,
+// e.g. "2026-01=q83v...,2025-07=3q2+...". currentID selects the key that
+// seals new values, the first one when empty.
+func New(spec, currentID string) (*Keyring, error) {
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+
+	var first string
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, encoded, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || encoded == "" || strings.Contains(kid, ":") {
+			return nil, fmt.Errorf("invalid ENCRYPTION_KEYS entry for %q, expected kid=base64", kid)
+		}
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %s is not valid base64", kid)
+		}
+		if _, exists := k.keys[kid]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %s", kid)
+		}
+		if err := k.add(kid, raw); err != nil {
+			return nil, err
+		}
+		if first == "" {
+			first = kid
+		}
+	}
+
+	if first == "" {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+	if currentID == "" {
+		currentID = first
+	}
+	if _, ok := k.keys[currentID]; !ok {
+		return nil, fmt.Errorf("current encryption key %s not found", currentID)
+	}
+	k.current = currentID
+
+	return k, nil
+}
+
+func (k *Keyring) add(kid string, key []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("encryption key %s must be 32 bytes, got %d", kid, len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	k.keys[kid] = aead
+	return nil
+}
+
+// Current is the id of the key that seals new values.
+func (k *Keyring) Current() string { return k.current }
+
+// Encrypt seals plaintext as enc:v1:<kid>:<wrapped data key>:<payload>.
+func (k *Keyring) Encrypt(plaintext []byte) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return "", err
+	}
+	payload, err := seal(aead, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	wrapped, err := k.wrap(k.current, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	return Prefix + k.current + ":" + wrapped + ":" + base64.RawStdEncoding.EncodeToString(payload), nil
+}
+
+// Decrypt opens a sealed value. Plaintext values are returned unchanged.
+func (k *Keyring) Decrypt(value string) ([]byte, error) {
+	if !IsEncrypted(value) {
+		return []byte(value), nil
+	}
+
+	_, dataKey, payload, err := k.open(value)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := unseal(aead, payload, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Rotate re-wraps the data key of a sealed value with the current key, or
+// seals a plaintext value. It reports whether the value changed.
+func (k *Keyring) Rotate(value string) (string, bool, error) {
+	if !IsEncrypted(value) {
+		sealed, err := k.Encrypt([]byte(value))
+		return sealed, err == nil, err
+	}
+
+	kid, dataKey, payload, err := k.open(value)
+	if err != nil {
+		return value, false, err
+	}
+	if kid == k.current {
+		return value, false, nil
+	}
+
+	wrapped, err := k.wrap(k.current, dataKey)
+	if err != nil {
+		return value, false, err
+	}
+	return Prefix + k.current + ":" + wrapped + ":" + base64.RawStdEncoding.EncodeToString(payload), true, nil
+}
+
+// IsEncrypted reports whether value was produced by Encrypt.
+func IsEncrypted(value string) bool { return strings.HasPrefix(value, Prefix) }
+
+// GenerateKey returns a random key in the format New expects.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// the key id is authenticated with the data key so a wrapped key can't be
+// relabelled to another key
+func (k *Keyring) wrap(kid string, dataKey []byte) (string, error) {
+	wrapped, err := seal(k.keys[kid], dataKey, []byte(kid))
+	if err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(wrapped), nil
+}
+
+func (k *Keyring) open(value string) (kid string, dataKey, payload []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrDecrypt
+	}
+	kid = parts[0]
+
+	kek, ok := k.keys[kid]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("%w: unknown key %s", ErrDecrypt, kid)
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrDecrypt
+	}
+	payload, err = base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrDecrypt
+	}
+
+	dataKey, err = unseal(kek, wrapped, []byte(kid))
+	if err != nil {
+		return "", nil, nil, ErrDecrypt
+	}
+	return kid, dataKey, payload, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal prefixes the ciphertext with a random nonce.
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func unseal(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
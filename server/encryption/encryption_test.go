@@ -0,0 +1,106 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, spec, current string) *Keyring {
+	t.Helper()
+	k, err := New(spec, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+const (
+	key1 = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	key2 = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func Test_EncryptDecrypt(t *testing.T) {
+	k := testKeyring(t, "2026-01="+key1, "")
+
+	sealed, err := k.Encrypt([]byte("JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sealed, Prefix+"2026-01:") || strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("Encrypt() = %q", sealed)
+	}
+
+	again, _ := k.Encrypt([]byte("JBSWY3DPEHPK3PXP"))
+	if again == sealed {
+		t.Fatal("Encrypt() is deterministic")
+	}
+
+	plain, err := k.Decrypt(sealed)
+	if err != nil || string(plain) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Decrypt() = %q, %v", plain, err)
+	}
+
+	// legacy plaintext passes through
+	plain, err = k.Decrypt("JBSWY3DPEHPK3PXP")
+	if err != nil || string(plain) != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Decrypt(plaintext) = %q, %v", plain, err)
+	}
+}
+
+func Test_DecryptTampered(t *testing.T) {
+	k := testKeyring(t, "2026-01="+key1+",2025-07="+key2, "")
+	sealed, _ := k.Encrypt([]byte("secret"))
+
+	parts := strings.Split(strings.TrimPrefix(sealed, Prefix), ":")
+	cases := map[string]string{
+		"relabelled key": Prefix + "2025-07:" + parts[1] + ":" + parts[2],
+		"unknown key":    Prefix + "1999-01:" + parts[1] + ":" + parts[2],
+		"payload":        Prefix + parts[0] + ":" + parts[1] + ":" + parts[2][:len(parts[2])-2] + "AA",
+		"truncated":      Prefix + parts[0] + ":" + parts[1],
+	}
+	for name, value := range cases {
+		if _, err := k.Decrypt(value); err == nil {
+			t.Errorf("%s: Decrypt() succeeded", name)
+		}
+	}
+}
+
+func Test_Rotate(t *testing.T) {
+	old := testKeyring(t, "2025-07="+key2, "")
+	sealed, _ := old.Encrypt([]byte("refresh-token"))
+
+	k := testKeyring(t, "2025-07="+key2+",2026-01="+key1, "2026-01")
+
+	rotated, changed, err := k.Rotate(sealed)
+	if err != nil || !changed || !strings.HasPrefix(rotated, Prefix+"2026-01:") {
+		t.Fatalf("Rotate() = %q, %v, %v", rotated, changed, err)
+	}
+	if plain, err := k.Decrypt(rotated); err != nil || string(plain) != "refresh-token" {
+		t.Fatalf("Decrypt(rotated) = %q, %v", plain, err)
+	}
+
+	if _, changed, _ := k.Rotate(rotated); changed {
+		t.Error("Rotate() changed a value under the current key")
+	}
+
+	sealed, changed, err = k.Rotate("plaintext")
+	if err != nil || !changed || !IsEncrypted(sealed) {
+		t.Fatalf("Rotate(plaintext) = %q, %v, %v", sealed, changed, err)
+	}
+
+	// once rotated the old key can be dropped
+	if _, err := testKeyring(t, "2026-01="+key1, "").Decrypt(rotated); err != nil {
+		t.Fatalf("Decrypt() after removing old key = %v", err)
+	}
+}
+
+func Test_New(t *testing.T) {
+	for _, spec := range []string{"", "a", "a=notbase64!", "a=c2hvcnQ=", "a=" + key1 + ",a=" + key2, "a:b=" + key1} {
+		if _, err := New(spec, ""); err == nil {
+			t.Errorf("New(%q) succeeded", spec)
+		}
+	}
+	if _, err := New("a="+key1, "b"); err == nil {
+		t.Error("New() accepted an unknown current key")
+	}
+}
@@ -0,0 +1,123 @@
+// Package geoip resolves client IPs to a country, city and network owner
+// with MaxMind-format databases mounted on the server. Nothing leaves the
+// server, and without databases every lookup is empty.
+package geoip
+
+import (
+	"log"
+	"net/netip"
+
+	"github.com/gobuffalo/envy"
+)
+
+// Location is what the databases know about an address. Fields the
+// databases don't have stay empty.
+type Location struct {
+	CountryCode string   `json:"country_code,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	City        string   `json:"city,omitempty"`
+	Latitude    *float64 `json:"latitude,omitempty"`
+	Longitude   *float64 `json:"longitude,omitempty"`
+	ASN         uint     `json:"asn,omitempty"`
+	ASOrg       string   `json:"as_org,omitempty"`
+}
+
+// Located tells whether the location has coordinates.
+func (l Location) Located() bool {
+	return l.Latitude != nil && l.Longitude != nil
+}
+
+// Databases are the readers lookups use; nil ones are skipped.
+type Databases struct {
+	// City is a GeoLite2/GeoIP2 City or Country database.
+	City *Reader
+	// ASN is a GeoLite2/GeoIP2 ASN database.
+	ASN *Reader
+	// Language picks the names of countries and cities, falling back to
+	// English.
+	Language string
+}
+
+// Default is loaded from GEOIP_CITY_DB and GEOIP_ASN_DB, paths to .mmdb
+// files, with names in GEOIP_LANGUAGE (default "es"). A configured file
+// that can't be read stops the server.
+var Default = Databases{
+	City:     openEnv("GEOIP_CITY_DB"),
+	ASN:      openEnv("GEOIP_ASN_DB"),
+	Language: envy.Get("GEOIP_LANGUAGE", "es"),
+}
+
+// Lookup resolves ip with the Default databases.
+func Lookup(ip string) Location {
+	return Default.Lookup(ip)
+}
+
+// Lookup resolves ip, returning an empty Location for addresses that can't
+// be parsed or aren't in the databases.
+func (dbs Databases) Lookup(ip string) Location {
+	var loc Location
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return loc
+	}
+
+	if dbs.City != nil {
+		if record, err := dbs.City.Lookup(addr); err == nil {
+			country := field(record, "country")
+			if country == nil {
+				country = field(record, "registered_country")
+			}
+			loc.CountryCode, _ = field(country, "iso_code").(string)
+			loc.Country = dbs.name(field(country, "names"))
+			loc.City = dbs.name(field(field(record, "city"), "names"))
+
+			location := field(record, "location")
+			if lat, ok := field(location, "latitude").(float64); ok {
+				if lon, ok := field(location, "longitude").(float64); ok {
+					loc.Latitude, loc.Longitude = &lat, &lon
+				}
+			}
+		}
+	}
+
+	if dbs.ASN != nil {
+		if record, err := dbs.ASN.Lookup(addr); err == nil {
+			if asn, ok := field(record, "autonomous_system_number").(uint64); ok {
+				loc.ASN = uint(asn)
+			}
+			loc.ASOrg, _ = field(record, "autonomous_system_organization").(string)
+		}
+	}
+
+	return loc
+}
+
+func (dbs Databases) name(names any) string {
+	if name, ok := field(names, dbs.Language).(string); ok {
+		return name
+	}
+	name, _ := field(names, "en").(string)
+	return name
+}
+
+// field reads key from a decoded map, nil when value isn't a map or lacks
+// it.
+func field(value any, key string) any {
+	m, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return m[key]
+}
+
+func openEnv(key string) *Reader {
+	path := envy.Get(key, "")
+	if path == "" {
+		return nil
+	}
+	r, err := Open(path)
+	if err != nil {
+		log.Fatalf("[ERROR] %s %s: %v", key, path, err)
+	}
+	return r
+}
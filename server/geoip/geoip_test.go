@@ -0,0 +1,196 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+// -- a minimal .mmdb writer for the tests
+
+type mmdbWriter struct {
+	data bytes.Buffer
+	// nodes[i] holds the left and right records; -1 is empty, values
+	// below -1 are -(data offset)-2
+	nodes [][2]int
+}
+
+func (w *mmdbWriter) encode(v any) {
+	switch v := v.(type) {
+	case string:
+		w.control(typeString, len(v))
+		w.data.WriteString(v)
+	case float64:
+		w.control(typeDouble, 8)
+		binary.Write(&w.data, binary.BigEndian, math.Float64bits(v))
+	case uint32:
+		w.control(typeUint32, 4)
+		binary.Write(&w.data, binary.BigEndian, v)
+	case uint16:
+		w.control(typeUint16, 2)
+		binary.Write(&w.data, binary.BigEndian, v)
+	case pointerTo:
+		w.data.WriteByte(typePointer<<5 | byte(v>>8)&0x7)
+		w.data.WriteByte(byte(v))
+	case map[string]any:
+		w.control(typeMap, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			w.encode(k)
+			w.encode(v[k])
+		}
+	}
+}
+
+// pointerTo is a pointer of 11 bits to a data offset.
+type pointerTo uint16
+
+func (w *mmdbWriter) control(kind, size int) {
+	if kind > 7 {
+		w.data.WriteByte(byte(size))
+		w.data.WriteByte(byte(kind - 7))
+		return
+	}
+	w.data.WriteByte(byte(kind<<5 | size))
+}
+
+// insert points the network prefix of an IPv6 tree at a data offset.
+func (w *mmdbWriter) insert(prefix netip.Prefix, offset int) {
+	if len(w.nodes) == 0 {
+		w.nodes = append(w.nodes, [2]int{-1, -1})
+	}
+	ip := prefix.Addr().As16()
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		bits += 96
+		ip = netip.AddrFrom4(prefix.Addr().As4()).As16()
+		copy(ip[:12], make([]byte, 12))
+	}
+	node := 0
+	for i := 0; i < bits; i++ {
+		bit := (ip[i/8] >> (7 - uint(i%8))) & 1
+		if i == bits-1 {
+			w.nodes[node][bit] = -offset - 2
+			return
+		}
+		next := w.nodes[node][bit]
+		if next < 0 {
+			w.nodes = append(w.nodes, [2]int{-1, -1})
+			next = len(w.nodes) - 1
+			w.nodes[node][bit] = next
+		}
+		node = next
+	}
+}
+
+func (w *mmdbWriter) bytes(recordSize int) []byte {
+	n := len(w.nodes)
+	value := func(r int) uint32 {
+		switch {
+		case r == -1:
+			return uint32(n)
+		case r < -1:
+			return uint32(n + dataSectionSeparator + (-r - 2))
+		default:
+			return uint32(r)
+		}
+	}
+
+	var out bytes.Buffer
+	for _, node := range w.nodes {
+		l, r := value(node[0]), value(node[1])
+		switch recordSize {
+		case 24:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(l>>20)&0xf0 | byte(r>>24)&0x0f, byte(r >> 16), byte(r >> 8), byte(r)})
+		case 32:
+			binary.Write(&out, binary.BigEndian, l)
+			binary.Write(&out, binary.BigEndian, r)
+		}
+	}
+	out.Write(make([]byte, dataSectionSeparator))
+	out.Write(w.data.Bytes())
+	out.Write(metadataMarker)
+
+	meta := mmdbWriter{}
+	meta.encode(map[string]any{
+		"node_count":    uint32(n),
+		"record_size":   uint16(recordSize),
+		"ip_version":    uint16(6),
+		"database_type": "Test-City",
+	})
+	out.Write(meta.data.Bytes())
+	return out.Bytes()
+}
+
+func testCityDB(t *testing.T, recordSize int) *Reader {
+	t.Helper()
+	w := &mmdbWriter{}
+
+	peru := w.data.Len()
+	w.encode(map[string]any{"iso_code": "PE", "names": map[string]any{"en": "Peru", "es": "Perú"}})
+
+	lima := w.data.Len()
+	w.encode(map[string]any{
+		"city":     map[string]any{"names": map[string]any{"en": "Lima"}},
+		"country":  pointerTo(peru),
+		"location": map[string]any{"latitude": -12.0432, "longitude": -77.0282},
+	})
+
+	madrid := w.data.Len()
+	w.encode(map[string]any{
+		"city":     map[string]any{"names": map[string]any{"en": "Madrid"}},
+		"country":  map[string]any{"iso_code": "ES", "names": map[string]any{"en": "Spain", "es": "España"}},
+		"location": map[string]any{"latitude": 40.4165, "longitude": -3.7026},
+	})
+
+	w.insert(netip.MustParsePrefix("190.42.0.0/16"), lima)
+	w.insert(netip.MustParsePrefix("2a02:9000::/23"), madrid)
+
+	r, err := NewReader(w.bytes(recordSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func Test_Lookup(t *testing.T) {
+	for _, size := range []int{24, 28, 32} {
+		dbs := Databases{City: testCityDB(t, size), Language: "es"}
+
+		lima := dbs.Lookup("190.42.17.8")
+		if lima.CountryCode != "PE" || lima.Country != "Perú" || lima.City != "Lima" || !lima.Located() || *lima.Latitude != -12.0432 {
+			t.Errorf("record size %d: unexpected location %+v", size, lima)
+		}
+
+		// IPv4-mapped addresses resolve like IPv4
+		if mapped := dbs.Lookup("::ffff:190.42.200.1"); mapped.City != "Lima" {
+			t.Errorf("record size %d: unexpected mapped location %+v", size, mapped)
+		}
+
+		madrid := dbs.Lookup("2a02:9010::1")
+		if madrid.CountryCode != "ES" || madrid.Country != "España" || madrid.City != "Madrid" {
+			t.Errorf("record size %d: unexpected location %+v", size, madrid)
+		}
+
+		for _, ip := range []string{"8.8.8.8", "2001:db8::1", "not an ip", ""} {
+			if loc := dbs.Lookup(ip); loc != (Location{}) {
+				t.Errorf("record size %d: %q: expected no location, got %+v", size, ip, loc)
+			}
+		}
+	}
+}
+
+func Test_NewReader_Invalid(t *testing.T) {
+	if _, err := NewReader([]byte("not a database")); err == nil {
+		t.Error("expected an error without metadata")
+	}
+}
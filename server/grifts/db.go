@@ -1,6 +1,13 @@
 package grifts
 
 import (
+	"fmt"
+	"time"
+
+	"server/cleanup"
+	"server/models"
+	"server/privacy"
+
 	"github.com/gobuffalo/grift/grift"
 )
 
@@ -12,4 +19,47 @@ var _ = grift.Namespace("db", func() {
 		return nil
 	})
 
+	grift.Desc("cleanup", "Deletes rows past their retention. Optional args: policy names (sessions, verification_tokens, oauth_states, webauthn_challenges, two_factor_setups, account_locks, login_attempts, rate_limits, dead_letters)")
+	grift.Add("cleanup", func(c *grift.Context) error {
+		policies, err := cleanup.Find(c.Args...)
+		if err != nil {
+			return err
+		}
+
+		deleted, err := cleanup.Run(models.DB, policies)
+		for _, p := range policies {
+			fmt.Printf("%-20s retention %-8s deleted %d\n", p.Name, p.Retention, deleted[p.Name])
+		}
+		return err
+	})
+
+	grift.Desc("cleanup:policies", "Lists the retention policies and how many rows each would delete now")
+	grift.Add("cleanup:policies", func(c *grift.Context) error {
+		for _, p := range cleanup.Policies {
+			count, err := p.Count(models.DB, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%-20s %-24s retention %-8s pending %d\n", p.Name, p.Table, p.Retention, count)
+		}
+		return nil
+	})
+
+	grift.Desc("rotate-encryption-keys", "Re-encrypts 2FA secrets and OAuth tokens with ENCRYPTION_KEY_ID, sealing values still in plaintext")
+	grift.Add("rotate-encryption-keys", func(c *grift.Context) error {
+		rotated, err := models.RotateEncryption(models.DB)
+		for _, col := range models.EncryptedColumns {
+			name := col.Table + "." + col.Column
+			fmt.Printf("%-32s rotated %d\n", name, rotated[name])
+		}
+		return err
+	})
+
+	grift.Desc("delete-accounts", "Deletes the accounts whose deletion grace period is over, anonymising their login attempts")
+	grift.Add("delete-accounts", func(c *grift.Context) error {
+		deleted, err := privacy.DeleteDue(models.DB, time.Now().UTC())
+		fmt.Printf("deleted %d accounts\n", deleted)
+		return err
+	})
+
 })
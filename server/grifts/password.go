@@ -0,0 +1,81 @@
+package grifts
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"server/passwordpolicy"
+
+	"github.com/gobuffalo/grift/grift"
+)
+
+var _ = grift.Namespace("password", func() {
+
+	grift.Desc("breached-filter", "Builds the BREACHED_PASSWORDS_FILE bloom filter from a Have I Been Pwned SHA-1 dump. Args: <dump> <output> [false positive rate, default 0.001]")
+	grift.Add("breached-filter", func(c *grift.Context) error {
+		if len(c.Args) < 2 {
+			return errors.New("usage: password:breached-filter <dump> <output> [false positive rate]")
+		}
+		fpRate := 0.001
+		if len(c.Args) > 2 {
+			rate, err := strconv.ParseFloat(c.Args[2], 64)
+			if err != nil || rate <= 0 || rate >= 1 {
+				return fmt.Errorf("invalid false positive rate %q", c.Args[2])
+			}
+			fpRate = rate
+		}
+
+		// the filter is sized before reading the dump a second time
+		lines, err := countLines(c.Args[0])
+		if err != nil {
+			return err
+		}
+
+		dump, err := os.Open(c.Args[0])
+		if err != nil {
+			return err
+		}
+		defer dump.Close()
+
+		filter := passwordpolicy.NewBloom(lines, fpRate)
+		added, err := filter.AddHIBP(dump)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.Create(c.Args[1])
+		if err != nil {
+			return err
+		}
+		size, err := filter.WriteTo(out)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		fmt.Printf("added %d hashes, wrote %d bytes to %s\n", added, size, c.Args[1])
+		return nil
+	})
+
+})
+
+func countLines(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var lines uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines++
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,118 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"server/models"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const DefaultQueue = "default"
+
+var (
+	// MaxAttempts is how many times a job runs before it is dead-lettered.
+	MaxAttempts = envInt("JOBS_MAX_ATTEMPTS", 8)
+
+	// BaseBackoff is the delay before the first retry. It doubles on every
+	// failed attempt up to MaxBackoff.
+	BaseBackoff = envDuration("JOBS_BASE_BACKOFF", 10*time.Second)
+	MaxBackoff  = envDuration("JOBS_MAX_BACKOFF", 1*time.Hour)
+)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]worker.Handler{}
+)
+
+// Register makes h available to the worker under name. Packages that own a
+// job register it from init().
+func Register(name string, h worker.Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	if _, exists := handlers[name]; exists {
+		panic(fmt.Sprintf("jobs: handler %q already registered", name))
+	}
+	handlers[name] = h
+}
+
+func handler(name string) (worker.Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[name]
+	return h, ok
+}
+
+// Enqueue stores job on tx so it only becomes visible to the worker when
+// the surrounding transaction commits.
+func Enqueue(tx *pop.Connection, job worker.Job) error {
+	return EnqueueAt(tx, job, time.Now().UTC())
+}
+
+func EnqueueIn(tx *pop.Connection, job worker.Job, d time.Duration) error {
+	return EnqueueAt(tx, job, time.Now().UTC().Add(d))
+}
+
+func EnqueueAt(tx *pop.Connection, job worker.Job, runAt time.Time) error {
+	if _, ok := handler(job.Handler); !ok {
+		return fmt.Errorf("jobs: no handler registered for %q", job.Handler)
+	}
+
+	args := job.Args
+	if args == nil {
+		args = worker.Args{}
+	}
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("jobs: encode args for %q: %w", job.Handler, err)
+	}
+
+	queue := job.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+
+	now := time.Now().UTC()
+	return tx.Create(&models.Job{
+		Queue:       queue,
+		Handler:     job.Handler,
+		Args:        payload,
+		Status:      "pending",
+		MaxAttempts: MaxAttempts,
+		RunAt:       runAt.UTC(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+// backoff returns the delay before the next run of a job that has failed
+// attempts times.
+func backoff(attempts int) time.Duration {
+	d := BaseBackoff
+	for i := 1; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+	return d
+}
+
+func envInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(envy.Get(key, "")); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	if v, err := time.ParseDuration(envy.Get(key, "")); err == nil && v > 0 {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Backoff(t *testing.T) {
+	BaseBackoff, MaxBackoff = 10*time.Second, time.Minute
+
+	cases := map[int]time.Duration{
+		1: 10 * time.Second,
+		2: 20 * time.Second,
+		3: 40 * time.Second,
+		4: time.Minute,
+		9: time.Minute,
+	}
+	for attempts, want := range cases {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/pop/v6"
+)
+
+type periodicJob struct {
+	every time.Duration
+	job   worker.Job
+}
+
+var schedule []periodicJob
+
+// Every makes running workers enqueue job once per interval. Register
+// periodic jobs from init(), before the worker starts.
+func Every(interval time.Duration, job worker.Job) {
+	schedule = append(schedule, periodicJob{every: interval, job: job})
+}
+
+// EnqueueUnique enqueues job unless one with the same handler is already
+// pending or running. It reports whether a job was added.
+func EnqueueUnique(db *pop.Connection, job worker.Job) (bool, error) {
+	added := false
+	err := db.Transaction(func(tx *pop.Connection) error {
+		// serialises workers enqueueing the same handler
+		if err := tx.RawQuery("SELECT pg_advisory_xact_lock(hashtext(?))", job.Handler).Exec(); err != nil {
+			return err
+		}
+
+		var count int
+		if err := tx.RawQuery("SELECT COUNT(*) FROM jobs.queue WHERE handler = ?", job.Handler).First(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := Enqueue(tx, job); err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+func (w *Worker) scheduleLoop(ctx context.Context, p periodicJob) {
+	defer w.wg.Done()
+
+	for {
+		if _, err := EnqueueUnique(w.db, p.job); err != nil {
+			log.Printf("[JOBS] schedule %s: %v", p.job.Handler, err)
+		}
+		if !sleep(ctx, p.every) {
+			return
+		}
+	}
+}
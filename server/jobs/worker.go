@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"server/models"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/pop/v6"
+)
+
+var (
+	// Concurrency is how many jobs a worker runs at the same time.
+	Concurrency = envInt("JOBS_CONCURRENCY", 2)
+
+	// PollInterval is how long an idle worker waits before looking for new jobs.
+	PollInterval = envDuration("JOBS_POLL_INTERVAL", 1*time.Second)
+
+	// LockTimeout is how long a job can stay running before another worker
+	// assumes its owner died and runs it again.
+	LockTimeout = envDuration("JOBS_LOCK_TIMEOUT", 5*time.Minute)
+)
+
+// Worker runs jobs stored in jobs.queue. Several workers, in one process or
+// many, can share the queue; rows are claimed with FOR UPDATE SKIP LOCKED.
+type Worker struct {
+	db *pop.Connection
+	id string
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorker(db *pop.Connection) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		db: db,
+		id: fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// Start launches the worker goroutines and returns immediately.
+func (w *Worker) Start(ctx context.Context) error {
+	ctx, w.cancel = context.WithCancel(ctx)
+
+	for i := 0; i < Concurrency; i++ {
+		w.wg.Add(1)
+		go w.loop(ctx)
+	}
+
+	w.wg.Add(1)
+	go w.reapLoop(ctx)
+
+	for _, p := range schedule {
+		w.wg.Add(1)
+		go w.scheduleLoop(ctx, p)
+	}
+
+	log.Printf("[JOBS] worker %s started with %d goroutines", w.id, Concurrency)
+	return nil
+}
+
+// Stop waits for running jobs to finish.
+func (w *Worker) Stop() error {
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.wg.Wait()
+	log.Printf("[JOBS] worker %s stopped", w.id)
+	return nil
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	defer w.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := w.claim()
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("[JOBS] claim: %v", err)
+			}
+			if !sleep(ctx, PollInterval) {
+				return
+			}
+			continue
+		}
+
+		w.run(job)
+	}
+}
+
+// claim locks the next due job and marks it running.
+func (w *Worker) claim() (models.Job, error) {
+	now := time.Now().UTC()
+
+	var job models.Job
+	err := w.db.RawQuery(`
+		UPDATE jobs.queue
+		SET status = 'running', attempts = attempts + 1, locked_at = ?, locked_by = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs.queue
+			WHERE status = 'pending' AND run_at <= ?
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *
+	`, now, w.id, now, now).First(&job)
+	return job, err
+}
+
+func (w *Worker) run(job models.Job) {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		// the worker running the last attempt died, see reapLoop
+		err = fmt.Errorf("lost while running, last error: %s", stringValue(job.LastError))
+	} else {
+		err = perform(job)
+	}
+	if err == nil {
+		if err := w.db.RawQuery("DELETE FROM jobs.queue WHERE id = ?", job.ID).Exec(); err != nil {
+			log.Printf("[JOBS] delete finished job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		log.Printf("[JOBS] %s (%s) dead-lettered after %d attempts: %v", job.Handler, job.ID, job.Attempts, err)
+		if err := w.deadLetter(job, err); err != nil {
+			log.Printf("[JOBS] dead-letter job %s: %v", job.ID, err)
+		}
+		return
+	}
+
+	delay := backoff(job.Attempts)
+	log.Printf("[JOBS] %s (%s) attempt %d failed, retrying in %s: %v", job.Handler, job.ID, job.Attempts, delay, err)
+
+	now := time.Now().UTC()
+	retryErr := w.db.RawQuery(`
+		UPDATE jobs.queue
+		SET status = 'pending', run_at = ?, last_error = ?, locked_at = NULL, locked_by = NULL, updated_at = ?
+		WHERE id = ?
+	`, now.Add(delay), err.Error(), now, job.ID).Exec()
+	if retryErr != nil {
+		log.Printf("[JOBS] reschedule job %s: %v", job.ID, retryErr)
+	}
+}
+
+func perform(job models.Job) (err error) {
+	h, ok := handler(job.Handler)
+	if !ok {
+		return fmt.Errorf("no handler registered for %q", job.Handler)
+	}
+
+	args := worker.Args{}
+	if err := json.Unmarshal(job.Args, &args); err != nil {
+		return fmt.Errorf("decode args: %w", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return h(args)
+}
+
+func (w *Worker) deadLetter(job models.Job, cause error) error {
+	return w.db.Transaction(func(tx *pop.Connection) error {
+		dead := models.DeadLetter{
+			ID:        job.ID,
+			Queue:     job.Queue,
+			Handler:   job.Handler,
+			Args:      job.Args,
+			Attempts:  job.Attempts,
+			LastError: cause.Error(),
+			CreatedAt: job.CreatedAt,
+			FailedAt:  time.Now().UTC(),
+		}
+		if err := tx.Create(&dead); err != nil {
+			return err
+		}
+		return tx.RawQuery("DELETE FROM jobs.queue WHERE id = ?", job.ID).Exec()
+	})
+}
+
+// reapLoop puts back jobs whose worker died while running them.
+func (w *Worker) reapLoop(ctx context.Context) {
+	defer w.wg.Done()
+
+	for sleep(ctx, LockTimeout/2) {
+		now := time.Now().UTC()
+		err := w.db.RawQuery(`
+			UPDATE jobs.queue
+			SET status = 'pending', run_at = ?, locked_at = NULL, locked_by = NULL, updated_at = ?
+			WHERE status = 'running' AND locked_at < ?
+		`, now, now, now.Add(-LockTimeout)).Exec()
+		if err != nil {
+			log.Printf("[JOBS] reap stale jobs: %v", err)
+		}
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// sleep waits for d and reports false if ctx was cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,224 @@
+// Package loginrisk scores a successful login against the user's previous
+// ones: the device, the IP network, the place and the time of day it comes
+// from, and the failed attempts that preceded it. Unseen devices are
+// reported so the user can be notified, and high scores ask for a step-up
+// verification before tokens are issued.
+package loginrisk
+
+import (
+	"log"
+	"math"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobuffalo/envy"
+)
+
+// Login is where and when a login came from. Latitude and Longitude are
+// only set when Located, from a GeoIP lookup of the IP.
+type Login struct {
+	IPAddress string
+	UserAgent string
+	At        time.Time
+
+	Located   bool
+	Latitude  float64
+	Longitude float64
+}
+
+// Assessment is the result of scoring a login.
+type Assessment struct {
+	// Score is 0 (nothing unusual) to 100.
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+
+	// NewDevice is set when the user logged in before, but never from
+	// this device.
+	NewDevice bool `json:"new_device"`
+}
+
+// StepUp tells whether the login needs a step-up verification.
+func (a Assessment) StepUp() bool {
+	return StepUpScore > 0 && a.Score >= StepUpScore
+}
+
+// Reasons a login scores.
+const (
+	NewDevice        = "new_device"
+	NewNetwork       = "new_network"
+	UnusualTime      = "unusual_time"
+	FailedAttempts   = "failed_attempts"
+	ImpossibleTravel = "impossible_travel"
+)
+
+// Weights of each reason. A new device on a new network, or an impossible
+// travel, reach the default StepUpScore on their own.
+var weights = map[string]int{
+	NewDevice:        35,
+	NewNetwork:       30,
+	UnusualTime:      15,
+	FailedAttempts:   20,
+	ImpossibleTravel: 60,
+}
+
+// StepUpScore is the score from which a login needs a step-up verification,
+// configured with LOGIN_RISK_STEP_UP_SCORE (0 disables it).
+var StepUpScore = envScore("LOGIN_RISK_STEP_UP_SCORE", 60)
+
+const (
+	// minTimeHistory is how many previous logins are needed before the
+	// time of day is scored.
+	minTimeHistory = 5
+
+	// timeWindow is how many hours either way of a previous login count
+	// as a usual time.
+	timeWindow = 2
+
+	// minFailedAttempts is how many recent failures make a login suspicious.
+	minFailedAttempts = 3
+
+	// maxTravelSpeed is the fastest a user can move between two logins, in
+	// km/h: about an airliner.
+	maxTravelSpeed = 1000
+
+	// minTravelDistance ignores shorter distances in km, which GeoIP
+	// databases aren't accurate enough for.
+	minTravelDistance = 500
+)
+
+// Assess scores current against history, the previous logins of the user.
+// recentFailures is how many failed attempts the account had recently. A
+// user without history has nothing to compare with and only scores the
+// failed attempts.
+func Assess(current Login, history []Login, recentFailures int) Assessment {
+	a := Assessment{Reasons: []string{}}
+
+	if len(history) > 0 {
+		device := DeviceKey(current.UserAgent)
+		network := NetworkKey(current.IPAddress)
+
+		knownDevice, knownNetwork, usualTime := false, network == "", false
+		hour := current.At.UTC().Hour()
+		for _, h := range history {
+			if DeviceKey(h.UserAgent) == device {
+				knownDevice = true
+			}
+			if network != "" && NetworkKey(h.IPAddress) == network {
+				knownNetwork = true
+			}
+			if hourDistance(h.At.UTC().Hour(), hour) <= timeWindow {
+				usualTime = true
+			}
+		}
+
+		if !knownDevice {
+			a.NewDevice = true
+			a.add(NewDevice)
+		}
+		if !knownNetwork {
+			a.add(NewNetwork)
+		}
+		if !usualTime && len(history) >= minTimeHistory {
+			a.add(UnusualTime)
+		}
+		if impossibleTravel(current, history) {
+			a.add(ImpossibleTravel)
+		}
+	}
+
+	if recentFailures >= minFailedAttempts {
+		a.add(FailedAttempts)
+	}
+
+	if a.Score > 100 {
+		a.Score = 100
+	}
+	return a
+}
+
+func (a *Assessment) add(reason string) {
+	a.Score += weights[reason]
+	a.Reasons = append(a.Reasons, reason)
+}
+
+var versions = regexp.MustCompile(`[0-9]+([._][0-9]+)*`)
+
+// DeviceKey identifies a device by its user agent without version numbers,
+// so browser and OS updates don't make a known device look new.
+func DeviceKey(userAgent string) string {
+	return strings.Join(strings.Fields(versions.ReplaceAllString(strings.ToLower(userAgent), "")), " ")
+}
+
+// NetworkKey is the /24 (IPv4) or /48 (IPv6) network of an address, or ""
+// when it can't be parsed.
+func NetworkKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ""
+	}
+	addr = addr.Unmap()
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ""
+	}
+	return prefix.String()
+}
+
+// impossibleTravel tells whether getting from the last located login in
+// history to current would have needed more than maxTravelSpeed.
+func impossibleTravel(current Login, history []Login) bool {
+	if !current.Located {
+		return false
+	}
+	var last *Login
+	for i := range history {
+		h := &history[i]
+		if h.Located && !h.At.After(current.At) && (last == nil || h.At.After(last.At)) {
+			last = h
+		}
+	}
+	if last == nil {
+		return false
+	}
+
+	km := Distance(last.Latitude, last.Longitude, current.Latitude, current.Longitude)
+	hours := math.Max(current.At.Sub(last.At).Hours(), 1.0/60)
+	return km >= minTravelDistance && km/hours > maxTravelSpeed
+}
+
+// Distance is the great-circle distance in km between two coordinates.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371
+	rad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
+
+func hourDistance(a, b int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return min(d, 24-d)
+}
+
+func envScore(key string, fallback int) int {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 100 {
+		log.Printf("[WARN] invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
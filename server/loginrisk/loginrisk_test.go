@@ -0,0 +1,102 @@
+package loginrisk
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	chromeMac    = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	chromeMac2   = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.6167.85 Safari/537.36"
+	firefoxLinux = "Mozilla/5.0 (X11; Linux x86_64; rv:122.0) Gecko/20100101 Firefox/122.0"
+)
+
+func login(ip, ua string, at time.Time) Login {
+	return Login{IPAddress: ip, UserAgent: ua, At: at}
+}
+
+func Test_Assess(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2026, 3, 1, hour, 15, 0, 0, time.UTC) }
+
+	var history []Login
+	for day := 0; day < 6; day++ {
+		history = append(history, Login{IPAddress: "190.42.17.8", UserAgent: chromeMac, At: at(9).AddDate(0, 0, -day)})
+	}
+
+	cases := []struct {
+		name     string
+		current  Login
+		history  []Login
+		failures int
+		reasons  string
+		stepUp   bool
+	}{
+		{"first login", login("203.0.113.7", firefoxLinux, at(3)), nil, 0, "", false},
+		{"known device updated", login("190.42.17.200", chromeMac2, at(10)), history, 0, "", false},
+		{"new device", login("190.42.17.8", firefoxLinux, at(8)), history, 0, "new_device", false},
+		{"new network", login("181.65.2.1", chromeMac, at(9)), history, 0, "new_network", false},
+		{"new device and network", login("203.0.113.7", firefoxLinux, at(11)), history, 0, "new_device,new_network", true},
+		{"unusual time", login("190.42.17.8", chromeMac, at(22)), history, 0, "unusual_time", false},
+		{"midnight wraps", login("190.42.17.8", chromeMac, at(0)), []Login{login("190.42.17.8", chromeMac, at(23))}, 0, "", false},
+		{"failed attempts", login("190.42.17.8", chromeMac, at(9)), history, 5, "failed_attempts", false},
+		{"everything", login("2001:db8::1", firefoxLinux, at(3)), history, 3, "new_device,new_network,unusual_time,failed_attempts", true},
+	}
+	for _, tc := range cases {
+		a := Assess(tc.current, tc.history, tc.failures)
+		if got := strings.Join(a.Reasons, ","); got != tc.reasons {
+			t.Errorf("%s: expected [%s], got [%s]", tc.name, tc.reasons, got)
+		}
+		if a.StepUp() != tc.stepUp {
+			t.Errorf("%s: expected step-up %v with score %d", tc.name, tc.stepUp, a.Score)
+		}
+		if a.NewDevice != strings.Contains(tc.reasons, NewDevice) {
+			t.Errorf("%s: unexpected new device %v", tc.name, a.NewDevice)
+		}
+	}
+}
+
+func Test_NetworkKey(t *testing.T) {
+	cases := map[string]string{
+		"190.42.17.8":         "190.42.17.0/24",
+		"::ffff:190.42.17.8":  "190.42.17.0/24",
+		"2001:db8:abcd:12::1": "2001:db8:abcd::/48",
+		"not an ip":           "",
+		"":                    "",
+	}
+	for ip, expected := range cases {
+		if got := NetworkKey(ip); got != expected {
+			t.Errorf("%q: expected %q, got %q", ip, expected, got)
+		}
+	}
+}
+
+func Test_Assess_ImpossibleTravel(t *testing.T) {
+	lima := func(at time.Time) Login {
+		return Login{IPAddress: "190.42.17.8", UserAgent: chromeMac, At: at, Located: true, Latitude: -12.04, Longitude: -77.03}
+	}
+	madrid := func(at time.Time) Login {
+		return Login{IPAddress: "190.42.17.9", UserAgent: chromeMac, At: at, Located: true, Latitude: 40.42, Longitude: -3.70}
+	}
+
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	history := []Login{lima(now.Add(-48 * time.Hour)), lima(now.Add(-2 * time.Hour))}
+
+	// about 9500 km in 2 hours
+	if a := Assess(madrid(now), history, 0); !slices.Contains(a.Reasons, ImpossibleTravel) || !a.StepUp() {
+		t.Errorf("expected impossible travel with step-up, got %+v", a)
+	}
+	// the same trip in a day is a flight
+	if a := Assess(madrid(now.Add(22*time.Hour)), history, 0); slices.Contains(a.Reasons, ImpossibleTravel) {
+		t.Errorf("unexpected impossible travel %+v", a)
+	}
+	// without coordinates nothing is compared
+	if a := Assess(login("190.42.17.8", chromeMac, now), history, 0); slices.Contains(a.Reasons, ImpossibleTravel) {
+		t.Errorf("unexpected impossible travel %+v", a)
+	}
+
+	if km := Distance(-12.04, -77.03, 40.42, -3.70); km < 9400 || km > 9600 {
+		t.Errorf("unexpected distance Lima-Madrid %.0f km", km)
+	}
+}
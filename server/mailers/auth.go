@@ -0,0 +1,140 @@
+package mailers
+
+import (
+	"net/url"
+	"server/models"
+	"strconv"
+	"time"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/pop/v6"
+)
+
+const dateFormat = "02/01/2006 15:04 MST"
+
+func link(path string, query url.Values) string {
+	u := AppURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+func SendVerificationEmail(tx *pop.Connection, user models.User, token string, expiresIn time.Duration) error {
+	return send(tx, user.Email, "Verifica tu correo electrónico", "verify_email", render.Data{
+		"name":       user.Name,
+		"link":       link("/auth/verify-email", url.Values{"token": {token}}),
+		"expires_in": humanDuration(expiresIn),
+	})
+}
+
+func SendPasswordResetEmail(tx *pop.Connection, user models.User, token string, expiresIn time.Duration) error {
+	return send(tx, user.Email, "Restablece tu contraseña", "password_reset", render.Data{
+		"name":       user.Name,
+		"link":       link("/auth/reset-password", url.Values{"token": {token}}),
+		"expires_in": humanDuration(expiresIn),
+	})
+}
+
+func SendMagicLinkEmail(tx *pop.Connection, user models.User, token string, expiresIn time.Duration) error {
+	return send(tx, user.Email, "Tu enlace para iniciar sesión", "magic_link", render.Data{
+		"name":       user.Name,
+		"link":       link("/auth/magic-link", url.Values{"token": {token}}),
+		"expires_in": humanDuration(expiresIn),
+	})
+}
+
+// SendEmailChangeEmail goes to the new address; the change only applies
+// once it is confirmed with token.
+func SendEmailChangeEmail(tx *pop.Connection, user models.User, newEmail, token string, expiresIn time.Duration) error {
+	return send(tx, newEmail, "Confirma tu nuevo correo electrónico", "email_change", render.Data{
+		"name":       user.Name,
+		"new_email":  newEmail,
+		"link":       link("/auth/confirm-email-change", url.Values{"token": {token}}),
+		"expires_in": humanDuration(expiresIn),
+	})
+}
+
+// SendEmailChangeNoticeEmail warns the current address, with a link to
+// cancel the change.
+func SendEmailChangeNoticeEmail(tx *pop.Connection, user models.User, newEmail, cancelToken string, expiresIn time.Duration) error {
+	return send(tx, user.Email, "Solicitud de cambio de correo electrónico", "email_change_notice", render.Data{
+		"name":       user.Name,
+		"new_email":  newEmail,
+		"link":       link("/auth/cancel-email-change", url.Values{"token": {cancelToken}}),
+		"expires_in": humanDuration(expiresIn),
+	})
+}
+
+// SendNewDeviceLoginEmail warns about a login from an unseen device.
+// device and location describe it for people, location may be empty.
+func SendNewDeviceLoginEmail(tx *pop.Connection, user models.User, ipAddress, device, location string, at time.Time) error {
+	return send(tx, user.Email, "Nuevo inicio de sesión en tu cuenta", "new_device_login", render.Data{
+		"name":       user.Name,
+		"ip_address": ipAddress,
+		"device":     device,
+		"location":   location,
+		"at":         at.UTC().Format(dateFormat),
+		"link":       link("/account/security", nil),
+	})
+}
+
+// SendLoginStepUpEmail sends the code that completes a login flagged as
+// suspicious, with where it came from like SendNewDeviceLoginEmail.
+func SendLoginStepUpEmail(tx *pop.Connection, user models.User, code, ipAddress, device, location string, expiresIn time.Duration) error {
+	return send(tx, user.Email, "Código para confirmar tu inicio de sesión", "login_step_up", render.Data{
+		"name":       user.Name,
+		"code":       code,
+		"ip_address": ipAddress,
+		"device":     device,
+		"location":   location,
+		"at":         time.Now().UTC().Format(dateFormat),
+		"expires_in": humanDuration(expiresIn),
+		"link":       link("/account/security", nil),
+	})
+}
+
+func SendTwoFactorChangedEmail(tx *pop.Connection, user models.User, enabled bool) error {
+	subject := "Autenticación de dos factores desactivada"
+	if enabled {
+		subject = "Autenticación de dos factores activada"
+	}
+	return send(tx, user.Email, subject, "two_factor_changed", render.Data{
+		"name":    user.Name,
+		"enabled": enabled,
+		"link":    link("/account/security", nil),
+	})
+}
+
+func SendPasswordChangedEmail(tx *pop.Connection, user models.User) error {
+	return send(tx, user.Email, "Tu contraseña fue modificada", "password_changed", render.Data{
+		"name": user.Name,
+		"at":   time.Now().UTC().Format(dateFormat),
+		"link": link("/auth/forgot-password", nil),
+	})
+}
+
+func SendAccountDeletionScheduledEmail(tx *pop.Connection, user models.User, at time.Time) error {
+	return send(tx, user.Email, "Tu cuenta será eliminada", "account_deletion_scheduled", render.Data{
+		"name": user.Name,
+		"at":   at.UTC().Format(dateFormat),
+		"link": link("/account/privacy", nil),
+	})
+}
+
+func humanDuration(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		if d == 24*time.Hour {
+			return "24 horas"
+		}
+		return strconv.Itoa(int(d/(24*time.Hour))) + " días"
+	case d >= time.Hour && d%time.Hour == 0:
+		if d == time.Hour {
+			return "1 hora"
+		}
+		return strconv.Itoa(int(d/time.Hour)) + " horas"
+	default:
+		return strconv.Itoa(int(d/time.Minute)) + " minutos"
+	}
+}
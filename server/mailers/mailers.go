@@ -0,0 +1,135 @@
+package mailers
+
+import (
+	"fmt"
+	"log"
+
+	"server/jobs"
+	"server/mailers/templates"
+
+	"github.com/gobuffalo/buffalo/mail"
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+)
+
+// Mailer delivers transactional email. mail.SMTPSender, FileSender,
+// LogSender and MemorySender all satisfy it.
+type Mailer interface {
+	Send(mail.Message) error
+}
+
+var (
+	ENV = envy.Get("GO_ENV", "development")
+
+	// MailerDriver selects the Mailer: smtp, file or log.
+	MailerDriver = envy.Get("MAILER", defaultDriver())
+	MailFrom     = envy.Get("MAIL_FROM", "RedOrange <no-reply@redorange.pe>")
+	MailDir      = envy.Get("MAIL_DIR", "tmp/mail")
+
+	// AppURL is the frontend base url used to build links in emails.
+	AppURL = envy.Get("APP_URL", "http://localhost:3000")
+)
+
+// Sender is the Mailer used by every Send* function. Tests can replace it
+// with a MemorySender.
+var Sender Mailer
+
+var r *render.Engine
+
+func init() {
+	r = render.New(render.Options{
+		HTMLLayout:  "layout.plush.html",
+		TemplatesFS: templates.FS(),
+		Helpers:     render.Helpers{},
+	})
+
+	jobs.Register(DeliverJob, deliver)
+
+	var err error
+	Sender, err = NewMailer(MailerDriver)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func defaultDriver() string {
+	if ENV == "production" {
+		return "smtp"
+	}
+	return "log"
+}
+
+// NewMailer builds the Mailer for the given driver name.
+func NewMailer(driver string) (Mailer, error) {
+	switch driver {
+	case "smtp":
+		return mail.NewSMTPSender(
+			envy.Get("SMTP_HOST", "localhost"),
+			envy.Get("SMTP_PORT", "587"),
+			envy.Get("SMTP_USER", ""),
+			envy.Get("SMTP_PASSWORD", ""),
+		)
+	case "file":
+		return FileSender{Dir: MailDir}, nil
+	case "log":
+		return LogSender{}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER driver %q, expected smtp, file or log", driver)
+	}
+}
+
+// DeliverJob is the jobs handler that renders and sends a queued email.
+const DeliverJob = "mailers:deliver"
+
+// send queues template for delivery on tx, so nothing is sent when the
+// request transaction rolls back.
+func send(tx *pop.Connection, to, subject, template string, data render.Data) error {
+	return jobs.Enqueue(tx, worker.Job{
+		Queue:   "mail",
+		Handler: DeliverJob,
+		Args:    deliverArgs(to, subject, template, data),
+	})
+}
+
+func deliverArgs(to, subject, template string, data render.Data) worker.Args {
+	return worker.Args{
+		"to":       to,
+		"subject":  subject,
+		"template": template,
+		"data":     map[string]interface{}(data),
+	}
+}
+
+// deliver renders the html and text versions of the queued template and
+// hands them to Sender.
+func deliver(args worker.Args) error {
+	to, _ := args["to"].(string)
+	subject, _ := args["subject"].(string)
+	template, _ := args["template"].(string)
+	if to == "" || template == "" {
+		return fmt.Errorf("invalid mail job args")
+	}
+
+	data := render.Data{}
+	if d, ok := args["data"].(map[string]interface{}); ok {
+		for k, v := range d {
+			data[k] = v
+		}
+	}
+
+	m := mail.NewMessage()
+	m.From = MailFrom
+	m.To = []string{to}
+	m.Subject = subject
+
+	data["subject"] = subject
+	data["app_url"] = AppURL
+
+	if err := m.AddBodies(data, r.HTML(template+".plush.html"), r.Plain(template+".plush.txt")); err != nil {
+		return err
+	}
+
+	return Sender.Send(m)
+}
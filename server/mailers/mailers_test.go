@@ -0,0 +1,53 @@
+package mailers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gobuffalo/buffalo/render"
+	"github.com/gobuffalo/buffalo/worker"
+)
+
+func Test_Deliver(t *testing.T) {
+	sender := NewMemorySender()
+	previous := Sender
+	Sender = sender
+	defer func() { Sender = previous }()
+
+	link := AppURL + "/auth/verify-email?token=abc123"
+
+	// args go through jobs.queue as JSONB
+	payload, err := json.Marshal(deliverArgs("ana@example.com", "Verifica tu correo electrónico", "verify_email", render.Data{
+		"name":       "Ana",
+		"link":       link,
+		"expires_in": "24 horas",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := worker.Args{}
+	if err := json.Unmarshal(payload, &args); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := deliver(args); err != nil {
+		t.Fatal(err)
+	}
+
+	m, ok := sender.Last()
+	if !ok {
+		t.Fatal("expected a message")
+	}
+	if len(m.To) != 1 || m.To[0] != "ana@example.com" {
+		t.Fatalf("unexpected recipients %v", m.To)
+	}
+	if len(m.Bodies) != 2 {
+		t.Fatalf("expected html and text bodies, got %d", len(m.Bodies))
+	}
+	for _, body := range m.Bodies {
+		if !strings.Contains(body.Content, link) {
+			t.Errorf("%s body does not contain %s", body.ContentType, link)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package mailers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobuffalo/buffalo/mail"
+)
+
+// -- file sender
+
+// FileSender writes every message to its own file in Dir. Meant for
+// development, where no SMTP server is available.
+type FileSender struct {
+	Dir string
+}
+
+func (s FileSender) Send(m mail.Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitizeFileName(strings.Join(m.To, "_")))
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(formatMessage(m)), 0o600)
+}
+
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '@':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// -- log sender
+
+// LogSender prints the text version of every message to the application log.
+type LogSender struct{}
+
+func (LogSender) Send(m mail.Message) error {
+	log.Printf("[MAIL]\n%s", formatMessage(m))
+	return nil
+}
+
+// -- memory sender
+
+// MemorySender keeps messages in memory so tests can assert on them.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []mail.Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(m mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func (s *MemorySender) Messages() []mail.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]mail.Message(nil), s.messages...)
+}
+
+func (s *MemorySender) Last() (mail.Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) == 0 {
+		return mail.Message{}, false
+	}
+	return s.messages[len(s.messages)-1], true
+}
+
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// -- helpers
+
+func formatMessage(m mail.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\n", m.From)
+	fmt.Fprintf(&b, "To: %s\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\n", m.Subject)
+	for _, body := range m.Bodies {
+		fmt.Fprintf(&b, "\n--- %s\n%s\n", body.ContentType, body.Content)
+	}
+	return b.String()
+}
@@ -0,0 +1,15 @@
+package templates
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/gobuffalo/buffalo"
+)
+
+//go:embed *.html *.txt
+var files embed.FS
+
+func FS() fs.FS {
+	return buffalo.NewFS(files, "mailers/templates")
+}
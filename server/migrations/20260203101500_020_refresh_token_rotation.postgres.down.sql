@@ -0,0 +1,3 @@
+-- server/migrations/20260203101500_020_refresh_token_rotation.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.rotated_refresh_tokens;
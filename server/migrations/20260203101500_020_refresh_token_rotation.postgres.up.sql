@@ -0,0 +1,15 @@
+-- server/migrations/20260203101500_020_refresh_token_rotation.postgres.up.sql
+
+-- refresh tokens already rotated out of a session (reuse detection)
+CREATE TABLE auth.rotated_refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES auth.sessions(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+
+    rotated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rotated_refresh_tokens_session_id ON auth.rotated_refresh_tokens(session_id);
+
+-- table comments
+COMMENT ON TABLE auth.rotated_refresh_tokens IS 'refresh tokens replaced by rotation, kept to detect reuse';
@@ -0,0 +1,3 @@
+-- server/migrations/20260205093000_030_role_permissions.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.role_permissions;
@@ -0,0 +1,22 @@
+-- server/migrations/20260205093000_030_role_permissions.postgres.up.sql
+
+-- named permissions granted to each role
+CREATE TABLE auth.role_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    role VARCHAR(20) NOT NULL CHECK (role IN ('support', 'admin', 'dev')),
+    permission VARCHAR(100) NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    UNIQUE(role, permission)
+);
+
+CREATE INDEX idx_role_permissions_role ON auth.role_permissions(role);
+
+-- default grants
+INSERT INTO auth.role_permissions (role, permission) VALUES
+    ('admin', 'users:read'),
+    ('admin', 'users:manage');
+
+-- table comments
+COMMENT ON TABLE auth.role_permissions IS 'permissions granted to each user role';
@@ -0,0 +1,3 @@
+-- server/migrations/20260207100000_040_job_queue.postgres.down.sql
+
+DROP SCHEMA IF EXISTS jobs CASCADE;
@@ -0,0 +1,47 @@
+-- server/migrations/20260207100000_040_job_queue.postgres.up.sql
+
+CREATE SCHEMA IF NOT EXISTS jobs;
+
+-- pending and running background jobs
+CREATE TABLE jobs.queue (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    queue VARCHAR(50) NOT NULL DEFAULT 'default',
+    handler VARCHAR(100) NOT NULL,
+    args JSONB NOT NULL DEFAULT '{}',
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 8,
+    run_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    locked_at TIMESTAMP,
+    locked_by VARCHAR(255),
+    last_error TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_queue_pending_run_at ON jobs.queue(run_at) WHERE status = 'pending';
+CREATE INDEX idx_queue_running_locked_at ON jobs.queue(locked_at) WHERE status = 'running';
+
+-- jobs that exhausted their attempts
+CREATE TABLE jobs.dead_letters (
+    id UUID PRIMARY KEY,
+    queue VARCHAR(50) NOT NULL,
+    handler VARCHAR(100) NOT NULL,
+    args JSONB NOT NULL DEFAULT '{}',
+
+    attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+
+    created_at TIMESTAMP NOT NULL,
+    failed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_dead_letters_handler ON jobs.dead_letters(handler);
+CREATE INDEX idx_dead_letters_failed_at ON jobs.dead_letters(failed_at);
+
+-- table comments
+COMMENT ON TABLE jobs.queue IS 'background jobs waiting to run or running, enqueued in the same transaction as the business write';
+COMMENT ON TABLE jobs.dead_letters IS 'background jobs that failed max_attempts times';
@@ -0,0 +1,4 @@
+-- server/migrations/20260209090000_050_retention_indexes.postgres.down.sql
+
+DROP INDEX IF EXISTS auth.idx_sessions_revoked_at;
+DROP INDEX IF EXISTS auth.idx_login_attempts_created_at;
@@ -0,0 +1,5 @@
+-- server/migrations/20260209090000_050_retention_indexes.postgres.up.sql
+
+-- retention cleanup scans by age
+CREATE INDEX idx_login_attempts_created_at ON auth.login_attempts(created_at);
+CREATE INDEX idx_sessions_revoked_at ON auth.sessions(revoked_at) WHERE revoked = true;
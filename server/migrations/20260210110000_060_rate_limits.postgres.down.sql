@@ -0,0 +1,3 @@
+-- server/migrations/20260210110000_060_rate_limits.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.rate_limits;
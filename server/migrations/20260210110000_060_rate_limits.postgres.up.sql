@@ -0,0 +1,17 @@
+-- server/migrations/20260210110000_060_rate_limits.postgres.up.sql
+
+-- token buckets shared by every instance
+CREATE TABLE auth.rate_limits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    key VARCHAR(255) NOT NULL UNIQUE,
+    tokens DOUBLE PRECISION NOT NULL,
+
+    updated_at TIMESTAMP NOT NULL,
+    -- bucket is full again after this, row can be deleted
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON auth.rate_limits(expires_at);
+
+-- table comments
+COMMENT ON TABLE auth.rate_limits IS 'rate limit token buckets keyed by route and client ip or email hash';
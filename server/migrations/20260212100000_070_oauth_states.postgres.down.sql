@@ -0,0 +1,3 @@
+-- server/migrations/20260212100000_070_oauth_states.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.oauth_states;
@@ -0,0 +1,25 @@
+-- server/migrations/20260212100000_070_oauth_states.postgres.up.sql
+
+-- pending oauth authorization requests (state + pkce)
+CREATE TABLE auth.oauth_states (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    state_hash VARCHAR(255) NOT NULL UNIQUE,
+    provider VARCHAR(50) NOT NULL,
+
+    -- frontend url the callback redirects to, already checked against the allowlist
+    redirect_uri TEXT NOT NULL,
+    -- opaque value from the frontend, echoed back on redirect
+    client_state VARCHAR(255),
+    code_verifier VARCHAR(128) NOT NULL,
+
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    used BOOLEAN NOT NULL DEFAULT FALSE,
+    used_at TIMESTAMP
+);
+
+CREATE INDEX idx_oauth_states_expires ON auth.oauth_states(expires_at, used);
+
+-- table comments
+COMMENT ON TABLE auth.oauth_states IS 'single use oauth state records with pkce verifier';
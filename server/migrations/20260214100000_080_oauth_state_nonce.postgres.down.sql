@@ -0,0 +1,4 @@
+-- server/migrations/20260214100000_080_oauth_state_nonce.postgres.down.sql
+
+ALTER TABLE auth.oauth_states DROP COLUMN IF EXISTS user_id;
+ALTER TABLE auth.oauth_states DROP COLUMN IF EXISTS nonce;
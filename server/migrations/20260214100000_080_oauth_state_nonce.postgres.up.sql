@@ -0,0 +1,7 @@
+-- server/migrations/20260214100000_080_oauth_state_nonce.postgres.up.sql
+
+-- oidc nonce, checked against the id token on callback
+ALTER TABLE auth.oauth_states ADD COLUMN nonce VARCHAR(128);
+
+-- set when the flow links a provider to a signed in user instead of logging in
+ALTER TABLE auth.oauth_states ADD COLUMN user_id UUID REFERENCES auth.users(id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- server/migrations/20260216100000_090_webauthn.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.webauthn_challenges;
+DROP TABLE IF EXISTS auth.webauthn_credentials;
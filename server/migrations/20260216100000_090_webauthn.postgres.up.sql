@@ -0,0 +1,48 @@
+-- server/migrations/20260216100000_090_webauthn.postgres.up.sql
+
+-- webauthn credentials (passkeys and security keys)
+CREATE TABLE auth.webauthn_credentials (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+
+    -- credential record from the authenticator
+    credential_id BYTEA NOT NULL UNIQUE,
+    public_key BYTEA NOT NULL,
+    attestation_type VARCHAR(50) NOT NULL DEFAULT 'none',
+    aaguid BYTEA,
+    transports VARCHAR(255),
+    sign_count BIGINT NOT NULL DEFAULT 0,
+    clone_warning BOOLEAN NOT NULL DEFAULT FALSE,
+
+    -- authenticator flags
+    user_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_eligible BOOLEAN NOT NULL DEFAULT FALSE,
+    backup_state BOOLEAN NOT NULL DEFAULT FALSE,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP
+);
+
+CREATE INDEX idx_webauthn_credentials_user_id ON auth.webauthn_credentials(user_id);
+
+-- pending registration and assertion ceremonies
+CREATE TABLE auth.webauthn_challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    -- null for passwordless login, the user is only known after the assertion
+    user_id UUID REFERENCES auth.users(id) ON DELETE CASCADE,
+    ceremony VARCHAR(20) NOT NULL CHECK (ceremony IN ('registration', 'login', 'second_factor')),
+
+    -- JSONB, webauthn session data (challenge, allowed credentials, ...)
+    session_data JSONB NOT NULL,
+
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webauthn_challenges_expires_at ON auth.webauthn_challenges(expires_at);
+
+-- table comments
+COMMENT ON TABLE auth.webauthn_credentials IS 'webauthn public key credentials registered by users';
+COMMENT ON TABLE auth.webauthn_challenges IS 'single use webauthn ceremony state';
@@ -0,0 +1,6 @@
+-- server/migrations/20260218100000_100_encryption_at_rest.postgres.down.sql
+
+-- values stay encrypted; run with the same ENCRYPTION_KEYS or disable 2fa first
+DROP TABLE IF EXISTS auth.two_factor_setups;
+
+ALTER TABLE auth.users ALTER COLUMN two_factor_secret TYPE VARCHAR(255);
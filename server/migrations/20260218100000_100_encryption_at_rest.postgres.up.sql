@@ -0,0 +1,22 @@
+-- server/migrations/20260218100000_100_encryption_at_rest.postgres.up.sql
+
+-- sealed values (enc:v1:<kid>:...) are longer than the plaintext secret
+ALTER TABLE auth.users ALTER COLUMN two_factor_secret TYPE TEXT;
+
+-- pending 2fa setups: the secret is encrypted, backup codes are only hashed
+CREATE TABLE auth.two_factor_setups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL UNIQUE REFERENCES auth.users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL UNIQUE,
+    secret TEXT NOT NULL,
+    backup_code_hashes TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_two_factor_setups_expires_at ON auth.two_factor_setups(expires_at);
+
+COMMENT ON TABLE auth.two_factor_setups IS '2fa secrets waiting for the first code, replaces 2fa_setup verification tokens';
+
+-- setups in flight kept the secret and backup codes in plaintext in the email column
+DELETE FROM auth.verification_tokens WHERE token_type = '2fa_setup';
@@ -0,0 +1,7 @@
+-- server/migrations/20260220100000_110_totp_replay.postgres.down.sql
+
+DROP INDEX IF EXISTS auth.idx_login_attempts_token_id;
+
+ALTER TABLE auth.login_attempts DROP COLUMN IF EXISTS token_id;
+
+ALTER TABLE auth.users DROP COLUMN IF EXISTS totp_last_step;
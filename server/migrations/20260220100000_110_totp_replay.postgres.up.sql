@@ -0,0 +1,9 @@
+-- server/migrations/20260220100000_110_totp_replay.postgres.up.sql
+
+-- last accepted totp time step (unix time / 30); codes for it or earlier steps are rejected
+ALTER TABLE auth.users ADD COLUMN totp_last_step BIGINT;
+
+-- jti of the temp_2fa token a failed 2fa attempt was made with
+ALTER TABLE auth.login_attempts ADD COLUMN token_id UUID;
+
+CREATE INDEX idx_login_attempts_token_id ON auth.login_attempts(token_id) WHERE token_id IS NOT NULL;
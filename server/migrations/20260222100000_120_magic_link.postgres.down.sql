@@ -0,0 +1,7 @@
+-- server/migrations/20260222100000_120_magic_link.postgres.down.sql
+
+DELETE FROM auth.verification_tokens WHERE token_type = 'magic_link';
+
+ALTER TABLE auth.verification_tokens DROP COLUMN IF EXISTS device_hash;
+
+COMMENT ON TABLE auth.verification_tokens IS 'tokens for email verification and password reset';
@@ -0,0 +1,7 @@
+-- server/migrations/20260222100000_120_magic_link.postgres.up.sql
+
+-- sha256 of the device secret held by the browser that requested the token;
+-- magic links can only be consumed from that browser
+ALTER TABLE auth.verification_tokens ADD COLUMN device_hash VARCHAR(64);
+
+COMMENT ON TABLE auth.verification_tokens IS 'tokens for email verification, password reset and magic link login';
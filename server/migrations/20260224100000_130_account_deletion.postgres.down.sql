@@ -0,0 +1,5 @@
+-- server/migrations/20260224100000_130_account_deletion.postgres.down.sql
+
+DROP INDEX IF EXISTS auth.idx_users_deletion_scheduled_at;
+
+ALTER TABLE auth.users DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,7 @@
+-- server/migrations/20260224100000_130_account_deletion.postgres.up.sql
+
+-- set when the user asks to delete the account; the account is erased once
+-- it passes, unless the request is cancelled first (ley 29733)
+ALTER TABLE auth.users ADD COLUMN deletion_scheduled_at TIMESTAMP;
+
+CREATE INDEX idx_users_deletion_scheduled_at ON auth.users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- server/migrations/20260226100000_140_password_history.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.password_history;
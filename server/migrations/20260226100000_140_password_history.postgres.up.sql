@@ -0,0 +1,15 @@
+-- server/migrations/20260226100000_140_password_history.postgres.up.sql
+
+-- hashes of the passwords a user had before the current one, so they can't
+-- be reused; trimmed to PASSWORD_HISTORY rows per user
+CREATE TABLE auth.password_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user_id ON auth.password_history(user_id, created_at DESC);
+
+COMMENT ON TABLE auth.password_history IS 'previous password hashes, to reject reuse';
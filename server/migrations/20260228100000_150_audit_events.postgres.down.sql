@@ -0,0 +1,7 @@
+-- server/migrations/20260228100000_150_audit_events.postgres.down.sql
+
+DELETE FROM auth.role_permissions WHERE permission = 'audit:read';
+
+DROP TABLE IF EXISTS auth.audit_events;
+
+DROP FUNCTION IF EXISTS auth.audit_events_append_only();
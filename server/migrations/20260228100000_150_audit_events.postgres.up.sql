@@ -0,0 +1,52 @@
+-- server/migrations/20260228100000_150_audit_events.postgres.up.sql
+
+-- security relevant changes made by or to a user; ids are not foreign keys
+-- so events outlive the accounts they mention
+CREATE TABLE auth.audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(64) NOT NULL,
+
+    -- who did it (null for anonymous requests) and whose account it affects
+    actor_id UUID,
+    target_id UUID,
+
+    ip_address INET,
+    user_agent TEXT,
+    metadata JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_events_target ON auth.audit_events(target_id, created_at DESC);
+CREATE INDEX idx_audit_events_actor ON auth.audit_events(actor_id, created_at DESC);
+CREATE INDEX idx_audit_events_type ON auth.audit_events(event_type, created_at DESC);
+CREATE INDEX idx_audit_events_created_at ON auth.audit_events(created_at DESC);
+
+-- append-only: the only change allowed is erasing the personal data of a
+-- deleted account (privacy), which keeps the event itself
+CREATE FUNCTION auth.audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.event_type = OLD.event_type
+        AND NEW.created_at = OLD.created_at
+        AND (NEW.actor_id IS NULL OR NEW.actor_id = OLD.actor_id)
+        AND (NEW.target_id IS NULL OR NEW.target_id = OLD.target_id)
+        AND (NEW.ip_address IS NULL OR NEW.ip_address = OLD.ip_address)
+        AND (NEW.user_agent IS NULL OR NEW.user_agent = OLD.user_agent)
+        AND (NEW.metadata = '{}' OR NEW.metadata = OLD.metadata)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'auth.audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON auth.audit_events
+    FOR EACH ROW EXECUTE FUNCTION auth.audit_events_append_only();
+
+INSERT INTO auth.role_permissions (role, permission) VALUES
+    ('admin', 'audit:read');
+
+COMMENT ON TABLE auth.audit_events IS 'append-only log of security relevant account changes';
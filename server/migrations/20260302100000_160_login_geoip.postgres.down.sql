@@ -0,0 +1,13 @@
+-- server/migrations/20260302100000_160_login_geoip.postgres.down.sql
+
+ALTER TABLE auth.login_attempts
+    DROP COLUMN IF EXISTS browser,
+    DROP COLUMN IF EXISTS browser_version,
+    DROP COLUMN IF EXISTS os,
+    DROP COLUMN IF EXISTS os_version,
+    DROP COLUMN IF EXISTS device_type,
+    DROP COLUMN IF EXISTS country_code,
+    DROP COLUMN IF EXISTS country,
+    DROP COLUMN IF EXISTS city,
+    DROP COLUMN IF EXISTS asn,
+    DROP COLUMN IF EXISTS as_org;
@@ -0,0 +1,15 @@
+-- server/migrations/20260302100000_160_login_geoip.postgres.up.sql
+
+-- what the user agent and ip of a login attempt resolve to; sessions keep
+-- the same in device_info. Rows from before this migration stay empty.
+ALTER TABLE auth.login_attempts
+    ADD COLUMN browser VARCHAR(64),
+    ADD COLUMN browser_version VARCHAR(32),
+    ADD COLUMN os VARCHAR(64),
+    ADD COLUMN os_version VARCHAR(32),
+    ADD COLUMN device_type VARCHAR(16),
+    ADD COLUMN country_code CHAR(2),
+    ADD COLUMN country VARCHAR(100),
+    ADD COLUMN city VARCHAR(100),
+    ADD COLUMN asn BIGINT,
+    ADD COLUMN as_org VARCHAR(255);
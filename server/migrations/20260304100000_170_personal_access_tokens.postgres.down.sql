@@ -0,0 +1,3 @@
+-- server/migrations/20260304100000_170_personal_access_tokens.postgres.down.sql
+
+DROP TABLE IF EXISTS auth.personal_access_tokens;
@@ -0,0 +1,29 @@
+-- server/migrations/20260304100000_170_personal_access_tokens.postgres.up.sql
+
+-- long-lived tokens users create for scripts and other machine clients;
+-- only the hash of the token is stored, like refresh tokens
+CREATE TABLE auth.personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES auth.users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    -- first characters of the token, to tell tokens apart in listings
+    token_prefix VARCHAR(16) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    last_used_at TIMESTAMP,
+    last_used_ip INET,
+    last_used_user_agent TEXT,
+
+    revoked BOOLEAN NOT NULL DEFAULT false,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX idx_personal_access_tokens_user ON auth.personal_access_tokens(user_id, created_at DESC);
+CREATE INDEX idx_personal_access_tokens_expires_at ON auth.personal_access_tokens(expires_at);
+
+COMMENT ON TABLE auth.personal_access_tokens IS 'scoped, expiring tokens for machine clients';
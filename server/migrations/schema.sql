@@ -46,6 +46,15 @@ CREATE SCHEMA infra;
 
 ALTER SCHEMA infra OWNER TO postgres;
 
+--
+-- Name: jobs; Type: SCHEMA; Schema: -; Owner: postgres
+--
+
+CREATE SCHEMA jobs;
+
+
+ALTER SCHEMA jobs OWNER TO postgres;
+
 --
 -- Name: tech; Type: SCHEMA; Schema: -; Owner: postgres
 --
@@ -55,6 +64,33 @@ CREATE SCHEMA tech;
 
 ALTER SCHEMA tech OWNER TO postgres;
 
+--
+-- Name: audit_events_append_only(); Type: FUNCTION; Schema: auth; Owner: postgres
+--
+
+CREATE FUNCTION auth.audit_events_append_only() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND NEW.id = OLD.id
+        AND NEW.event_type = OLD.event_type
+        AND NEW.created_at = OLD.created_at
+        AND (NEW.actor_id IS NULL OR NEW.actor_id = OLD.actor_id)
+        AND (NEW.target_id IS NULL OR NEW.target_id = OLD.target_id)
+        AND (NEW.ip_address IS NULL OR NEW.ip_address = OLD.ip_address)
+        AND (NEW.user_agent IS NULL OR NEW.user_agent = OLD.user_agent)
+        AND (NEW.metadata = '{}' OR NEW.metadata = OLD.metadata)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'auth.audit_events is append-only';
+END;
+$$;
+
+
+ALTER FUNCTION auth.audit_events_append_only() OWNER TO postgres;
+
 --
 -- Name: update_updated_at_column(); Type: FUNCTION; Schema: auth; Owner: postgres
 --
@@ -97,6 +133,31 @@ ALTER TABLE auth.account_locks OWNER TO postgres;
 COMMENT ON TABLE auth.account_locks IS 'temporary account lock control';
 
 
+--
+-- Name: audit_events; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.audit_events (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    event_type character varying(64) NOT NULL,
+    actor_id uuid,
+    target_id uuid,
+    ip_address inet,
+    user_agent text,
+    metadata jsonb DEFAULT '{}'::jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE auth.audit_events OWNER TO postgres;
+
+--
+-- Name: TABLE audit_events; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.audit_events IS 'append-only log of security relevant account changes';
+
+
 --
 -- Name: login_attempts; Type: TABLE; Schema: auth; Owner: postgres
 --
@@ -109,7 +170,18 @@ CREATE TABLE auth.login_attempts (
     failure_reason character varying(100),
     ip_address inet,
     user_agent text,
-    created_at timestamp without time zone DEFAULT now() NOT NULL
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    token_id uuid,
+    browser character varying(64),
+    browser_version character varying(32),
+    os character varying(64),
+    os_version character varying(32),
+    device_type character varying(16),
+    country_code character(2),
+    country character varying(100),
+    city character varying(100),
+    asn bigint,
+    as_org character varying(255)
 );
 
 
@@ -149,6 +221,151 @@ ALTER TABLE auth.oauth_providers OWNER TO postgres;
 COMMENT ON TABLE auth.oauth_providers IS 'oauth providers linked to users';
 
 
+--
+-- Name: oauth_states; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.oauth_states (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    state_hash character varying(255) NOT NULL,
+    provider character varying(50) NOT NULL,
+    redirect_uri text NOT NULL,
+    client_state character varying(255),
+    code_verifier character varying(128) NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    used boolean DEFAULT false NOT NULL,
+    used_at timestamp without time zone,
+    nonce character varying(128),
+    user_id uuid
+);
+
+
+ALTER TABLE auth.oauth_states OWNER TO postgres;
+
+--
+-- Name: TABLE oauth_states; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.oauth_states IS 'single use oauth state records with pkce verifier';
+
+
+--
+-- Name: password_history; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.password_history (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    password_hash character varying(255) NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE auth.password_history OWNER TO postgres;
+
+--
+-- Name: TABLE password_history; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.password_history IS 'previous password hashes, to reject reuse';
+
+
+--
+-- Name: personal_access_tokens; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.personal_access_tokens (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    name character varying(100) NOT NULL,
+    token_hash character varying(64) NOT NULL,
+    token_prefix character varying(16) NOT NULL,
+    scopes text[] DEFAULT '{}'::text[] NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    last_used_at timestamp without time zone,
+    last_used_ip inet,
+    last_used_user_agent text,
+    revoked boolean DEFAULT false NOT NULL,
+    revoked_at timestamp without time zone
+);
+
+
+ALTER TABLE auth.personal_access_tokens OWNER TO postgres;
+
+--
+-- Name: TABLE personal_access_tokens; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.personal_access_tokens IS 'scoped, expiring tokens for machine clients';
+
+
+--
+-- Name: rate_limits; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.rate_limits (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    key character varying(255) NOT NULL,
+    tokens double precision NOT NULL,
+    updated_at timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone NOT NULL
+);
+
+
+ALTER TABLE auth.rate_limits OWNER TO postgres;
+
+--
+-- Name: TABLE rate_limits; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.rate_limits IS 'rate limit token buckets keyed by route and client ip or email hash';
+
+
+--
+-- Name: role_permissions; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.role_permissions (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    role character varying(20) NOT NULL,
+    permission character varying(100) NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT role_permissions_role_check CHECK (((role)::text = ANY ((ARRAY['support'::character varying, 'admin'::character varying, 'dev'::character varying])::text[])))
+);
+
+
+ALTER TABLE auth.role_permissions OWNER TO postgres;
+
+--
+-- Name: TABLE role_permissions; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.role_permissions IS 'permissions granted to each user role';
+
+
+--
+-- Name: rotated_refresh_tokens; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.rotated_refresh_tokens (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    session_id uuid NOT NULL,
+    token_hash character varying(255) NOT NULL,
+    rotated_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE auth.rotated_refresh_tokens OWNER TO postgres;
+
+--
+-- Name: TABLE rotated_refresh_tokens; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.rotated_refresh_tokens IS 'refresh tokens replaced by rotation, kept to detect reuse';
+
+
 --
 -- Name: sessions; Type: TABLE; Schema: auth; Owner: postgres
 --
@@ -198,6 +415,30 @@ ALTER TABLE auth.two_factor_backup_codes OWNER TO postgres;
 COMMENT ON TABLE auth.two_factor_backup_codes IS 'backup codes for 2fa';
 
 
+--
+-- Name: two_factor_setups; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.two_factor_setups (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    token_hash character varying(255) NOT NULL,
+    secret text NOT NULL,
+    backup_code_hashes text NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE auth.two_factor_setups OWNER TO postgres;
+
+--
+-- Name: TABLE two_factor_setups; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.two_factor_setups IS '2fa secrets waiting for the first code, replaces 2fa_setup verification tokens';
+
+
 --
 -- Name: users; Type: TABLE; Schema: auth; Owner: postgres
 --
@@ -213,10 +454,12 @@ CREATE TABLE auth.users (
     role character varying(20) NOT NULL,
     active boolean DEFAULT true,
     two_factor_enabled boolean DEFAULT false,
-    two_factor_secret character varying(255),
+    two_factor_secret text,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
     updated_at timestamp without time zone DEFAULT now() NOT NULL,
     last_login_at timestamp without time zone,
+    totp_last_step bigint,
+    deletion_scheduled_at timestamp without time zone,
     CONSTRAINT email_format CHECK (((email)::text ~* '^[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}$'::text)),
     CONSTRAINT users_role_check CHECK (((role)::text = ANY ((ARRAY['support'::character varying, 'admin'::character varying, 'dev'::character varying])::text[])))
 );
@@ -244,7 +487,8 @@ CREATE TABLE auth.verification_tokens (
     expires_at timestamp without time zone NOT NULL,
     created_at timestamp without time zone DEFAULT now() NOT NULL,
     used boolean DEFAULT false,
-    used_at timestamp without time zone
+    used_at timestamp without time zone,
+    device_hash character varying(64)
 );
 
 
@@ -254,7 +498,120 @@ ALTER TABLE auth.verification_tokens OWNER TO postgres;
 -- Name: TABLE verification_tokens; Type: COMMENT; Schema: auth; Owner: postgres
 --
 
-COMMENT ON TABLE auth.verification_tokens IS 'tokens for email verification and password reset';
+COMMENT ON TABLE auth.verification_tokens IS 'tokens for email verification, password reset and magic link login';
+
+
+--
+-- Name: webauthn_challenges; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.webauthn_challenges (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid,
+    ceremony character varying(20) NOT NULL,
+    session_data jsonb NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT webauthn_challenges_ceremony_check CHECK (((ceremony)::text = ANY ((ARRAY['registration'::character varying, 'login'::character varying, 'second_factor'::character varying])::text[])))
+);
+
+
+ALTER TABLE auth.webauthn_challenges OWNER TO postgres;
+
+--
+-- Name: TABLE webauthn_challenges; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.webauthn_challenges IS 'single use webauthn ceremony state';
+
+
+--
+-- Name: webauthn_credentials; Type: TABLE; Schema: auth; Owner: postgres
+--
+
+CREATE TABLE auth.webauthn_credentials (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    user_id uuid NOT NULL,
+    name character varying(100) NOT NULL,
+    credential_id bytea NOT NULL,
+    public_key bytea NOT NULL,
+    attestation_type character varying(50) DEFAULT 'none'::character varying NOT NULL,
+    aaguid bytea,
+    transports character varying(255),
+    sign_count bigint DEFAULT 0 NOT NULL,
+    clone_warning boolean DEFAULT false NOT NULL,
+    user_verified boolean DEFAULT false NOT NULL,
+    backup_eligible boolean DEFAULT false NOT NULL,
+    backup_state boolean DEFAULT false NOT NULL,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    last_used_at timestamp without time zone
+);
+
+
+ALTER TABLE auth.webauthn_credentials OWNER TO postgres;
+
+--
+-- Name: TABLE webauthn_credentials; Type: COMMENT; Schema: auth; Owner: postgres
+--
+
+COMMENT ON TABLE auth.webauthn_credentials IS 'webauthn public key credentials registered by users';
+
+
+--
+-- Name: dead_letters; Type: TABLE; Schema: jobs; Owner: postgres
+--
+
+CREATE TABLE jobs.dead_letters (
+    id uuid NOT NULL,
+    queue character varying(50) NOT NULL,
+    handler character varying(100) NOT NULL,
+    args jsonb DEFAULT '{}'::jsonb NOT NULL,
+    attempts integer NOT NULL,
+    last_error text NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    failed_at timestamp without time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE jobs.dead_letters OWNER TO postgres;
+
+--
+-- Name: TABLE dead_letters; Type: COMMENT; Schema: jobs; Owner: postgres
+--
+
+COMMENT ON TABLE jobs.dead_letters IS 'background jobs that failed max_attempts times';
+
+
+--
+-- Name: queue; Type: TABLE; Schema: jobs; Owner: postgres
+--
+
+CREATE TABLE jobs.queue (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    queue character varying(50) DEFAULT 'default'::character varying NOT NULL,
+    handler character varying(100) NOT NULL,
+    args jsonb DEFAULT '{}'::jsonb NOT NULL,
+    status character varying(20) DEFAULT 'pending'::character varying NOT NULL,
+    attempts integer DEFAULT 0 NOT NULL,
+    max_attempts integer DEFAULT 8 NOT NULL,
+    run_at timestamp without time zone DEFAULT now() NOT NULL,
+    locked_at timestamp without time zone,
+    locked_by character varying(255),
+    last_error text,
+    created_at timestamp without time zone DEFAULT now() NOT NULL,
+    updated_at timestamp without time zone DEFAULT now() NOT NULL,
+    CONSTRAINT queue_status_check CHECK (((status)::text = ANY ((ARRAY['pending'::character varying, 'running'::character varying])::text[])))
+);
+
+
+ALTER TABLE jobs.queue OWNER TO postgres;
+
+--
+-- Name: TABLE queue; Type: COMMENT; Schema: jobs; Owner: postgres
+--
+
+COMMENT ON TABLE jobs.queue IS 'background jobs waiting to run or running, enqueued in the same transaction as the business write';
 
 
 --
@@ -284,6 +641,14 @@ ALTER TABLE ONLY auth.account_locks
     ADD CONSTRAINT account_locks_user_id_key UNIQUE (user_id);
 
 
+--
+-- Name: audit_events audit_events_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.audit_events
+    ADD CONSTRAINT audit_events_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: login_attempts login_attempts_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
 --
@@ -308,6 +673,94 @@ ALTER TABLE ONLY auth.oauth_providers
     ADD CONSTRAINT oauth_providers_provider_provider_user_id_key UNIQUE (provider, provider_user_id);
 
 
+--
+-- Name: oauth_states oauth_states_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.oauth_states
+    ADD CONSTRAINT oauth_states_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: oauth_states oauth_states_state_hash_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.oauth_states
+    ADD CONSTRAINT oauth_states_state_hash_key UNIQUE (state_hash);
+
+
+--
+-- Name: password_history password_history_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.password_history
+    ADD CONSTRAINT password_history_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: personal_access_tokens personal_access_tokens_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.personal_access_tokens
+    ADD CONSTRAINT personal_access_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: personal_access_tokens personal_access_tokens_token_hash_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.personal_access_tokens
+    ADD CONSTRAINT personal_access_tokens_token_hash_key UNIQUE (token_hash);
+
+
+--
+-- Name: rate_limits rate_limits_key_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.rate_limits
+    ADD CONSTRAINT rate_limits_key_key UNIQUE (key);
+
+
+--
+-- Name: rate_limits rate_limits_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.rate_limits
+    ADD CONSTRAINT rate_limits_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: role_permissions role_permissions_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.role_permissions
+    ADD CONSTRAINT role_permissions_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: role_permissions role_permissions_role_permission_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.role_permissions
+    ADD CONSTRAINT role_permissions_role_permission_key UNIQUE (role, permission);
+
+
+--
+-- Name: rotated_refresh_tokens rotated_refresh_tokens_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.rotated_refresh_tokens
+    ADD CONSTRAINT rotated_refresh_tokens_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: rotated_refresh_tokens rotated_refresh_tokens_token_hash_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.rotated_refresh_tokens
+    ADD CONSTRAINT rotated_refresh_tokens_token_hash_key UNIQUE (token_hash);
+
+
 --
 -- Name: sessions sessions_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
 --
@@ -324,6 +777,30 @@ ALTER TABLE ONLY auth.two_factor_backup_codes
     ADD CONSTRAINT two_factor_backup_codes_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: two_factor_setups two_factor_setups_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.two_factor_setups
+    ADD CONSTRAINT two_factor_setups_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: two_factor_setups two_factor_setups_token_hash_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.two_factor_setups
+    ADD CONSTRAINT two_factor_setups_token_hash_key UNIQUE (token_hash);
+
+
+--
+-- Name: two_factor_setups two_factor_setups_user_id_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.two_factor_setups
+    ADD CONSTRAINT two_factor_setups_user_id_key UNIQUE (user_id);
+
+
 --
 -- Name: users users_email_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
 --
@@ -356,6 +833,46 @@ ALTER TABLE ONLY auth.verification_tokens
     ADD CONSTRAINT verification_tokens_token_hash_key UNIQUE (token_hash);
 
 
+--
+-- Name: webauthn_challenges webauthn_challenges_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.webauthn_challenges
+    ADD CONSTRAINT webauthn_challenges_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: webauthn_credentials webauthn_credentials_credential_id_key; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.webauthn_credentials
+    ADD CONSTRAINT webauthn_credentials_credential_id_key UNIQUE (credential_id);
+
+
+--
+-- Name: webauthn_credentials webauthn_credentials_pkey; Type: CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.webauthn_credentials
+    ADD CONSTRAINT webauthn_credentials_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: dead_letters dead_letters_pkey; Type: CONSTRAINT; Schema: jobs; Owner: postgres
+--
+
+ALTER TABLE ONLY jobs.dead_letters
+    ADD CONSTRAINT dead_letters_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: queue queue_pkey; Type: CONSTRAINT; Schema: jobs; Owner: postgres
+--
+
+ALTER TABLE ONLY jobs.queue
+    ADD CONSTRAINT queue_pkey PRIMARY KEY (id);
+
+
 --
 -- Name: schema_migration schema_migration_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -371,6 +888,34 @@ ALTER TABLE ONLY public.schema_migration
 CREATE INDEX idx_account_locks_user_id ON auth.account_locks USING btree (user_id, locked_until);
 
 
+--
+-- Name: idx_audit_events_actor; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_audit_events_actor ON auth.audit_events USING btree (actor_id, created_at DESC);
+
+
+--
+-- Name: idx_audit_events_created_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_audit_events_created_at ON auth.audit_events USING btree (created_at DESC);
+
+
+--
+-- Name: idx_audit_events_target; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_audit_events_target ON auth.audit_events USING btree (target_id, created_at DESC);
+
+
+--
+-- Name: idx_audit_events_type; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_audit_events_type ON auth.audit_events USING btree (event_type, created_at DESC);
+
+
 --
 -- Name: idx_backup_codes_user_id; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -378,6 +923,13 @@ CREATE INDEX idx_account_locks_user_id ON auth.account_locks USING btree (user_i
 CREATE INDEX idx_backup_codes_user_id ON auth.two_factor_backup_codes USING btree (user_id);
 
 
+--
+-- Name: idx_login_attempts_created_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_login_attempts_created_at ON auth.login_attempts USING btree (created_at);
+
+
 --
 -- Name: idx_login_attempts_email; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -392,6 +944,13 @@ CREATE INDEX idx_login_attempts_email ON auth.login_attempts USING btree (email,
 CREATE INDEX idx_login_attempts_ip ON auth.login_attempts USING btree (ip_address, created_at);
 
 
+--
+-- Name: idx_login_attempts_token_id; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_login_attempts_token_id ON auth.login_attempts USING btree (token_id) WHERE (token_id IS NOT NULL);
+
+
 --
 -- Name: idx_login_attempts_user_id; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -406,6 +965,13 @@ CREATE INDEX idx_login_attempts_user_id ON auth.login_attempts USING btree (user
 CREATE INDEX idx_oauth_provider ON auth.oauth_providers USING btree (provider, provider_user_id);
 
 
+--
+-- Name: idx_oauth_states_expires; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_oauth_states_expires ON auth.oauth_states USING btree (expires_at, used);
+
+
 --
 -- Name: idx_oauth_user_id; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -413,6 +979,48 @@ CREATE INDEX idx_oauth_provider ON auth.oauth_providers USING btree (provider, p
 CREATE INDEX idx_oauth_user_id ON auth.oauth_providers USING btree (user_id);
 
 
+--
+-- Name: idx_password_history_user_id; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_password_history_user_id ON auth.password_history USING btree (user_id, created_at DESC);
+
+
+--
+-- Name: idx_personal_access_tokens_expires_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_personal_access_tokens_expires_at ON auth.personal_access_tokens USING btree (expires_at);
+
+
+--
+-- Name: idx_personal_access_tokens_user; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_personal_access_tokens_user ON auth.personal_access_tokens USING btree (user_id, created_at DESC);
+
+
+--
+-- Name: idx_rate_limits_expires_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_rate_limits_expires_at ON auth.rate_limits USING btree (expires_at);
+
+
+--
+-- Name: idx_role_permissions_role; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_role_permissions_role ON auth.role_permissions USING btree (role);
+
+
+--
+-- Name: idx_rotated_refresh_tokens_session_id; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_rotated_refresh_tokens_session_id ON auth.rotated_refresh_tokens USING btree (session_id);
+
+
 --
 -- Name: idx_sessions_active; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -427,6 +1035,13 @@ CREATE INDEX idx_sessions_active ON auth.sessions USING btree (user_id, revoked,
 CREATE INDEX idx_sessions_expires_at ON auth.sessions USING btree (expires_at);
 
 
+--
+-- Name: idx_sessions_revoked_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_sessions_revoked_at ON auth.sessions USING btree (revoked_at) WHERE (revoked = true);
+
+
 --
 -- Name: idx_sessions_token_hash; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -441,6 +1056,13 @@ CREATE INDEX idx_sessions_token_hash ON auth.sessions USING btree (refresh_token
 CREATE INDEX idx_sessions_user_id ON auth.sessions USING btree (user_id);
 
 
+--
+-- Name: idx_two_factor_setups_expires_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_two_factor_setups_expires_at ON auth.two_factor_setups USING btree (expires_at);
+
+
 --
 -- Name: idx_users_active; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -448,6 +1070,13 @@ CREATE INDEX idx_sessions_user_id ON auth.sessions USING btree (user_id);
 CREATE INDEX idx_users_active ON auth.users USING btree (active);
 
 
+--
+-- Name: idx_users_deletion_scheduled_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_users_deletion_scheduled_at ON auth.users USING btree (deletion_scheduled_at) WHERE (deletion_scheduled_at IS NOT NULL);
+
+
 --
 -- Name: idx_users_email; Type: INDEX; Schema: auth; Owner: postgres
 --
@@ -483,6 +1112,48 @@ CREATE INDEX idx_verification_tokens_hash ON auth.verification_tokens USING btre
 CREATE INDEX idx_verification_tokens_user_id ON auth.verification_tokens USING btree (user_id);
 
 
+--
+-- Name: idx_webauthn_challenges_expires_at; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_webauthn_challenges_expires_at ON auth.webauthn_challenges USING btree (expires_at);
+
+
+--
+-- Name: idx_webauthn_credentials_user_id; Type: INDEX; Schema: auth; Owner: postgres
+--
+
+CREATE INDEX idx_webauthn_credentials_user_id ON auth.webauthn_credentials USING btree (user_id);
+
+
+--
+-- Name: idx_dead_letters_failed_at; Type: INDEX; Schema: jobs; Owner: postgres
+--
+
+CREATE INDEX idx_dead_letters_failed_at ON jobs.dead_letters USING btree (failed_at);
+
+
+--
+-- Name: idx_dead_letters_handler; Type: INDEX; Schema: jobs; Owner: postgres
+--
+
+CREATE INDEX idx_dead_letters_handler ON jobs.dead_letters USING btree (handler);
+
+
+--
+-- Name: idx_queue_pending_run_at; Type: INDEX; Schema: jobs; Owner: postgres
+--
+
+CREATE INDEX idx_queue_pending_run_at ON jobs.queue USING btree (run_at) WHERE ((status)::text = 'pending'::text);
+
+
+--
+-- Name: idx_queue_running_locked_at; Type: INDEX; Schema: jobs; Owner: postgres
+--
+
+CREATE INDEX idx_queue_running_locked_at ON jobs.queue USING btree (locked_at) WHERE ((status)::text = 'running'::text);
+
+
 --
 -- Name: schema_migration_version_idx; Type: INDEX; Schema: public; Owner: postgres
 --
@@ -490,6 +1161,13 @@ CREATE INDEX idx_verification_tokens_user_id ON auth.verification_tokens USING b
 CREATE UNIQUE INDEX schema_migration_version_idx ON public.schema_migration USING btree (version);
 
 
+--
+-- Name: audit_events audit_events_append_only; Type: TRIGGER; Schema: auth; Owner: postgres
+--
+
+CREATE TRIGGER audit_events_append_only BEFORE DELETE OR UPDATE ON auth.audit_events FOR EACH ROW EXECUTE FUNCTION auth.audit_events_append_only();
+
+
 --
 -- Name: oauth_providers update_oauth_providers_updated_at; Type: TRIGGER; Schema: auth; Owner: postgres
 --
@@ -528,6 +1206,38 @@ ALTER TABLE ONLY auth.oauth_providers
     ADD CONSTRAINT oauth_providers_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: oauth_states oauth_states_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.oauth_states
+    ADD CONSTRAINT oauth_states_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: password_history password_history_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.password_history
+    ADD CONSTRAINT password_history_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: personal_access_tokens personal_access_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.personal_access_tokens
+    ADD CONSTRAINT personal_access_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: rotated_refresh_tokens rotated_refresh_tokens_session_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.rotated_refresh_tokens
+    ADD CONSTRAINT rotated_refresh_tokens_session_id_fkey FOREIGN KEY (session_id) REFERENCES auth.sessions(id) ON DELETE CASCADE;
+
+
 --
 -- Name: sessions sessions_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
 --
@@ -544,6 +1254,14 @@ ALTER TABLE ONLY auth.two_factor_backup_codes
     ADD CONSTRAINT two_factor_backup_codes_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: two_factor_setups two_factor_setups_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.two_factor_setups
+    ADD CONSTRAINT two_factor_setups_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
 --
 -- Name: verification_tokens verification_tokens_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
 --
@@ -552,6 +1270,22 @@ ALTER TABLE ONLY auth.verification_tokens
     ADD CONSTRAINT verification_tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
 
 
+--
+-- Name: webauthn_challenges webauthn_challenges_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.webauthn_challenges
+    ADD CONSTRAINT webauthn_challenges_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: webauthn_credentials webauthn_credentials_user_id_fkey; Type: FK CONSTRAINT; Schema: auth; Owner: postgres
+--
+
+ALTER TABLE ONLY auth.webauthn_credentials
+    ADD CONSTRAINT webauthn_credentials_user_id_fkey FOREIGN KEY (user_id) REFERENCES auth.users(id) ON DELETE CASCADE;
+
+
 --
 -- PostgreSQL database dump complete
 --
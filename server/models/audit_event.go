@@ -0,0 +1,32 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// AuditEvent is an entry of the append-only security log.
+type AuditEvent struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	EventType string `db:"event_type" json:"event_type"`
+
+	// Quién lo hizo (null si es anónimo) y a qué cuenta afecta
+	ActorID  *uuid.UUID `db:"actor_id" json:"actor_id,omitempty"`
+	TargetID *uuid.UUID `db:"target_id" json:"target_id,omitempty"`
+
+	// INET lo representamos como string
+	IPAddress *string `db:"ip_address" json:"ip_address,omitempty"`
+	UserAgent *string `db:"user_agent" json:"user_agent,omitempty"`
+
+	// JSONB
+	Metadata json.RawMessage `db:"metadata" json:"metadata"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (e AuditEvent) TableName() string { return "auth.audit_events" }
+
+type AuditEvents []AuditEvent
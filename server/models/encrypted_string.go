@@ -0,0 +1,135 @@
+package models
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strings"
+
+	"server/encryption"
+
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// Encryption keys are configured as a comma separated list of kid=base64
+// pairs of 32 byte keys, e.g. ENCRYPTION_KEYS="2026-01=...,2025-07=...".
+// ENCRYPTION_KEY_ID selects the key that seals new values; the others are
+// kept until `buffalo task db:rotate-encryption-keys` has re-wrapped
+// everything sealed with them.
+var Keys *encryption.Keyring
+
+func init() {
+	var err error
+	Keys, err = loadKeys(envy.Get("ENCRYPTION_KEYS", ""), envy.Get("ENCRYPTION_KEY_ID", ""))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func loadKeys(spec, currentID string) (*encryption.Keyring, error) {
+	if strings.TrimSpace(spec) != "" {
+		return encryption.New(spec, currentID)
+	}
+
+	if envy.Get("GO_ENV", "development") == "production" {
+		return nil, fmt.Errorf("ENCRYPTION_KEYS must be configured in production")
+	}
+
+	// Sin llaves configuradas: llave fija solo para desarrollo/tests, así
+	// los datos sobreviven reinicios
+	devKey := sha256.Sum256([]byte("redorange development encryption key"))
+	log.Printf("[WARN] ENCRYPTION_KEYS not set, using the development key")
+	return encryption.New("dev="+base64.StdEncoding.EncodeToString(devKey[:]), "")
+}
+
+// EncryptedString is sealed with Keys when written and opened when read, so
+// models use it as a plain string.
+type EncryptedString string
+
+func (s EncryptedString) Value() (driver.Value, error) {
+	return Keys.Encrypt([]byte(s))
+}
+
+func (s *EncryptedString) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EncryptedString", src)
+	}
+
+	plain, err := Keys.Decrypt(value)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plain)
+	return nil
+}
+
+// EncryptedColumns lists the columns stored as EncryptedString.
+var EncryptedColumns = []struct{ Table, Column string }{
+	{"auth.users", "two_factor_secret"},
+	{"auth.oauth_providers", "access_token"},
+	{"auth.oauth_providers", "refresh_token"},
+	{"auth.two_factor_setups", "secret"},
+}
+
+type encryptedValue struct {
+	ID    uuid.UUID `db:"id"`
+	Value string    `db:"value"`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// RotateEncryption re-wraps every value in EncryptedColumns that isn't
+// sealed with the current key, sealing legacy plaintext on the way, and
+// returns how many rows changed per column.
+func RotateEncryption(db *pop.Connection) (map[string]int, error) {
+	const batchSize = 500
+
+	rotated := map[string]int{}
+	current := likeEscaper.Replace(encryption.Prefix+Keys.Current()+":") + "%"
+
+	for _, col := range EncryptedColumns {
+		name := col.Table + "." + col.Column
+		for {
+			var rows []encryptedValue
+			err := db.RawQuery(fmt.Sprintf(
+				"SELECT id, %[1]s AS value FROM %[2]s WHERE %[1]s IS NOT NULL AND %[1]s NOT LIKE ? ESCAPE '\\' ORDER BY id LIMIT %[3]d",
+				col.Column, col.Table, batchSize,
+			), current).All(&rows)
+			if err != nil {
+				return rotated, fmt.Errorf("%s: %w", name, err)
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			for _, row := range rows {
+				value, changed, err := Keys.Rotate(row.Value)
+				if err != nil {
+					return rotated, fmt.Errorf("%s %s: %w", name, row.ID, err)
+				}
+				if !changed {
+					// sealed with the current key but not matching the
+					// pattern would loop forever
+					return rotated, fmt.Errorf("%s %s: value not rotated", name, row.ID)
+				}
+				err = db.RawQuery(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", col.Table, col.Column), value, row.ID).Exec()
+				if err != nil {
+					return rotated, fmt.Errorf("%s %s: %w", name, row.ID, err)
+				}
+				rotated[name]++
+			}
+		}
+	}
+
+	return rotated, nil
+}
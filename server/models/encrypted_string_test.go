@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+
+	"server/encryption"
+)
+
+// useKeys swaps Keys for a keyring with the given kids, sealing with the
+// first one, until the test ends.
+func (ms *ModelSuite) useKeys(kids ...string) *encryption.Keyring {
+	entries := make([]string, len(kids))
+	for i, kid := range kids {
+		key, err := encryption.GenerateKey()
+		ms.NoError(err)
+		entries[i] = kid + "=" + key
+	}
+	keys, err := encryption.New(strings.Join(entries, ","), kids[0])
+	ms.NoError(err)
+
+	previous := Keys
+	Keys = keys
+	ms.T().Cleanup(func() { Keys = previous })
+	return keys
+}
+
+func (ms *ModelSuite) createUserWithSecret(email, storedSecret string) User {
+	user := User{Email: email, Name: "Test", LastName: "User", Role: "support", Active: true}
+	ms.NoError(ms.DB.Create(&user))
+	ms.NoError(ms.DB.RawQuery("UPDATE auth.users SET two_factor_secret = ? WHERE id = ?", storedSecret, user.ID).Exec())
+	return user
+}
+
+func (ms *ModelSuite) storedSecret(user User) string {
+	var row encryptedValue
+	ms.NoError(ms.DB.RawQuery("SELECT id, two_factor_secret AS value FROM auth.users WHERE id = ?", user.ID).First(&row))
+	return row.Value
+}
+
+func (ms *ModelSuite) Test_RotateEncryption() {
+	// "keyx1" matches "key_1" unless the underscore is escaped
+	old := ms.useKeys("keyx1")
+	sealedWithOld, err := old.Encrypt([]byte("OLD-SECRET"))
+	ms.NoError(err)
+
+	keys := ms.useKeys("key_1", "keyx1")
+	sealedWithCurrent, err := keys.Encrypt([]byte("CURRENT-SECRET"))
+	ms.NoError(err)
+
+	legacy := ms.createUserWithSecret("legacy@example.com", "LEGACY-SECRET")
+	rewrapped := ms.createUserWithSecret("old@example.com", sealedWithOld)
+	current := ms.createUserWithSecret("current@example.com", sealedWithCurrent)
+
+	rotated, err := RotateEncryption(ms.DB)
+	ms.NoError(err)
+	ms.Equal(map[string]int{"auth.users.two_factor_secret": 2}, rotated)
+
+	for _, tc := range []struct {
+		user   User
+		secret string
+	}{
+		{legacy, "LEGACY-SECRET"},
+		{rewrapped, "OLD-SECRET"},
+		{current, "CURRENT-SECRET"},
+	} {
+		ms.True(strings.HasPrefix(ms.storedSecret(tc.user), encryption.Prefix+"key_1:"), tc.user.Email)
+
+		var reloaded User
+		ms.NoError(ms.DB.Find(&reloaded, tc.user.ID))
+		ms.NotNil(reloaded.TwoFactorSecret)
+		ms.Equal(tc.secret, string(*reloaded.TwoFactorSecret), tc.user.Email)
+	}
+	ms.Equal(sealedWithCurrent, ms.storedSecret(current))
+
+	rotated, err = RotateEncryption(ms.DB)
+	ms.NoError(err)
+	ms.Empty(rotated)
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type Job struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	Queue   string `db:"queue" json:"queue"`
+	Handler string `db:"handler" json:"handler"`
+
+	// JSONB
+	Args json.RawMessage `db:"args" json:"args"`
+
+	// pending, running
+	Status      string    `db:"status" json:"status"`
+	Attempts    int       `db:"attempts" json:"attempts"`
+	MaxAttempts int       `db:"max_attempts" json:"max_attempts"`
+	RunAt       time.Time `db:"run_at" json:"run_at"`
+
+	LockedAt  *time.Time `db:"locked_at" json:"locked_at,omitempty"`
+	LockedBy  *string    `db:"locked_by" json:"locked_by,omitempty"`
+	LastError *string    `db:"last_error" json:"last_error,omitempty"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (j Job) TableName() string { return "jobs.queue" }
+
+type Jobs []Job
+
+type DeadLetter struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	Queue   string `db:"queue" json:"queue"`
+	Handler string `db:"handler" json:"handler"`
+
+	// JSONB
+	Args json.RawMessage `db:"args" json:"args"`
+
+	Attempts  int    `db:"attempts" json:"attempts"`
+	LastError string `db:"last_error" json:"last_error"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	FailedAt  time.Time `db:"failed_at" json:"failed_at"`
+}
+
+func (d DeadLetter) TableName() string { return "jobs.dead_letters" }
+
+type DeadLetters []DeadLetter
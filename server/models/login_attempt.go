@@ -19,6 +19,21 @@ type LoginAttempt struct {
 	IPAddress *string `db:"ip_address" json:"ip_address,omitempty"`
 	UserAgent *string `db:"user_agent" json:"user_agent,omitempty"`
 
+	// jti del temp token en intentos de 2FA
+	TokenID *uuid.UUID `db:"token_id" json:"-"`
+
+	// user agent y GeoIP resueltos al registrar el intento
+	Browser        *string `db:"browser" json:"browser,omitempty"`
+	BrowserVersion *string `db:"browser_version" json:"browser_version,omitempty"`
+	OS             *string `db:"os" json:"os,omitempty"`
+	OSVersion      *string `db:"os_version" json:"os_version,omitempty"`
+	DeviceType     *string `db:"device_type" json:"device_type,omitempty"`
+	CountryCode    *string `db:"country_code" json:"country_code,omitempty"`
+	Country        *string `db:"country" json:"country,omitempty"`
+	City           *string `db:"city" json:"city,omitempty"`
+	ASN            *int64  `db:"asn" json:"asn,omitempty"`
+	ASOrg          *string `db:"as_org" json:"as_org,omitempty"`
+
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
 
@@ -15,10 +15,10 @@ type OAuthProvider struct {
 	ProviderUserID string  `db:"provider_user_id" json:"provider_user_id"`       // id externo
 	ProviderEmail  *string `db:"provider_email" json:"provider_email,omitempty"` // correo externo
 
-	// Tokens NO se exponen en JSON por seguridad
-	AccessToken  *string    `db:"access_token" json:"-"`
-	RefreshToken *string    `db:"refresh_token" json:"-"`
-	ExpiresAt    *time.Time `db:"expires_at" json:"expires_at,omitempty"`
+	// Tokens NO se exponen en JSON por seguridad; se cifran en reposo
+	AccessToken  *EncryptedString `db:"access_token" json:"-"`
+	RefreshToken *EncryptedString `db:"refresh_token" json:"-"`
+	ExpiresAt    *time.Time       `db:"expires_at" json:"expires_at,omitempty"`
 
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
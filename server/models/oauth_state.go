@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type OAuthState struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	// No exponer hash
+	StateHash string `db:"state_hash" json:"-"`
+	Provider  string `db:"provider" json:"provider"`
+
+	RedirectURI string  `db:"redirect_uri" json:"redirect_uri"`
+	ClientState *string `db:"client_state" json:"client_state,omitempty"`
+
+	// PKCE y nonce OIDC, no exponer
+	CodeVerifier string  `db:"code_verifier" json:"-"`
+	Nonce        *string `db:"nonce" json:"-"`
+
+	// usuario que vincula el proveedor; nil en un login
+	UserID *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+
+	Used   bool       `db:"used" json:"used"`
+	UsedAt *time.Time `db:"used_at" json:"used_at,omitempty"`
+}
+
+func (s OAuthState) TableName() string { return "auth.oauth_states" }
+
+type OAuthStates []OAuthState
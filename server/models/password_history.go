@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// PasswordHistory is a password the user had before the current one.
+type PasswordHistory struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+
+	// No exponer hash
+	PasswordHash string `db:"password_hash" json:"-"`
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (h PasswordHistory) TableName() string { return "auth.password_history" }
+
+type PasswordHistories []PasswordHistory
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop/v6/slices"
+	"github.com/gofrs/uuid"
+)
+
+// PersonalAccessToken lets a machine client call the API as its user,
+// limited to Scopes.
+type PersonalAccessToken struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	Name   string    `db:"name" json:"name"`
+
+	// No exponer hash; el prefijo identifica el token en listados
+	TokenHash   string        `db:"token_hash" json:"-"`
+	TokenPrefix string        `db:"token_prefix" json:"token_prefix"`
+	Scopes      slices.String `db:"scopes" json:"scopes"`
+
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+
+	// INET lo representamos como string
+	LastUsedAt        *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+	LastUsedIP        *string    `db:"last_used_ip" json:"last_used_ip,omitempty"`
+	LastUsedUserAgent *string    `db:"last_used_user_agent" json:"last_used_user_agent,omitempty"`
+
+	Revoked   bool       `db:"revoked" json:"revoked"`
+	RevokedAt *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+}
+
+func (p PersonalAccessToken) TableName() string { return "auth.personal_access_tokens" }
+
+type PersonalAccessTokens []PersonalAccessToken
+
+// HasScope reports whether the token was created with scope.
+func (p PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type RolePermission struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	Role       string `db:"role" json:"role"`             // support, admin, dev
+	Permission string `db:"permission" json:"permission"` // users:read, users:manage, etc.
+
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (p RolePermission) TableName() string { return "auth.role_permissions" }
+
+type RolePermissions []RolePermission
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type RotatedRefreshToken struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	SessionID uuid.UUID `db:"session_id" json:"session_id"`
+
+	// No exponer hash
+	TokenHash string `db:"token_hash" json:"-"`
+
+	RotatedAt time.Time `db:"rotated_at" json:"rotated_at"`
+}
+
+func (t RotatedRefreshToken) TableName() string { return "auth.rotated_refresh_tokens" }
+
+type RotatedRefreshTokens []RotatedRefreshToken
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// TwoFactorSetup holds a TOTP secret between POST /auth/2fa/enable and the
+// first valid code. There is at most one per user.
+type TwoFactorSetup struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+
+	// No exponer hash ni secreto
+	TokenHash string          `db:"token_hash" json:"-"`
+	Secret    EncryptedString `db:"secret" json:"-"`
+
+	// sha256 de los códigos sin guiones, separados por comas
+	BackupCodeHashes string `db:"backup_code_hashes" json:"-"`
+
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (s TwoFactorSetup) TableName() string { return "auth.two_factor_setups" }
+
+type TwoFactorSetups []TwoFactorSetup
@@ -21,12 +21,16 @@ type User struct {
 	Role   string `db:"role" json:"role"`
 	Active bool   `db:"active" json:"active"`
 
-	TwoFactorEnabled bool    `db:"two_factor_enabled" json:"two_factor_enabled"`
-	TwoFactorSecret  *string `db:"two_factor_secret" json:"-"`
+	TwoFactorEnabled bool             `db:"two_factor_enabled" json:"two_factor_enabled"`
+	TwoFactorSecret  *EncryptedString `db:"two_factor_secret" json:"-"` // cifrado en reposo
+	TOTPLastStep     *int64           `db:"totp_last_step" json:"-"`    // último paso TOTP usado
 
 	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
 	LastLoginAt *time.Time `db:"last_login_at" json:"last_login_at,omitempty"`
+
+	// Fecha en que se borra la cuenta, si el usuario lo pidió
+	DeletionScheduledAt *time.Time `db:"deletion_scheduled_at" json:"deletion_scheduled_at,omitempty"`
 }
 
 func (u User) TableName() string { return "auth.users" }
@@ -15,7 +15,10 @@ type VerificationToken struct {
 	// No exponer hash
 	TokenHash string `db:"token_hash" json:"-"`
 
-	TokenType string `db:"token_type" json:"token_type"` // email_verification, password_reset, 2fa_setup
+	TokenType string `db:"token_type" json:"token_type"` // email_verification, password_reset, magic_link, email_change, email_change_cancel, login_step_up
+
+	// Hash del secreto del navegador que pidió el magic link
+	DeviceHash *string `db:"device_hash" json:"-"`
 
 	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
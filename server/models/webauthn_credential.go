@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type WebAuthnCredential struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	Name   string    `db:"name" json:"name"`
+
+	// Registro de la credencial, no exponer
+	CredentialID    []byte  `db:"credential_id" json:"-"`
+	PublicKey       []byte  `db:"public_key" json:"-"`
+	AttestationType string  `db:"attestation_type" json:"-"`
+	AAGUID          []byte  `db:"aaguid" json:"-"`
+	Transports      *string `db:"transports" json:"-"` // separados por coma
+	SignCount       int64   `db:"sign_count" json:"-"`
+	CloneWarning    bool    `db:"clone_warning" json:"clone_warning"`
+
+	UserVerified   bool `db:"user_verified" json:"user_verified"`
+	BackupEligible bool `db:"backup_eligible" json:"backup_eligible"`
+	BackupState    bool `db:"backup_state" json:"backup_state"`
+
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at" json:"updated_at"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"last_used_at,omitempty"`
+}
+
+func (w WebAuthnCredential) TableName() string { return "auth.webauthn_credentials" }
+
+type WebAuthnCredentials []WebAuthnCredential
+
+type WebAuthnChallenge struct {
+	ID uuid.UUID `db:"id" json:"id"`
+
+	UserID   *uuid.UUID `db:"user_id" json:"user_id,omitempty"`
+	Ceremony string     `db:"ceremony" json:"ceremony"` // registration, login, second_factor
+
+	// JSONB, no exponer
+	SessionData json.RawMessage `db:"session_data" json:"-"`
+
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (w WebAuthnChallenge) TableName() string { return "auth.webauthn_challenges" }
+
+type WebAuthnChallenges []WebAuthnChallenge
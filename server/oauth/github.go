@@ -0,0 +1,98 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GitHub is a plain OAuth2 provider: no ID token, the user comes from the
+// REST API.
+type GitHub struct {
+	cfg Config
+
+	AuthURL  string
+	TokenURL string
+	APIURL   string
+	// Client overrides HTTPClient.
+	Client *http.Client
+}
+
+// NewGitHub returns github.com. Point the URLs elsewhere for GitHub
+// Enterprise Server.
+func NewGitHub(cfg Config) *GitHub {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	return &GitHub{
+		cfg:      cfg,
+		AuthURL:  "https://github.com/login/oauth/authorize",
+		TokenURL: "https://github.com/login/oauth/access_token",
+		APIURL:   "https://api.github.com",
+	}
+}
+
+func (g *GitHub) Name() string { return "github" }
+
+func (g *GitHub) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	return authCodeURL(g.AuthURL, g.cfg, req, nil), nil
+}
+
+func (g *GitHub) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	return exchangeCode(ctx, g.Client, g.TokenURL, g.cfg, code, codeVerifier)
+}
+
+type githubUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// Identity reads /user and takes the primary address from /user/emails,
+// which (unlike the public email on the profile) says whether it is
+// verified.
+func (g *GitHub) Identity(ctx context.Context, tok *Token, nonce string) (*Identity, error) {
+	api := strings.TrimRight(g.APIURL, "/")
+
+	var user githubUser
+	if err := getJSON(ctx, g.Client, api+"/user", tok.AccessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("oauth: github user without id")
+	}
+
+	var emails []githubEmail
+	if err := getJSON(ctx, g.Client, api+"/user/emails", tok.AccessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	id := &Identity{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Name:    user.Name,
+		Picture: user.AvatarURL,
+	}
+	if id.Name == "" {
+		id.Name = user.Login
+	}
+	if given, family, ok := strings.Cut(id.Name, " "); ok {
+		id.GivenName, id.FamilyName = given, family
+	}
+	for _, e := range emails {
+		if e.Primary {
+			id.Email = e.Email
+			id.EmailVerified = e.Verified
+			break
+		}
+	}
+	return id, nil
+}
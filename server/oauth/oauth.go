@@ -0,0 +1,177 @@
+// Package oauth implements the authorization code flow (with PKCE) against
+// the external identity providers users can sign in with: GitHub, Microsoft
+// Entra ID and any OpenID Connect issuer, Google included.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPClient is used for every call to a provider unless the provider sets
+// its own.
+var HTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+var ErrInvalidIDToken = errors.New("oauth: invalid id token")
+
+// Config is the client registration at the provider.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// AuthParams are extra query parameters for the authorization URL,
+	// e.g. prompt=consent.
+	AuthParams url.Values
+}
+
+// AuthRequest holds the per-request values sent to the authorization
+// endpoint. Nonce is ignored by providers without ID tokens.
+type AuthRequest struct {
+	State         string
+	CodeChallenge string
+	Nonce         string
+}
+
+// Token is the token endpoint response.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// Identity is the user as reported by the provider. Subject is the stable
+// provider user id; EmailVerified is only true when the provider says so.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+	Picture       string
+}
+
+// Provider is an external identity provider.
+type Provider interface {
+	Name() string
+	AuthCodeURL(ctx context.Context, req AuthRequest) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier string) (*Token, error)
+	// Identity resolves the user behind tok. nonce is the value sent in the
+	// AuthRequest and must match the ID token, when there is one.
+	Identity(ctx context.Context, tok *Token, nonce string) (*Identity, error)
+}
+
+// -- shared oauth2 plumbing
+
+func authCodeURL(endpoint string, cfg Config, req AuthRequest, extra url.Values) string {
+	params := url.Values{}
+	params.Set("client_id", cfg.ClientID)
+	params.Set("redirect_uri", cfg.RedirectURL)
+	params.Set("response_type", "code")
+	params.Set("scope", strings.Join(cfg.Scopes, " "))
+	params.Set("state", req.State)
+	if req.CodeChallenge != "" {
+		params.Set("code_challenge", req.CodeChallenge)
+		params.Set("code_challenge_method", "S256")
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	for k, v := range cfg.AuthParams {
+		params[k] = v
+	}
+
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+	return endpoint + sep + params.Encode()
+}
+
+func exchangeCode(ctx context.Context, client *http.Client, endpoint string, cfg Config, code, codeVerifier string) (*Token, error) {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", cfg.RedirectURL)
+	data.Set("client_id", cfg.ClientID)
+	data.Set("client_secret", cfg.ClientSecret)
+	if codeVerifier != "" {
+		data.Set("code_verifier", codeVerifier)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// github answers with a form encoded body unless asked for json
+	req.Header.Set("Accept", "application/json")
+
+	var body struct {
+		Token
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := doJSON(client, req, &body)
+	if err != nil {
+		return nil, err
+	}
+	if body.Error != "" {
+		return nil, fmt.Errorf("oauth: token endpoint: %s: %s", body.Error, body.ErrorDescription)
+	}
+	if status != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("oauth: token endpoint returned %d", status)
+	}
+	return &body.Token, nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	status, err := doJSON(client, req, v)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("oauth: GET %s returned %d", endpoint, status)
+	}
+	return nil
+}
+
+func doJSON(client *http.Client, req *http.Request, v any) (int, error) {
+	if client == nil {
+		client = HTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return resp.StatusCode, fmt.Errorf("oauth: decode %s: %w", req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,257 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingMethods are the ID token algorithms we accept. Never HS* (the
+// client secret is not a signing key here) and never none.
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// tenantPlaceholder appears in the issuer of multi-tenant Entra ID
+// discovery documents; the real issuer carries the tid claim instead.
+const tenantPlaceholder = "{tenantid}"
+
+// Discovery is the part of the provider metadata
+// (/.well-known/openid-configuration) we use.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDC is an OpenID Connect provider. Endpoints come from the issuer's
+// discovery document, fetched on first use, and the user comes from the ID
+// token after checking its signature against the issuer's JWKS.
+type OIDC struct {
+	name   string
+	issuer string
+	cfg    Config
+
+	// Client overrides HTTPClient.
+	Client *http.Client
+	// EmailVerifiedClaim is the claim that says the email was verified by
+	// the provider, email_verified unless the provider uses another one.
+	EmailVerifiedClaim string
+
+	// other spellings of the issuer found in iss (google uses both)
+	issuerAliases []string
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      *keySet
+}
+
+// NewOIDC returns a provider for issuer. Scopes default to openid, email
+// and profile.
+func NewOIDC(name, issuer string, cfg Config) *OIDC {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &OIDC{
+		name:               name,
+		issuer:             strings.TrimRight(issuer, "/"),
+		cfg:                cfg,
+		EmailVerifiedClaim: "email_verified",
+	}
+}
+
+// NewGoogle returns Google as an OIDC provider, asking for a refresh token.
+func NewGoogle(cfg Config) *OIDC {
+	if cfg.AuthParams == nil {
+		cfg.AuthParams = url.Values{"access_type": {"offline"}, "prompt": {"consent"}}
+	}
+	p := NewOIDC("google", "https://accounts.google.com", cfg)
+	p.issuerAliases = []string{"accounts.google.com"}
+	return p
+}
+
+// NewEntra returns Microsoft Entra ID for tenant, which may be a tenant id
+// or common / organizations / consumers. Entra does not assert that emails
+// are verified unless the optional xms_edov claim is configured on the app.
+func NewEntra(tenant string, cfg Config) *OIDC {
+	if tenant == "" {
+		tenant = "common"
+	}
+	p := NewOIDC("microsoft", "https://login.microsoftonline.com/"+tenant+"/v2.0", cfg)
+	p.EmailVerifiedClaim = "xms_edov"
+	return p
+}
+
+func (o *OIDC) Name() string   { return o.name }
+func (o *OIDC) Issuer() string { return o.issuer }
+
+func (o *OIDC) AuthCodeURL(ctx context.Context, req AuthRequest) (string, error) {
+	d, err := o.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	extra := url.Values{}
+	if req.Nonce != "" {
+		extra.Set("nonce", req.Nonce)
+	}
+	return authCodeURL(d.AuthorizationEndpoint, o.cfg, req, extra), nil
+}
+
+func (o *OIDC) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	d, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return exchangeCode(ctx, o.Client, d.TokenEndpoint, o.cfg, code, codeVerifier)
+}
+
+func (o *OIDC) Identity(ctx context.Context, tok *Token, nonce string) (*Identity, error) {
+	if tok.IDToken == "" {
+		return nil, fmt.Errorf("%w: missing from token response", ErrInvalidIDToken)
+	}
+	claims, err := o.VerifyIDToken(ctx, tok.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identity{
+		Subject:       claimString(claims, "sub"),
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, o.EmailVerifiedClaim),
+		Name:          claimString(claims, "name"),
+		GivenName:     claimString(claims, "given_name"),
+		FamilyName:    claimString(claims, "family_name"),
+		Picture:       claimString(claims, "picture"),
+	}
+
+	// some issuers keep the profile out of the id token
+	d, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id.Email == "" && d.UserinfoEndpoint != "" && tok.AccessToken != "" {
+		info := map[string]any{}
+		if err := getJSON(ctx, o.Client, d.UserinfoEndpoint, tok.AccessToken, &info); err != nil {
+			return nil, err
+		}
+		if claimString(info, "sub") != id.Subject {
+			return nil, errors.New("oauth: userinfo subject does not match id token")
+		}
+		id.Email = claimString(info, "email")
+		id.EmailVerified = claimBool(info, o.EmailVerifiedClaim)
+		if id.Name == "" {
+			id.Name = claimString(info, "name")
+			id.GivenName = claimString(info, "given_name")
+			id.FamilyName = claimString(info, "family_name")
+		}
+		if id.Picture == "" {
+			id.Picture = claimString(info, "picture")
+		}
+	}
+	return id, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of
+// an ID token and returns its claims.
+func (o *OIDC) VerifyIDToken(ctx context.Context, raw, nonce string) (jwt.MapClaims, error) {
+	d, err := o.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return o.keys.key(ctx, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if !o.validIssuer(d, claims) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claimString(claims, "iss"))
+	}
+	if azp := claimString(claims, "azp"); azp != "" && azp != o.cfg.ClientID {
+		return nil, fmt.Errorf("%w: issued to another client", ErrInvalidIDToken)
+	}
+	if nonce != "" && claimString(claims, "nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claimString(claims, "sub") == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func (o *OIDC) validIssuer(d *Discovery, claims jwt.MapClaims) bool {
+	iss := claimString(claims, "iss")
+	expected := d.Issuer
+	if strings.Contains(expected, tenantPlaceholder) {
+		tid := claimString(claims, "tid")
+		if tid == "" {
+			return false
+		}
+		expected = strings.ReplaceAll(expected, tenantPlaceholder, tid)
+	}
+	return iss == expected || slices.Contains(o.issuerAliases, iss)
+}
+
+// discover fetches and caches the discovery document. Failures are not
+// cached, so a provider that was down at boot recovers on the next login.
+func (o *OIDC) discover(ctx context.Context) (*Discovery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.discovery != nil {
+		return o.discovery, nil
+	}
+
+	var d Discovery
+	if err := getJSON(ctx, o.Client, o.issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, err
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("oauth: incomplete discovery document for %s", o.issuer)
+	}
+	// OIDC Discovery 4.3: the document must be for the issuer we asked for
+	if !strings.Contains(d.Issuer, tenantPlaceholder) && strings.TrimRight(d.Issuer, "/") != o.issuer {
+		return nil, fmt.Errorf("oauth: discovery issuer %q does not match %q", d.Issuer, o.issuer)
+	}
+
+	o.discovery = &d
+	o.keys = newKeySet(d.JWKSURI, o.Client)
+	return o.discovery, nil
+}
+
+func claimString(claims map[string]any, name string) string {
+	s, _ := claims[name].(string)
+	return s
+}
+
+// claimBool accepts true and "true"; some providers send strings.
+func claimBool(claims map[string]any, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"server/oauth/oauthtest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newStubProvider(t *testing.T) (*oauthtest.Server, *OIDC) {
+	t.Helper()
+	srv := oauthtest.NewServer("client-1", "secret-1")
+	t.Cleanup(srv.Close)
+	return srv, NewOIDC("stub", srv.URL, Config{
+		ClientID:     "client-1",
+		ClientSecret: "secret-1",
+		RedirectURL:  "http://localhost:8000/api/v1/auth/oauth/stub/callback",
+	})
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func Test_OIDC_Flow(t *testing.T) {
+	srv, p := newStubProvider(t)
+	ctx := context.Background()
+	verifier := "0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	authURL, err := p.AuthCodeURL(ctx, AuthRequest{State: "st", CodeChallenge: challenge(verifier), Nonce: "n-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, state, err := srv.Authorize(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != "st" {
+		t.Fatalf("state = %q", state)
+	}
+
+	if _, err := p.Exchange(ctx, code, "wrong-verifier"); err == nil {
+		t.Fatal("exchange with a wrong PKCE verifier succeeded")
+	}
+
+	code, _, _ = srv.Authorize(authURL)
+	tok, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := p.Identity(ctx, tok, "n-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != srv.User.Subject || id.Email != srv.User.Email || !id.EmailVerified {
+		t.Fatalf("identity = %+v", id)
+	}
+
+	if _, err := p.Identity(ctx, tok, "other-nonce"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("nonce mismatch: err = %v", err)
+	}
+}
+
+func Test_OIDC_VerifyIDToken(t *testing.T) {
+	srv, p := newStubProvider(t)
+	other := oauthtest.NewServer("client-1", "secret-1")
+	defer other.Close()
+	ctx := context.Background()
+
+	if _, err := p.VerifyIDToken(ctx, srv.IDToken(nil), ""); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+
+	hs256, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": srv.URL, "aud": "client-1", "sub": "x", "exp": time.Now().Add(time.Minute).Unix(),
+	}).SignedString([]byte("secret-1"))
+
+	tests := map[string]string{
+		"wrong audience": srv.IDToken(jwt.MapClaims{"aud": "client-2"}),
+		"expired":        srv.IDToken(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}),
+		"wrong issuer":   srv.IDToken(jwt.MapClaims{"iss": other.URL}),
+		"other azp":      srv.IDToken(jwt.MapClaims{"azp": "client-2"}),
+		"no subject":     srv.IDToken(jwt.MapClaims{"sub": ""}),
+		"unknown key":    other.IDToken(jwt.MapClaims{"iss": srv.URL}),
+		"hs256":          hs256,
+	}
+	for name, raw := range tests {
+		if _, err := p.VerifyIDToken(ctx, raw, ""); !errors.Is(err, ErrInvalidIDToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidIDToken", name, err)
+		}
+	}
+}
+
+func Test_OIDC_KeyRotation(t *testing.T) {
+	srv, p := newStubProvider(t)
+	ctx := context.Background()
+
+	defer func(d time.Duration) { KeyRefreshInterval = d }(KeyRefreshInterval)
+	KeyRefreshInterval = 0
+
+	if _, err := p.VerifyIDToken(ctx, srv.IDToken(nil), ""); err != nil {
+		t.Fatal(err)
+	}
+	srv.RotateKey()
+	if _, err := p.VerifyIDToken(ctx, srv.IDToken(nil), ""); err != nil {
+		t.Fatalf("token signed with rotated key rejected: %v", err)
+	}
+}
+
+func Test_OIDC_TenantIssuer(t *testing.T) {
+	p := NewEntra("common", Config{ClientID: "client-1"})
+	d := &Discovery{Issuer: "https://login.microsoftonline.com/{tenantid}/v2.0"}
+
+	ok := p.validIssuer(d, jwt.MapClaims{"iss": "https://login.microsoftonline.com/t-1/v2.0", "tid": "t-1"})
+	if !ok {
+		t.Fatal("issuer for the token's tenant rejected")
+	}
+	ok = p.validIssuer(d, jwt.MapClaims{"iss": "https://login.microsoftonline.com/t-2/v2.0", "tid": "t-1"})
+	if ok {
+		t.Fatal("issuer for another tenant accepted")
+	}
+}
+
+func Test_FromEnv(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_CLIENT_ID":        "gh",
+		"OIDC_PROVIDERS":          "acme-sso",
+		"OIDC_ACME_SSO_ISSUER":    "https://sso.acme.test",
+		"OIDC_ACME_SSO_CLIENT_ID": "acme",
+		"OIDC_ACME_SSO_SCOPES":    "openid email",
+		"OAUTH_CALLBACK_URL":      "https://api.redorange.pe/api/v1/auth/oauth/",
+	}
+	getenv := func(key, fallback string) string {
+		if v, ok := env[key]; ok && v != "" {
+			return v
+		}
+		return fallback
+	}
+
+	registry, err := FromEnv(getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := registry.Names(); len(names) != 2 || names[0] != "github" || names[1] != "acme-sso" {
+		t.Fatalf("names = %v", names)
+	}
+	p, _ := registry.Get("acme-sso")
+	if got := p.(*OIDC).cfg.RedirectURL; got != "https://api.redorange.pe/api/v1/auth/oauth/acme-sso/callback" {
+		t.Fatalf("redirect = %q", got)
+	}
+
+	delete(env, "OIDC_ACME_SSO_ISSUER")
+	if _, err := FromEnv(getenv); err == nil {
+		t.Fatal("expected an error for a provider without issuer")
+	}
+	env["OIDC_PROVIDERS"] = "exchange"
+	env["OIDC_EXCHANGE_ISSUER"] = "https://sso.acme.test"
+	env["OIDC_EXCHANGE_CLIENT_ID"] = "x"
+	if _, err := FromEnv(getenv); err == nil {
+		t.Fatal("expected an error for a reserved provider name")
+	}
+}
@@ -0,0 +1,111 @@
+package oauth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// provider names are path segments in /auth/oauth/{provider}/...
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,49}$`)
+
+// reservedNames are taken by sibling routes.
+var reservedNames = map[string]bool{"exchange": true, "providers": true}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]Provider
+	order     []string
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: map[string]Provider{}}
+}
+
+func (r *Registry) Register(p Provider) error {
+	name := p.Name()
+	if !validName.MatchString(name) || reservedNames[name] {
+		return fmt.Errorf("oauth: invalid provider name %q", name)
+	}
+	if _, ok := r.providers[name]; ok {
+		return fmt.Errorf("oauth: provider %q registered twice", name)
+	}
+	r.providers[name] = p
+	r.order = append(r.order, name)
+	return nil
+}
+
+func (r *Registry) Get(name string) (Provider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names lists the providers in registration order.
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+// FromEnv builds the registry from the environment (getenv is envy.Get in
+// the app). A provider is enabled when its client id is set:
+//
+//	GOOGLE_CLIENT_ID, GOOGLE_CLIENT_SECRET, GOOGLE_REDIRECT_URI
+//	GITHUB_CLIENT_ID, GITHUB_CLIENT_SECRET, GITHUB_REDIRECT_URI
+//	MICROSOFT_CLIENT_ID, MICROSOFT_CLIENT_SECRET, MICROSOFT_REDIRECT_URI, MICROSOFT_TENANT
+//
+// Generic OIDC issuers are listed in OIDC_PROVIDERS (e.g. "okta,keycloak")
+// and each one reads OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _REDIRECT_URI and _SCOPES. Redirect URIs default to
+// OAUTH_CALLBACK_URL/<name>/callback.
+func FromEnv(getenv func(key, fallback string) string) (*Registry, error) {
+	callbackURL := strings.TrimRight(getenv("OAUTH_CALLBACK_URL", "http://localhost:8000/api/v1/auth/oauth"), "/")
+	config := func(prefix, name string) (Config, bool) {
+		cfg := Config{
+			ClientID:     getenv(prefix+"_CLIENT_ID", ""),
+			ClientSecret: getenv(prefix+"_CLIENT_SECRET", ""),
+			RedirectURL:  getenv(prefix+"_REDIRECT_URI", callbackURL+"/"+name+"/callback"),
+			Scopes:       strings.FieldsFunc(getenv(prefix+"_SCOPES", ""), isScopeSep),
+		}
+		return cfg, cfg.ClientID != ""
+	}
+
+	registry := NewRegistry()
+	var providers []Provider
+
+	if cfg, ok := config("GOOGLE", "google"); ok {
+		providers = append(providers, NewGoogle(cfg))
+	}
+	if cfg, ok := config("GITHUB", "github"); ok {
+		providers = append(providers, NewGitHub(cfg))
+	}
+	if cfg, ok := config("MICROSOFT", "microsoft"); ok {
+		providers = append(providers, NewEntra(getenv("MICROSOFT_TENANT", "common"), cfg))
+	}
+
+	for _, name := range strings.Split(getenv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		issuer := getenv(prefix+"_ISSUER", "")
+		if issuer == "" {
+			return nil, fmt.Errorf("oauth: %s_ISSUER is required for provider %q", prefix, name)
+		}
+		cfg, ok := config(prefix, name)
+		if !ok {
+			return nil, fmt.Errorf("oauth: %s_CLIENT_ID is required for provider %q", prefix, name)
+		}
+		providers = append(providers, NewOIDC(name, issuer, cfg))
+	}
+
+	for _, p := range providers {
+		if err := registry.Register(p); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+func isScopeSep(r rune) bool {
+	return r == ' ' || r == ','
+}
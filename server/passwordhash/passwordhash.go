@@ -0,0 +1,117 @@
+// Package passwordhash hashes passwords with argon2id and verifies every
+// format stored in auth.users.password_hash: argon2id with the current or
+// older parameters, the hex encoded argon2id hashes written before this
+// package existed, and bcrypt hashes imported from legacy systems. Verify
+// reports when a hash is not in the current format so callers can replace
+// it once they know the password.
+package passwordhash
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/gobuffalo/envy"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Params are the argon2id parameters of new hashes, from ARGON2_MEMORY (KiB),
+// ARGON2_ITERATIONS and ARGON2_PARALLELISM.
+var Params = &argon2id.Params{
+	Memory:      envUint("ARGON2_MEMORY", 64*1024),
+	Iterations:  envUint("ARGON2_ITERATIONS", 1),
+	Parallelism: uint8(envUint("ARGON2_PARALLELISM", 4)),
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hash returns the argon2id hash of password with Params, in the PHC string
+// format: $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>, base64 without
+// padding.
+func Hash(password string) string {
+	hash, err := argon2id.CreateHash(password, Params)
+	if err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(fmt.Sprintf("passwordhash: %v", err))
+	}
+	return hash
+}
+
+// Verify reports whether password matches encoded, comparing in constant
+// time, and whether encoded should be replaced by Hash(password).
+func Verify(password, encoded string) (ok, rehash bool) {
+	switch {
+	case isBcrypt(encoded):
+		return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil, true
+	case isLegacyHex(encoded):
+		return verifyHex(password, encoded), true
+	}
+
+	ok, params, err := argon2id.CheckHash(password, encoded)
+	if err != nil {
+		return false, false
+	}
+	return ok, *params != *Params
+}
+
+func isBcrypt(encoded string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(encoded, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLegacyHex matches the argon2id hashes with hex salt and key. The base64
+// key of a current hash can't be read as hex: 32 bytes are 43 characters.
+func isLegacyHex(encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+	for _, part := range parts[4:] {
+		if len(part)%2 != 0 || strings.Trim(part, "0123456789abcdef") != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func verifyHex(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+
+	var memory, iterations uint32
+	var parallelism uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism); err != nil {
+		return false
+	}
+	salt, err := hex.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(parts[5])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(expected)))
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+func envUint(key string, fallback uint32) uint32 {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n == 0 {
+		log.Fatalf("[ERROR] invalid %s=%q", key, value)
+	}
+	return uint32(n)
+}
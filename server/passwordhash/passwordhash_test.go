@@ -0,0 +1,74 @@
+package passwordhash
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/argon2id"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func withParams(t *testing.T, p argon2id.Params) {
+	previous := Params
+	Params = &p
+	t.Cleanup(func() { Params = previous })
+}
+
+func Test_Verify(t *testing.T) {
+	withParams(t, argon2id.Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	hash := Hash("correct-horse-battery")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("unexpected hash %q", hash)
+	}
+	if ok, rehash := Verify("correct-horse-battery", hash); !ok || rehash {
+		t.Errorf("expected match without rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+	if ok, _ := Verify("correct-horse-batterY", hash); ok {
+		t.Error("expected a wrong password to fail")
+	}
+
+	// the stored parameters are honoured after the config changes
+	withParams(t, argon2id.Params{Memory: 2048, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+	if ok, rehash := Verify("correct-horse-battery", hash); !ok || !rehash {
+		t.Errorf("expected match with rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+
+	for _, invalid := range []string{"", "plaintext", "$argon2id$v=19$m=1024$x$y", "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"} {
+		if ok, _ := Verify("correct-horse-battery", invalid); ok {
+			t.Errorf("expected %q to fail", invalid)
+		}
+	}
+}
+
+func Test_Verify_Legacy(t *testing.T) {
+	withParams(t, argon2id.Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	// hex salt and key, as hashPassword wrote them before
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	key := argon2.IDKey([]byte("correct-horse-battery"), salt, 1, 1024, 1, 32)
+	legacy := fmt.Sprintf("$argon2id$v=19$m=1024,t=1,p=1$%s$%s", hex.EncodeToString(salt), hex.EncodeToString(key))
+
+	if ok, rehash := Verify("correct-horse-battery", legacy); !ok || !rehash {
+		t.Errorf("legacy hex: expected match with rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+	if ok, _ := Verify("wrong", legacy); ok {
+		t.Error("legacy hex: expected a wrong password to fail")
+	}
+
+	imported, err := bcrypt.GenerateFromPassword([]byte("correct-horse-battery"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, rehash := Verify("correct-horse-battery", string(imported)); !ok || !rehash {
+		t.Errorf("bcrypt: expected match with rehash, got ok=%v rehash=%v", ok, rehash)
+	}
+	if ok, _ := Verify("wrong", string(imported)); ok {
+		t.Error("bcrypt: expected a wrong password to fail")
+	}
+}
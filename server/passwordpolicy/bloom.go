@@ -0,0 +1,136 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Bloom is a bloom filter over SHA-1 digests, the format of the Have I Been
+// Pwned password dump. A hit means the password is probably breached, a miss
+// means it certainly isn't in the set the filter was built from.
+//
+// On disk it is "RBLM", a uint32 version, uint32 k, uint64 m and m/64 uint64
+// words, all little endian.
+type Bloom struct {
+	k    uint32
+	m    uint64
+	bits []uint64
+}
+
+var bloomMagic = [4]byte{'R', 'B', 'L', 'M'}
+
+const bloomVersion = 1
+
+// NewBloom sizes a filter for n digests with false positive rate fpRate.
+func NewBloom(n uint64, fpRate float64) *Bloom {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) / 64 * 64
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &Bloom{k: k, m: m, bits: make([]uint64, m/64)}
+}
+
+// Add inserts a SHA-1 digest.
+func (b *Bloom) Add(digest [sha1.Size]byte) {
+	h1, h2 := bloomHashes(digest)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		bit := (h1 + i*h2) % b.m
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test reports whether the digest is probably in the filter.
+func (b *Bloom) Test(digest [sha1.Size]byte) bool {
+	h1, h2 := bloomHashes(digest)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// SHA-1 is already uniform, so two of its words are enough for double
+// hashing.
+func bloomHashes(digest [sha1.Size]byte) (uint64, uint64) {
+	h1 := binary.LittleEndian.Uint64(digest[0:8])
+	h2 := binary.LittleEndian.Uint64(digest[8:16]) | 1
+	return h1, h2
+}
+
+// WriteTo saves the filter in the file format.
+func (b *Bloom) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := struct {
+		Magic   [4]byte
+		Version uint32
+		K       uint32
+		M       uint64
+	}{bloomMagic, bloomVersion, b.k, b.m}
+	if err := binary.Write(bw, binary.LittleEndian, header); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, b.bits); err != nil {
+		return 0, err
+	}
+	return int64(binary.Size(header) + 8*len(b.bits)), bw.Flush()
+}
+
+// ReadBloom loads a filter written by WriteTo.
+func ReadBloom(r io.Reader) (*Bloom, error) {
+	br := bufio.NewReader(r)
+	var header struct {
+		Magic   [4]byte
+		Version uint32
+		K       uint32
+		M       uint64
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading bloom header: %w", err)
+	}
+	if header.Magic != bloomMagic {
+		return nil, errors.New("not a bloom filter file")
+	}
+	if header.Version != bloomVersion {
+		return nil, fmt.Errorf("unsupported bloom filter version %d", header.Version)
+	}
+	if header.K == 0 || header.M == 0 || header.M%64 != 0 {
+		return nil, errors.New("corrupt bloom filter header")
+	}
+
+	b := &Bloom{k: header.K, m: header.M, bits: make([]uint64, header.M/64)}
+	if err := binary.Read(br, binary.LittleEndian, b.bits); err != nil {
+		return nil, fmt.Errorf("reading bloom bits: %w", err)
+	}
+	return b, nil
+}
+
+// AddHIBP adds every line of a Have I Been Pwned SHA-1 dump
+// ("SHA1HEX:COUNT") and returns how many digests it added.
+func (b *Bloom) AddHIBP(r io.Reader) (uint64, error) {
+	var added uint64
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+		var digest [sha1.Size]byte
+		if n, err := hex.Decode(digest[:], []byte(hash)); err != nil || n != sha1.Size {
+			return added, fmt.Errorf("line %d: invalid SHA-1 %q", line, hash)
+		}
+		b.Add(digest)
+		added++
+	}
+	return added, scanner.Err()
+}
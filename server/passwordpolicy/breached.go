@@ -0,0 +1,39 @@
+package passwordpolicy
+
+import (
+	"crypto/sha1"
+	"log"
+	"os"
+
+	"github.com/gobuffalo/envy"
+)
+
+// breached is the filter at BREACHED_PASSWORDS_FILE, nil when the check is
+// disabled.
+var breached = loadBreached(envy.Get("BREACHED_PASSWORDS_FILE", ""))
+
+func loadBreached(path string) *Bloom {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("[ERROR] BREACHED_PASSWORDS_FILE: %v", err)
+	}
+	defer f.Close()
+
+	b, err := ReadBloom(f)
+	if err != nil {
+		log.Fatalf("[ERROR] BREACHED_PASSWORDS_FILE %s: %v", path, err)
+	}
+	return b
+}
+
+// IsBreached reports whether password is probably in the breached password
+// filter. It is always false when no filter is configured.
+func IsBreached(password string) bool {
+	if breached == nil {
+		return false
+	}
+	return breached.Test(sha1.Sum([]byte(password)))
+}
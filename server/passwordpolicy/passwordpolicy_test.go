@@ -0,0 +1,118 @@
+package passwordpolicy
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func Test_Estimate(t *testing.T) {
+	weak := []string{"password", "Password1", "p@ssw0rd", "qwerty123", "aaaaaaaa", "abcdefgh", "19901990", "abcabcabcabc"}
+	for _, p := range weak {
+		if s := Estimate(p); s.Score > 0 {
+			t.Errorf("%q: expected score 0, got %d", p, s.Score)
+		}
+	}
+
+	strong := []string{"kX9#mP2$vL", "mi-gato-se-llama-tom", "xK7pQ2mL!r"}
+	for _, p := range strong {
+		if s := Estimate(p); s.Score < 4 {
+			t.Errorf("%q: expected score 4, got %d", p, s.Score)
+		}
+	}
+
+	// user inputs are the cheapest words
+	if with, without := Estimate("zorritoperez", "perez"), Estimate("zorritoperez"); with.Guesses >= without.Guesses {
+		t.Errorf("user input didn't lower the estimate: %v >= %v", with.Guesses, without.Guesses)
+	}
+}
+
+func Test_Policy_Check(t *testing.T) {
+	p := Policy{MinLength: 8, MaxLength: 64, MinScore: 3}
+
+	codes := func(vs []Violation) string {
+		var c []string
+		for _, v := range vs {
+			c = append(c, v.Code)
+		}
+		return strings.Join(c, ",")
+	}
+
+	cases := []struct {
+		password string
+		expected string
+	}{
+		{"kX9#mP2$vL", ""},
+		{"abc", "too_short,too_weak"},
+		{strings.Repeat("kX9#", 20), "too_long"},
+		{"Perez#kX9mP2$", "contains_personal_info"},
+		{"ANA.kX9#mP2$vL", "contains_personal_info"},
+		{"p3r3z#kX9mP2$", "contains_personal_info"},
+		{"password123", "too_weak"},
+	}
+	for _, tc := range cases {
+		got := codes(p.Check(tc.password, "Ana", "Pérez", "ana.perez@example.com"))
+		if got != tc.expected {
+			t.Errorf("%q: expected [%s], got [%s]", tc.password, tc.expected, got)
+		}
+	}
+
+	// the domain of the email counts, common TLDs don't
+	if got := codes(p.Check("example#kX9mP2$", "ana@example.com")); got != PersonalInfo {
+		t.Errorf("expected the email domain to be personal info, got [%s]", got)
+	}
+	if got := codes(p.Check("com#kX9mP2$vL", "ana@example.com")); got != "" {
+		t.Errorf("expected no violations, got [%s]", got)
+	}
+}
+
+func Test_Bloom(t *testing.T) {
+	var dump bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))
+		fmt.Fprintf(&dump, "%s:%d\r\n", strings.ToUpper(hex.EncodeToString(sum[:])), i+1)
+	}
+
+	b := NewBloom(1000, 0.001)
+	added, err := b.AddHIBP(&dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 1000 {
+		t.Fatalf("expected 1000 digests, got %d", added)
+	}
+
+	var file bytes.Buffer
+	if _, err := b.WriteTo(&file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := ReadBloom(&file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if !loaded.Test(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))) {
+			t.Fatalf("breached-%d not found", i)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if loaded.Test(sha1.Sum([]byte(fmt.Sprintf("safe-%d", i)))) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Errorf("too many false positives: %d in 10000", falsePositives)
+	}
+
+	if _, err := ReadBloom(strings.NewReader("not a filter")); err == nil {
+		t.Error("expected an error for an invalid file")
+	}
+	if _, err := NewBloom(1, 0.01).AddHIBP(strings.NewReader("nothex:1\n")); err == nil {
+		t.Error("expected an error for an invalid dump line")
+	}
+}
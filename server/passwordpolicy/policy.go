@@ -0,0 +1,166 @@
+// Package passwordpolicy decides whether a new password is acceptable:
+// length, estimated strength, personal information and the offline list of
+// breached passwords. Reuse of previous passwords needs the database and is
+// checked by the caller against Policy.HistorySize.
+package passwordpolicy
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gobuffalo/envy"
+)
+
+// Policy is the set of rules a new password must follow.
+type Policy struct {
+	MinLength int
+	MaxLength int
+
+	// MinScore is the lowest accepted Estimate score, 0 to 4 (0 disables).
+	MinScore int
+
+	// HistorySize is how many previous passwords can't be reused (0
+	// disables).
+	HistorySize int
+}
+
+// Default is configured with PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH,
+// PASSWORD_MIN_SCORE and PASSWORD_HISTORY.
+var Default = Policy{
+	MinLength:   envInt("PASSWORD_MIN_LENGTH", 8),
+	MaxLength:   envInt("PASSWORD_MAX_LENGTH", 128),
+	MinScore:    envInt("PASSWORD_MIN_SCORE", 3),
+	HistorySize: envInt("PASSWORD_HISTORY", 5),
+}
+
+// Violation is one rule a password breaks, returned to clients in the
+// error details.
+type Violation struct {
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// Violation codes.
+const (
+	TooShort     = "too_short"
+	TooLong      = "too_long"
+	TooWeak      = "too_weak"
+	PersonalInfo = "contains_personal_info"
+	Breached     = "breached"
+	Reused       = "reused"
+)
+
+// ReusedViolation is reported by callers that find the password in the
+// user's history.
+func (p Policy) ReusedViolation() Violation {
+	return Violation{
+		Code:    Reused,
+		Message: fmt.Sprintf("Password must not be one of your last %d passwords", p.HistorySize),
+		Params:  map[string]any{"history": p.HistorySize},
+	}
+}
+
+// Check returns the rules password breaks. userInputs are the user's name,
+// last name and email, which the password must not contain.
+func (p Policy) Check(password string, userInputs ...string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Code:    TooShort,
+			Message: fmt.Sprintf("Password must be at least %d characters", p.MinLength),
+			Params:  map[string]any{"min_length": p.MinLength},
+		})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		// nothing else is estimated on very long input
+		return append(violations, Violation{
+			Code:    TooLong,
+			Message: fmt.Sprintf("Password must be at most %d characters", p.MaxLength),
+			Params:  map[string]any{"max_length": p.MaxLength},
+		})
+	}
+
+	tokens := personalTokens(userInputs)
+	if containsToken(password, tokens) {
+		violations = append(violations, Violation{
+			Code:    PersonalInfo,
+			Message: "Password must not contain your name or email",
+		})
+	}
+
+	if p.MinScore > 0 {
+		strength := Estimate(password, tokens...)
+		if strength.Score < p.MinScore {
+			violations = append(violations, Violation{
+				Code:    TooWeak,
+				Message: "Password is too easy to guess",
+				Params: map[string]any{
+					"score":     strength.Score,
+					"min_score": p.MinScore,
+				},
+			})
+		}
+	}
+
+	if IsBreached(password) {
+		violations = append(violations, Violation{
+			Code:    Breached,
+			Message: "Password appeared in a data breach, choose a different one",
+		})
+	}
+
+	return violations
+}
+
+// personalTokens splits names and emails into the words a password must not
+// contain, e.g. "ana.perez@redorange.pe" gives ana, perez and redorange.
+func personalTokens(inputs []string) []string {
+	var tokens []string
+	seen := map[string]bool{}
+	for _, input := range inputs {
+		parts := strings.FieldsFunc(strings.ToLower(input), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, part := range parts {
+			if utf8.RuneCountInString(part) < 3 || seen[part] || commonTLDs[part] {
+				continue
+			}
+			seen[part] = true
+			tokens = append(tokens, part)
+		}
+	}
+	return tokens
+}
+
+var commonTLDs = map[string]bool{"com": true, "net": true, "org": true, "edu": true, "gob": true}
+
+func containsToken(password string, tokens []string) bool {
+	lower := strings.ToLower(password)
+	unleeted := unleet(lower)
+	for _, t := range tokens {
+		if strings.Contains(lower, t) || strings.Contains(unleeted, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func envInt(key string, fallback int) int {
+	value := envy.Get(key, "")
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("[WARN] invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,360 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Strength estimates how many guesses an attacker needs, in the spirit of
+// zxcvbn: the password is split into the cheapest sequence of patterns
+// (common words, keyboard walks, sequences, repeats, dates and brute
+// force) and the guesses of each part are multiplied.
+type Strength struct {
+	Guesses float64 `json:"guesses"`
+	// Score is 0 (too guessable) to 4 (very unguessable), with zxcvbn's
+	// thresholds.
+	Score int `json:"score"`
+}
+
+// Estimate scores password. userInputs are words specific to the user or
+// the site that count as the most common words.
+func Estimate(password string, userInputs ...string) Strength {
+	guesses := estimateGuesses([]rune(password), userInputs)
+	return Strength{Guesses: guesses, Score: score(guesses)}
+}
+
+func score(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0
+	case guesses < 1e6+delta:
+		return 1
+	case guesses < 1e8+delta:
+		return 2
+	case guesses < 1e10+delta:
+		return 3
+	default:
+		return 4
+	}
+}
+
+//go:embed common.txt
+var commonList string
+
+// dictionary ranks common passwords and words, most common first.
+var dictionary = func() map[string]int {
+	ranked := map[string]int{}
+	scanner := bufio.NewScanner(strings.NewReader(commonList))
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, exists := ranked[word]; !exists {
+			ranked[word] = len(ranked) + 1
+		}
+	}
+	return ranked
+}()
+
+var keyboardRows = []string{
+	"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./",
+	"1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik,9ol.0p;/", "qazwsxedcrfvtgbyhnujmikolp",
+}
+
+var leet = strings.NewReplacer("4", "a", "@", "a", "8", "b", "(", "c", "3", "e", "6", "g", "1", "i", "!", "i", "|", "l", "0", "o", "$", "s", "5", "s", "7", "t", "+", "t", "2", "z")
+
+func unleet(s string) string { return leet.Replace(s) }
+
+type match struct {
+	i, j    int // runes [i, j)
+	guesses float64
+}
+
+const (
+	maxGuesses = 1e300
+
+	// from zxcvbn: per-match floors and the cost of every extra part
+	minSingleGuesses = 10
+	minMultiGuesses  = 50
+	growingSequence  = 10000
+)
+
+// estimateGuesses finds the sequence of matches covering the password with
+// the fewest guesses, l! * product(guesses) + 10000^(l-1) for l parts.
+func estimateGuesses(runes []rune, userInputs []string) float64 {
+	n := len(runes)
+	if n == 0 {
+		return 1
+	}
+
+	matches := findMatches(runes, userInputs)
+	card := float64(cardinality(runes))
+
+	// best[j][l] is the lowest product covering runes[:j] with l parts
+	best := make([][]float64, n+1)
+	for j := range best {
+		best[j] = make([]float64, n+1)
+		for l := range best[j] {
+			best[j][l] = math.Inf(1)
+		}
+	}
+	best[0][0] = 1
+
+	byEnd := make([][]match, n+1)
+	for _, m := range matches {
+		byEnd[m.j] = append(byEnd[m.j], m)
+	}
+
+	for j := 1; j <= n; j++ {
+		candidates := byEnd[j]
+		for i := 0; i < j; i++ {
+			candidates = append(candidates, match{i: i, j: j, guesses: bruteforceGuesses(card, j-i)})
+		}
+		for _, m := range candidates {
+			g := m.guesses
+			if m.j-m.i == 1 {
+				g = math.Max(g, minSingleGuesses)
+			} else {
+				g = math.Max(g, minMultiGuesses)
+			}
+			for l := 0; l < j; l++ {
+				if math.IsInf(best[m.i][l], 1) {
+					continue
+				}
+				if p := math.Min(best[m.i][l]*g, maxGuesses); p < best[j][l+1] {
+					best[j][l+1] = p
+				}
+			}
+		}
+	}
+
+	total := math.Inf(1)
+	for l := 1; l <= n; l++ {
+		if math.IsInf(best[n][l], 1) {
+			continue
+		}
+		g := factorial(l)*best[n][l] + math.Pow(growingSequence, float64(l-1))
+		total = math.Min(total, g)
+	}
+	return math.Min(total, maxGuesses)
+}
+
+func findMatches(runes []rune, userInputs []string) []match {
+	n := len(runes)
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != n {
+		// case mapping changed the length, match on the original
+		lower = runes
+	}
+
+	inputs := map[string]bool{}
+	for _, in := range userInputs {
+		inputs[strings.ToLower(in)] = true
+	}
+
+	var matches []match
+	for i := 0; i < n; i++ {
+		for j := i + 3; j <= n; j++ {
+			word := string(lower[i:j])
+			original := string(runes[i:j])
+
+			if inputs[word] {
+				matches = append(matches, match{i, j, upperVariations(original)})
+			}
+			if rank, ok := dictionary[word]; ok {
+				matches = append(matches, match{i, j, float64(rank) * upperVariations(original)})
+			}
+			if plain := unleet(word); plain != word {
+				if inputs[plain] {
+					matches = append(matches, match{i, j, 2 * upperVariations(original)})
+				}
+				if rank, ok := dictionary[plain]; ok {
+					matches = append(matches, match{i, j, float64(rank) * 2 * upperVariations(original)})
+				}
+			}
+			if rank, ok := dictionary[reverse(word)]; ok {
+				matches = append(matches, match{i, j, float64(rank) * 2 * upperVariations(original)})
+			}
+
+			if j-i >= 4 && onKeyboard(word) {
+				matches = append(matches, match{i, j, 40 * float64(j-i)})
+			}
+			if g, ok := dateGuesses(word); ok {
+				matches = append(matches, match{i, j, g})
+			}
+		}
+	}
+
+	matches = append(matches, sequences(lower)...)
+	matches = append(matches, repeats(lower)...)
+	return matches
+}
+
+// upperVariations is how many ways the capitalisation could have been
+// chosen: all lower, first or all upper are cheap guesses.
+func upperVariations(word string) float64 {
+	upper, lowerCount := 0, 0
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lowerCount++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	first := []rune(word)[0]
+	if lowerCount == 0 || (upper == 1 && unicode.IsUpper(first)) {
+		return 2
+	}
+	variations := 0.0
+	for k := 1; k <= min(upper, lowerCount); k++ {
+		variations += binomial(upper+lowerCount, k)
+	}
+	return variations
+}
+
+func onKeyboard(word string) bool {
+	for _, row := range keyboardRows {
+		if strings.Contains(row, word) || strings.Contains(row, reverse(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dateGuesses covers years (1900-2039) and dates written with digits only,
+// like 1990, 250190 or 25011990.
+func dateGuesses(word string) (float64, bool) {
+	for _, r := range word {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	switch len(word) {
+	case 4:
+		if (word[:2] == "19") || (word[:2] == "20" && word[2] <= '3') {
+			return 140, true
+		}
+	case 6:
+		return 365 * 100, true
+	case 8:
+		return 365 * 140, true
+	}
+	return 0, false
+}
+
+// sequences finds runs like abcd, 2468 or 9876 with a constant step.
+func sequences(runes []rune) []match {
+	var matches []match
+	n := len(runes)
+	for i := 0; i < n-2; {
+		step := runes[i+1] - runes[i]
+		j := i + 2
+		for j < n && runes[j]-runes[j-1] == step {
+			j++
+		}
+		if j-i >= 3 && step != 0 && step >= -5 && step <= 5 {
+			base := 26.0
+			switch first := runes[i]; {
+			case first == 'a' || first == 'z' || first == '0' || first == '1' || first == '9':
+				base = 4
+			case unicode.IsDigit(first):
+				base = 10
+			}
+			if step < 0 {
+				base *= 2
+			}
+			matches = append(matches, match{i, j, base * float64(j-i)})
+			i = j - 1
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// repeats finds a rune or a block repeated back to back, like aaaa or
+// abcabc.
+func repeats(runes []rune) []match {
+	var matches []match
+	n := len(runes)
+	for i := 0; i < n; i++ {
+		for size := 1; size <= (n-i)/2; size++ {
+			count := 1
+			for i+(count+1)*size <= n && string(runes[i:i+size]) == string(runes[i+count*size:i+(count+1)*size]) {
+				count++
+			}
+			if count < 2 || (size == 1 && count < 3) {
+				continue
+			}
+			block := estimateGuesses(runes[i:i+size], nil)
+			matches = append(matches, match{i, i + count*size, block * float64(count)})
+		}
+	}
+	return matches
+}
+
+func bruteforceGuesses(card float64, length int) float64 {
+	return math.Min(math.Pow(card, float64(length)), maxGuesses)
+}
+
+// cardinality is the size of the alphabet the password draws from.
+func cardinality(runes []rune) int {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < 128:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	card := 0
+	for _, c := range []struct {
+		set  bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.set {
+			card += c.size
+		}
+	}
+	return card
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r *= float64(n-k+i) / float64(i)
+	}
+	return r
+}
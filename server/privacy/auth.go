@@ -0,0 +1,101 @@
+package privacy
+
+import (
+	"server/models"
+
+	"github.com/gobuffalo/pop/v6"
+)
+
+// Auth tables reference auth.users ON DELETE CASCADE, except login attempts
+// and audit events, which are kept anonymised for security statistics.
+func init() {
+	Register(Section{
+		Name: "profile",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			return user, nil
+		},
+	})
+	Register(Section{
+		Name: "sessions",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			sessions := models.Sessions{}
+			err := db.Where("user_id = ?", user.ID).Order("created_at DESC").All(&sessions)
+			return sessions, err
+		},
+	})
+	Register(Section{
+		Name: "login_history",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			attempts := models.LoginAttempts{}
+			err := db.Where("user_id = ?", user.ID).Order("created_at DESC").All(&attempts)
+			return attempts, err
+		},
+		Erase: anonymiseLoginAttempts,
+	})
+	Register(Section{
+		Name: "security_events",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			events := models.AuditEvents{}
+			err := db.Where("target_id = ?", user.ID).Order("created_at DESC").All(&events)
+			return events, err
+		},
+		Erase: anonymiseAuditEvents,
+	})
+	Register(Section{
+		Name: "oauth_providers",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			providers := models.OAuthProviders{}
+			err := db.Where("user_id = ?", user.ID).Order("created_at").All(&providers)
+			return providers, err
+		},
+	})
+	Register(Section{
+		Name: "personal_access_tokens",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			tokens := models.PersonalAccessTokens{}
+			err := db.Where("user_id = ?", user.ID).Order("created_at").All(&tokens)
+			return tokens, err
+		},
+	})
+	Register(Section{
+		Name: "passkeys",
+		Export: func(db *pop.Connection, user models.User) (any, error) {
+			passkeys := models.WebAuthnCredentials{}
+			err := db.Where("user_id = ?", user.ID).Order("created_at").All(&passkeys)
+			return passkeys, err
+		},
+	})
+}
+
+// anonymiseLoginAttempts keeps outcome and time of the user's attempts and
+// drops everything that identifies them, including failed attempts made
+// with the email before it had an account.
+func anonymiseLoginAttempts(tx *pop.Connection, user models.User) error {
+	return tx.RawQuery(`
+		UPDATE auth.login_attempts
+		SET user_id = NULL, email = NULL, ip_address = NULL, user_agent = NULL,
+			browser = NULL, browser_version = NULL, os = NULL, os_version = NULL, device_type = NULL,
+			country_code = NULL, country = NULL, city = NULL, asn = NULL, as_org = NULL
+		WHERE user_id = ? OR email = ?
+	`, user.ID, user.Email).Exec()
+}
+
+// anonymiseAuditEvents keeps type and time of the events about the user, of
+// failed logins with their email and of the changes they made to others (as
+// an admin), dropping the ids, network data and, except for changes to
+// others, the metadata. It is the only update the append-only trigger on
+// auth.audit_events lets through.
+func anonymiseAuditEvents(tx *pop.Connection, user models.User) error {
+	return tx.RawQuery(`
+		UPDATE auth.audit_events
+		SET actor_id = NULLIF(actor_id, ?),
+			target_id = NULLIF(target_id, ?),
+			ip_address = NULL,
+			user_agent = NULL,
+			metadata = CASE
+				WHEN target_id = ? OR metadata->>'email' = ? THEN '{}'::jsonb
+				ELSE metadata
+			END
+		WHERE actor_id = ? OR target_id = ? OR metadata->>'email' = ?
+	`, user.ID, user.ID, user.ID, user.Email, user.ID, user.ID, user.Email).Exec()
+}
@@ -0,0 +1,108 @@
+package privacy
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"server/jobs"
+	"server/models"
+
+	"github.com/gobuffalo/buffalo/worker"
+	"github.com/gobuffalo/envy"
+	"github.com/gobuffalo/pop/v6"
+	"github.com/gofrs/uuid"
+)
+
+// GracePeriod is how long a deletion request can be cancelled before the
+// account is erased (ACCOUNT_DELETION_GRACE_DAYS, default 30).
+var GracePeriod = envDays("ACCOUNT_DELETION_GRACE_DAYS", 30)
+
+// DeletionJob is the jobs handler that erases accounts whose grace period
+// is over.
+const DeletionJob = "privacy:delete-accounts"
+
+func init() {
+	jobs.Register(DeletionJob, func(worker.Args) error {
+		_, err := DeleteDue(models.DB, time.Now().UTC())
+		return err
+	})
+	jobs.Every(time.Hour, worker.Job{Queue: "maintenance", Handler: DeletionJob})
+}
+
+// DeleteDue erases every account scheduled for deletion at or before now
+// and returns how many were erased.
+func DeleteDue(db *pop.Connection, now time.Time) (int, error) {
+	var ids []uuid.UUID
+	err := db.RawQuery(`
+		SELECT id FROM auth.users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+	`, now).All(&ids)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		ok, err := DeleteAccount(db, id, now)
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		log.Printf("[PRIVACY] deleted %d accounts", deleted)
+	}
+	return deleted, nil
+}
+
+// DeleteAccount erases the account if it is still scheduled for deletion
+// at now: every section's Erase runs and the user row is deleted, taking
+// the cascading auth tables with it. It reports whether it was erased.
+func DeleteAccount(db *pop.Connection, userID uuid.UUID, now time.Time) (bool, error) {
+	deleted := false
+	err := db.Transaction(func(tx *pop.Connection) error {
+		var user models.User
+		// the lock makes a concurrent cancellation wait for us, or win
+		err := tx.RawQuery(`
+			SELECT * FROM auth.users
+			WHERE id = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+			FOR UPDATE
+		`, userID, now).First(&user)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, s := range registered() {
+			if s.Erase == nil {
+				continue
+			}
+			if err := s.Erase(tx, user); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Destroy(&user); err != nil {
+			return err
+		}
+		deleted = true
+		return nil
+	})
+	return deleted, err
+}
+
+func envDays(key string, fallback int) time.Duration {
+	days, err := strconv.Atoi(envy.Get(key, strconv.Itoa(fallback)))
+	if err != nil || days < 0 {
+		log.Printf("[WARN] invalid %s, using %d days", key, fallback)
+		days = fallback
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
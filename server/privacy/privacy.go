@@ -0,0 +1,110 @@
+// Package privacy implements the rights of Peru's personal data protection
+// law (Ley 29733) over an account: exporting everything stored about the
+// user and erasing it after a grace period.
+//
+// The data lives in sections. Auth registers its own below; modules with
+// business records about a user (tech, infra, digital) register theirs
+// from init() so they are exported and erased with the account.
+package privacy
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"server/models"
+
+	"github.com/gobuffalo/pop/v6"
+)
+
+// Section is one part of a user's data.
+type Section struct {
+	Name string
+
+	// Export returns the section's data, encoded as JSON in the archive.
+	Export func(db *pop.Connection, user models.User) (any, error)
+
+	// Erase removes or anonymises the section before the user row is
+	// deleted. Sections fully covered by ON DELETE CASCADE leave it nil.
+	Erase func(tx *pop.Connection, user models.User) error
+}
+
+var (
+	sectionsMu sync.RWMutex
+	sections   []Section
+)
+
+// Register adds s to every export and deletion.
+func Register(s Section) {
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	for _, existing := range sections {
+		if existing.Name == s.Name {
+			panic(fmt.Sprintf("privacy: section %q already registered", s.Name))
+		}
+	}
+	sections = append(sections, s)
+}
+
+func registered() []Section {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	return append([]Section(nil), sections...)
+}
+
+// Archive is a user's data by section name.
+type Archive struct {
+	ExportedAt time.Time
+	Sections   map[string]any
+}
+
+// Export collects every registered section for user.
+func Export(db *pop.Connection, user models.User) (Archive, error) {
+	archive := Archive{ExportedAt: time.Now().UTC(), Sections: map[string]any{}}
+	for _, s := range registered() {
+		data, err := s.Export(db, user)
+		if err != nil {
+			return archive, fmt.Errorf("export %s: %w", s.Name, err)
+		}
+		archive.Sections[s.Name] = data
+	}
+	return archive, nil
+}
+
+func (a Archive) MarshalJSON() ([]byte, error) {
+	out := map[string]any{"exported_at": a.ExportedAt}
+	for name, data := range a.Sections {
+		out[name] = data
+	}
+	return json.Marshal(out)
+}
+
+// WriteZip writes the archive as a zip with one JSON file per section.
+func (a Archive) WriteZip(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	for _, s := range registered() {
+		data, ok := a.Sections[s.Name]
+		if !ok {
+			continue
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     s.Name + ".json",
+			Method:   zip.Deflate,
+			Modified: a.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
@@ -0,0 +1,67 @@
+package privacy
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+)
+
+func Test_Archive(t *testing.T) {
+	archive := Archive{
+		ExportedAt: time.Date(2026, 2, 24, 10, 0, 0, 0, time.UTC),
+		Sections: map[string]any{
+			"profile":  map[string]string{"email": "ana@example.com"},
+			"sessions": []string{},
+		},
+	}
+
+	raw, err := json.Marshal(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["exported_at"] != "2026-02-24T10:00:00Z" {
+		t.Errorf("unexpected exported_at %v", doc["exported_at"])
+	}
+	if profile, ok := doc["profile"].(map[string]any); !ok || profile["email"] != "ana@example.com" {
+		t.Errorf("unexpected profile %v", doc["profile"])
+	}
+
+	var buf bytes.Buffer
+	if err := archive.WriteZip(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	// sections missing from the archive are skipped
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %v", files)
+	}
+	var profile map[string]string
+	if err := json.Unmarshal([]byte(files["profile.json"]), &profile); err != nil || profile["email"] != "ana@example.com" {
+		t.Errorf("unexpected profile.json %q", files["profile.json"])
+	}
+	if _, ok := files["sessions.json"]; !ok {
+		t.Error("missing sessions.json")
+	}
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/gobuffalo/pop/v6"
+)
+
+// PostgresStore keeps buckets in auth.rate_limits so every instance shares
+// them. db must not be the request transaction: a 429 rolls that back.
+type PostgresStore struct {
+	db *pop.Connection
+}
+
+func NewPostgresStore(db *pop.Connection) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Allow(key string, limit Limit) (Result, error) {
+	var res Result
+	err := s.db.Transaction(func(tx *pop.Connection) error {
+		now := time.Now().UTC()
+
+		if err := tx.RawQuery(`
+			INSERT INTO auth.rate_limits (key, tokens, updated_at, expires_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (key) DO NOTHING
+		`, key, float64(limit.Requests), now, now).Exec(); err != nil {
+			return err
+		}
+
+		var b bucket
+		if err := tx.RawQuery(`
+			SELECT tokens, updated_at FROM auth.rate_limits WHERE key = ? FOR UPDATE
+		`, key).First(&b); err != nil {
+			return err
+		}
+
+		b, res = b.take(limit, now)
+
+		return tx.RawQuery(`
+			UPDATE auth.rate_limits SET tokens = ?, updated_at = ?, expires_at = ? WHERE key = ?
+		`, b.Tokens, b.UpdatedAt, b.fullAt(limit), key).Exec()
+	})
+	return res, err
+}
@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows Requests per Period, refilled continuously (token bucket with
+// a burst of Requests).
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// rate is tokens refilled per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// ParseLimit reads "N/period", e.g. "5/15m" or "100/1h". "0" disables the limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+
+	requests, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected N/period", s)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad request count", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: bad period", s)
+	}
+	return Limit{Requests: n, Period: d}, nil
+}
+
+// Result is the outcome of a single Allow call.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Store keeps one token bucket per key.
+type Store interface {
+	Allow(key string, limit Limit) (Result, error)
+}
+
+// bucket is the state shared by every Store implementation.
+type bucket struct {
+	Tokens    float64   `db:"tokens"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// take refills b up to now and spends one token if available.
+func (b bucket) take(limit Limit, now time.Time) (bucket, Result) {
+	capacity := float64(limit.Requests)
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*limit.rate())
+	}
+	b.UpdatedAt = now
+
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return b, Result{Allowed: true, Remaining: int(b.Tokens)}
+	}
+
+	wait := time.Duration((1 - b.Tokens) / limit.rate() * float64(time.Second))
+	return b, Result{Allowed: false, RetryAfter: wait}
+}
+
+// fullAt is when b will be back to capacity, after which it can be dropped.
+func (b bucket) fullAt(limit Limit) time.Time {
+	missing := float64(limit.Requests) - b.Tokens
+	return b.UpdatedAt.Add(time.Duration(missing / limit.rate() * float64(time.Second)))
+}
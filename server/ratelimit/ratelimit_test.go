@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_ParseLimit(t *testing.T) {
+	limit, err := ParseLimit("5/15m")
+	if err != nil || limit.Requests != 5 || limit.Period != 15*time.Minute {
+		t.Fatalf("ParseLimit(5/15m) = %+v, %v", limit, err)
+	}
+
+	if limit, err := ParseLimit("0"); err != nil || limit.Enabled() {
+		t.Fatalf("ParseLimit(0) = %+v, %v", limit, err)
+	}
+
+	for _, bad := range []string{"5", "x/1m", "5/x", "5/0s", "-1/1m"} {
+		if _, err := ParseLimit(bad); err == nil {
+			t.Errorf("ParseLimit(%q) should fail", bad)
+		}
+	}
+}
+
+func Test_MemoryStore(t *testing.T) {
+	now := time.Date(2026, 2, 10, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Requests: 3, Period: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		res, _ := store.Allow("k", limit)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i, res)
+		}
+	}
+
+	res, _ := store.Allow("k", limit)
+	if res.Allowed || res.RetryAfter != 20*time.Second {
+		t.Fatalf("expected denial with 20s retry, got %+v", res)
+	}
+
+	if res, _ := store.Allow("other", limit); !res.Allowed {
+		t.Fatal("keys must not share buckets")
+	}
+
+	now = now.Add(20 * time.Second)
+	if res, _ := store.Allow("k", limit); !res.Allowed {
+		t.Fatalf("expected a refilled token, got %+v", res)
+	}
+}
@@ -0,0 +1,155 @@
+// Package useragent turns User-Agent headers into the browser, operating
+// system and kind of device they come from, for showing sessions and login
+// history to users. It recognises the common browsers and platforms and
+// leaves the rest empty; it is not meant for feature detection.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types.
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Bot     = "bot"
+)
+
+// Device is what a user agent tells about where a request came from.
+type Device struct {
+	Browser        string `json:"browser,omitempty"`
+	BrowserVersion string `json:"browser_version,omitempty"`
+	OS             string `json:"os,omitempty"`
+	OSVersion      string `json:"os_version,omitempty"`
+	Type           string `json:"device_type,omitempty"`
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// browsers go from the most specific to the most generic: Edge and Opera
+// also claim to be Chrome, which claims to be Safari.
+var browsers = []rule{
+	{"Edge", regexp.MustCompile(`(?:Edg|EdgA|EdgiOS|Edge)/([\d.]+)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|OPT|Opera)/([\d.]+)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{"Yandex", regexp.MustCompile(`YaBrowser/([\d.]+)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS|Chromium)/([\d.]+)`)},
+	{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+// bots are API clients and crawlers, named after the tool when known.
+var bots = []rule{
+	{"curl", regexp.MustCompile(`^curl/([\d.]+)`)},
+	{"Wget", regexp.MustCompile(`^Wget/([\d.]+)`)},
+	{"Postman", regexp.MustCompile(`PostmanRuntime/([\d.]+)`)},
+	{"Insomnia", regexp.MustCompile(`^insomnia/([\d.]+)`)},
+	{"HTTPie", regexp.MustCompile(`^HTTPie/([\d.]+)`)},
+	{"Python", regexp.MustCompile(`^python-(?:requests|httpx|urllib\d?)/([\d.]+)`)},
+	{"Go", regexp.MustCompile(`^Go-http-client/([\d.]+)`)},
+	{"okhttp", regexp.MustCompile(`^okhttp/([\d.]+)`)},
+	{"Bot", regexp.MustCompile(`(?i)(?:bot|crawler|spider|slurp)\b/?([\d.]*)`)},
+}
+
+var (
+	windows  = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	iOS      = regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)
+	macOS    = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+	android  = regexp.MustCompile(`Android ([\d.]+)`)
+	chromeOS = regexp.MustCompile(`CrOS \S+ ([\d.]+)`)
+)
+
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+// Parse reads a User-Agent header. Parts it doesn't recognise stay empty.
+func Parse(ua string) Device {
+	var d Device
+	ua = strings.TrimSpace(ua)
+	if ua == "" {
+		return d
+	}
+
+	for _, b := range bots {
+		if m := b.pattern.FindStringSubmatch(ua); m != nil {
+			d.Browser, d.BrowserVersion, d.Type = b.name, m[1], Bot
+			d.OS, d.OSVersion = parseOS(ua)
+			return d
+		}
+	}
+
+	for _, b := range browsers {
+		if m := b.pattern.FindStringSubmatch(ua); m != nil {
+			d.Browser, d.BrowserVersion = b.name, majorMinor(m[1])
+			break
+		}
+	}
+
+	d.OS, d.OSVersion = parseOS(ua)
+	d.Type = deviceType(ua, d.OS)
+	return d
+}
+
+func parseOS(ua string) (string, string) {
+	switch {
+	case strings.Contains(ua, "Windows Phone"):
+		return "Windows Phone", ""
+	case windows.MatchString(ua):
+		v := windows.FindStringSubmatch(ua)[1]
+		return "Windows", windowsVersions[v]
+	case iOS.MatchString(ua):
+		name := "iOS"
+		if strings.Contains(ua, "iPad") {
+			name = "iPadOS"
+		}
+		return name, strings.ReplaceAll(iOS.FindStringSubmatch(ua)[1], "_", ".")
+	case macOS.MatchString(ua):
+		return "macOS", strings.ReplaceAll(macOS.FindStringSubmatch(ua)[1], "_", ".")
+	case android.MatchString(ua):
+		return "Android", android.FindStringSubmatch(ua)[1]
+	case strings.Contains(ua, "Android"):
+		return "Android", ""
+	case chromeOS.MatchString(ua):
+		return "ChromeOS", chromeOS.FindStringSubmatch(ua)[1]
+	case strings.Contains(ua, "Linux") || strings.Contains(ua, "X11"):
+		return "Linux", ""
+	}
+	return "", ""
+}
+
+func deviceType(ua, os string) string {
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet"):
+		return Tablet
+	case os == "Android" && !strings.Contains(ua, "Mobile"):
+		// Android tablets leave Mobile out
+		return Tablet
+	case strings.Contains(ua, "Mobi") || strings.Contains(ua, "iPhone") || os == "Windows Phone":
+		return Mobile
+	case os == "Windows" || os == "macOS" || os == "Linux" || os == "ChromeOS":
+		return Desktop
+	}
+	return ""
+}
+
+// majorMinor drops patch and build numbers, which change with every update
+// and mean nothing to users: 120.0.6099.71 is 120.0.
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,58 @@
+package useragent
+
+import "testing"
+
+func Test_Parse(t *testing.T) {
+	cases := []struct {
+		ua       string
+		expected Device
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.71 Safari/537.36",
+			Device{"Chrome", "120.0", "Windows", "10", Desktop},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			Device{"Edge", "120.0", "Windows", "10", Desktop},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			Device{"Safari", "17.2", "macOS", "10.15.7", Desktop},
+		},
+		{
+			"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Device{"Firefox", "121.0", "Linux", "", Desktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			Device{"Chrome", "120.0", "iOS", "17.2.1", Mobile},
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			Device{"Safari", "16.6", "iPadOS", "16.6", Tablet},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			Device{"Samsung Internet", "23.0", "Android", "14", Mobile},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Device{"Chrome", "120.0", "Android", "13", Tablet},
+		},
+		{
+			"curl/8.5.0",
+			Device{"curl", "8.5.0", "", "", Bot},
+		},
+		{
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Device{"Bot", "2.1", "", "", Bot},
+		},
+		{"", Device{}},
+		{"something unknown", Device{}},
+	}
+	for _, tc := range cases {
+		if got := Parse(tc.ua); got != tc.expected {
+			t.Errorf("%q:\nexpected %+v\n     got %+v", tc.ua, tc.expected, got)
+		}
+	}
+}